@@ -43,6 +43,7 @@ import (
 	"github.com/gardener/machine-controller-manager/cmd/machine-controller-manager/app/options"
 	"github.com/gardener/machine-controller-manager/pkg/handlers"
 	"github.com/gardener/machine-controller-manager/pkg/util/configz"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
 	prometheus "github.com/prometheus/client_golang/prometheus/promhttp"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -255,6 +256,12 @@ func StartControllers(s *options.MCMServer,
 	// All shared informers are v1alpha1 API level
 	machineSharedInformers := controlMachineInformerFactory.Machine().V1alpha1()
 
+	// Node annotation writes are limited to half of the target API budget, so that bursts
+	// during mass drains don't starve the other requests of the controllers.
+	mcmcontroller.SetNodeAnnotationRateLimiter(mcmcontroller.NewNodeAnnotationRateLimiter(s.KubeAPIQPS/2, int(s.KubeAPIBurst)/2))
+
+	mcmcontroller.RecordMachinePhaseMetrics(prometheusclient.DefaultRegisterer)
+
 	klog.V(4).Infof("Creating controllers...")
 	mcmController, err := mcmcontroller.NewController(
 		s.Namespace,
@@ -268,6 +275,7 @@ func StartControllers(s *options.MCMServer,
 		recorder,
 		s.SafetyOptions,
 		s.AutoscalerScaleDownAnnotationDuringRollout,
+		nil,
 	)
 	if err != nil {
 		return err
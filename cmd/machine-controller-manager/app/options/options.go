@@ -107,6 +107,9 @@ func (s *MCMServer) AddFlags(fs *pflag.FlagSet) {
 // Validate is used to validate the options and config before launching the controller manager
 func (s *MCMServer) Validate() error {
 	var errs []error
-	// TODO add validation
+	if err := leaderelectionconfig.ValidateResourceLock(s.LeaderElection.ResourceLock); err != nil {
+		errs = append(errs, err)
+	}
+	// TODO add further validation
 	return utilerrors.NewAggregate(errs)
 }
@@ -81,6 +81,8 @@ const (
 	MachineSetReplicaFailure MachineSetConditionType = "ReplicaFailure"
 	// MachineSetFrozen is set when the machineset has exceeded its replica threshold at the safety controller
 	MachineSetFrozen MachineSetConditionType = "Frozen"
+	// MachineSetMachinesReady is set in a machine set reflecting whether all of its desired replicas are ready.
+	MachineSetMachinesReady MachineSetConditionType = "MachinesReady"
 )
 
 // MachineSetCondition describes the state of a machine set at a certain point.
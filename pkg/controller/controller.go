@@ -38,7 +38,8 @@ const (
 	DeleteFinalizerName = "machine.sapcloud.io/machine-controller-manager"
 )
 
-// NewController returns a new Node controller.
+// NewController returns a new Node controller. The replica changes of machineSets are passed to
+// scaleRecorder for auditing, unless it is nil.
 func NewController(
 	namespace string,
 	controlMachineClient machineapi.MachineV1alpha1Interface,
@@ -51,6 +52,7 @@ func NewController(
 	recorder record.EventRecorder,
 	safetyOptions options.SafetyOptions,
 	autoscalerScaleDownAnnotationDuringRollout bool,
+	scaleRecorder ScaleRecorder,
 ) (Controller, error) {
 	controller := &controller{
 		namespace:                      namespace,
@@ -58,7 +60,8 @@ func NewController(
 		controlCoreClient:              controlCoreClient,
 		targetCoreClient:               targetCoreClient,
 		recorder:                       recorder,
-		expectations:                   NewUIDTrackingContExpectations(NewContExpectations()),
+		scaleRecorder:                  scaleRecorder,
+		expectations:                   NewUIDTrackingContExpectations(NewContExpectations(0, prometheus.DefaultRegisterer)),
 		nodeQueue:                      workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "node"),
 		machineQueue:                   workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "machine"),
 		machineSetQueue:                workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "machineset"),
@@ -85,6 +88,7 @@ func NewController(
 	controller.machineControl = RealMachineControl{
 		controlMachineClient: controlMachineClient,
 		Recorder:             eventBroadcaster.NewRecorder(machinescheme.Scheme, corev1.EventSource{Component: "machineset-controller"}),
+		Namespace:            namespace,
 	}
 
 	controller.machineSetControl = RealMachineSetControl{
@@ -167,6 +171,7 @@ type controller struct {
 	targetCoreClient     kubernetes.Interface
 
 	recorder          record.EventRecorder
+	scaleRecorder     ScaleRecorder
 	machineControl    MachineControlInterface
 	machineSetControl MachineSetControlInterface
 	safetyOptions     options.SafetyOptions
@@ -216,6 +221,7 @@ func (c *controller) Run(workers int, stopCh <-chan struct{}) {
 	// will collect metrics to expose them via the metrics endpoint of the mcm
 	// every time when the endpoint is called.
 	prometheus.MustRegister(c)
+	prometheus.MustRegister(worker.ReconcileQueueDepth)
 
 	for i := 0; i < workers; i++ {
 		worker.Run(c.machineSetQueue, "ClusterMachineSet", worker.DefaultMaxRetries, true, c.reconcileClusterMachineSet, stopCh, &waitGroup)
@@ -223,6 +229,14 @@ func (c *controller) Run(workers int, stopCh <-chan struct{}) {
 		worker.Run(c.machineSafetyOvershootingQueue, "ClusterMachineSafetyOvershooting", worker.DefaultMaxRetries, true, c.reconcileClusterMachineSafetyOvershooting, stopCh, &waitGroup)
 	}
 
+	for controller, queue := range map[string]workqueue.Interface{
+		"ClusterMachineSet":                c.machineSetQueue,
+		"ClusterMachineDeployment":         c.machineDeploymentQueue,
+		"ClusterMachineSafetyOvershooting": c.machineSafetyOvershootingQueue,
+	} {
+		worker.NewQueueDepthReporter(controller, queue).Run(worker.DefaultQueueDepthReportPeriod, stopCh, &waitGroup)
+	}
+
 	<-stopCh
 	klog.V(1).Info("Shutting down Machine Controller Manager ")
 	handlers.UpdateHealth(false)
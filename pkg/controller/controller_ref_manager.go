@@ -24,6 +24,7 @@ package controller
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"sync"
 
@@ -33,6 +34,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/klog/v2"
 )
@@ -268,6 +270,45 @@ func (m *MachineControllerRefManager) ReleaseMachine(ctx context.Context, machin
 	return err
 }
 
+// DedupeOwnerReferences removes owner references with the same UID as an earlier owner reference of the machine,
+// e.g. accumulated by repeated adoptions. If one of the duplicates is the controller reference, it is kept in place
+// of the earlier owner reference. It returns true if any owner reference was removed.
+func DedupeOwnerReferences(machine *v1alpha1.Machine) (changed bool) {
+	seen := make(map[types.UID]int, len(machine.OwnerReferences))
+	deduped := make([]metav1.OwnerReference, 0, len(machine.OwnerReferences))
+	for _, ref := range machine.OwnerReferences {
+		i, ok := seen[ref.UID]
+		if !ok {
+			seen[ref.UID] = len(deduped)
+			deduped = append(deduped, ref)
+			continue
+		}
+		if ref.Controller != nil && *ref.Controller && (deduped[i].Controller == nil || !*deduped[i].Controller) {
+			deduped[i] = ref
+		}
+	}
+	if len(deduped) == len(machine.OwnerReferences) {
+		return false
+	}
+	machine.OwnerReferences = deduped
+	return true
+}
+
+// PatchMachineOwnerReferences sends a patch replacing the owner references of the Machine by the given ones,
+// e.g. to persist the owner references deduplicated by DedupeOwnerReferences.
+func PatchMachineOwnerReferences(ctx context.Context, machineControl MachineControlInterface, machine *v1alpha1.Machine) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"ownerReferences": machine.OwnerReferences,
+			"uid":             machine.UID,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return machineControl.PatchMachine(ctx, machine.Namespace, machine.Name, patch)
+}
+
 // MachineSetControllerRefManager is used to manage controllerRef of MachineSets.
 // Three methods are defined on this object 1: Classify 2: AdoptMachineSet and
 // 3: ReleaseMachineSet which are used to classify the MachineSets into appropriate
@@ -0,0 +1,95 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+
+	machinev1 "github.com/gardener/machine-controller-manager/pkg/apis/machine/v1alpha1"
+	fakemachineclientset "github.com/gardener/machine-controller-manager/pkg/client/clientset/versioned/fake"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+)
+
+var _ = Describe("controller_ref_manager", func() {
+	newOwnerReference := func(name string, uid types.UID, controller bool) metav1.OwnerReference {
+		return metav1.OwnerReference{
+			APIVersion: machinev1.SchemeGroupVersion.String(),
+			Kind:       "MachineSet",
+			Name:       name,
+			UID:        uid,
+			Controller: ptr.To(controller),
+		}
+	}
+	newOwnedMachine := func(refs ...metav1.OwnerReference) *machinev1.Machine {
+		return &machinev1.Machine{
+			ObjectMeta: metav1.ObjectMeta{Name: "machine-0", Namespace: testNamespace, UID: "machine-uid", OwnerReferences: refs},
+		}
+	}
+
+	Describe("#DedupeOwnerReferences", func() {
+		It("should remove owner references with duplicate UIDs", func() {
+			machine := newOwnedMachine(
+				newOwnerReference("ms-1", "uid-1", false),
+				newOwnerReference("ms-2", "uid-2", false),
+				newOwnerReference("ms-1", "uid-1", false),
+			)
+
+			Expect(DedupeOwnerReferences(machine)).To(BeTrue())
+			Expect(machine.OwnerReferences).To(Equal([]metav1.OwnerReference{
+				newOwnerReference("ms-1", "uid-1", false),
+				newOwnerReference("ms-2", "uid-2", false),
+			}))
+		})
+
+		It("should preserve the controller reference among duplicates", func() {
+			machine := newOwnedMachine(
+				newOwnerReference("ms-1", "uid-1", false),
+				newOwnerReference("ms-2", "uid-2", false),
+				newOwnerReference("ms-1", "uid-1", true),
+			)
+
+			Expect(DedupeOwnerReferences(machine)).To(BeTrue())
+			Expect(machine.OwnerReferences).To(Equal([]metav1.OwnerReference{
+				newOwnerReference("ms-1", "uid-1", true),
+				newOwnerReference("ms-2", "uid-2", false),
+			}))
+			Expect(metav1.GetControllerOf(machine).UID).To(Equal(types.UID("uid-1")))
+		})
+
+		It("should not change owner references without duplicates", func() {
+			machine := newOwnedMachine(
+				newOwnerReference("ms-1", "uid-1", true),
+				newOwnerReference("ms-2", "uid-2", false),
+			)
+
+			Expect(DedupeOwnerReferences(machine)).To(BeFalse())
+			Expect(machine.OwnerReferences).To(HaveLen(2))
+			Expect(DedupeOwnerReferences(newOwnedMachine())).To(BeFalse())
+		})
+	})
+
+	Describe("#PatchMachineOwnerReferences", func() {
+		It("should persist the deduplicated owner references", func() {
+			machine := newOwnedMachine(
+				newOwnerReference("ms-1", "uid-1", false),
+				newOwnerReference("ms-1", "uid-1", true),
+			)
+			fakeClient := fakemachineclientset.NewSimpleClientset(machine.DeepCopy())
+			machineControl := RealMachineControl{controlMachineClient: fakeClient.MachineV1alpha1(), Recorder: record.NewFakeRecorder(10)}
+
+			Expect(DedupeOwnerReferences(machine)).To(BeTrue())
+			Expect(PatchMachineOwnerReferences(context.TODO(), machineControl, machine)).To(Succeed())
+
+			updated, err := fakeClient.MachineV1alpha1().Machines(testNamespace).Get(context.TODO(), machine.Name, metav1.GetOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(updated.OwnerReferences).To(Equal([]metav1.OwnerReference{newOwnerReference("ms-1", "uid-1", true)}))
+		})
+	})
+})
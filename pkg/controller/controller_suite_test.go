@@ -446,7 +446,7 @@ func createController(
 		machineSetQueue:                workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "machineset"),
 		machineDeploymentQueue:         workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "machinedeployment"),
 		machineSafetyOvershootingQueue: workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "machinesafetyovershooting"),
-		expectations:                   NewUIDTrackingContExpectations(NewContExpectations()),
+		expectations:                   NewUIDTrackingContExpectations(NewContExpectations(0, nil)),
 		recorder:                       record.NewBroadcaster().NewRecorder(nil, corev1.EventSource{Component: ""}),
 	}
 
@@ -458,6 +458,8 @@ func createController(
 
 	controller.machineControl = FakeMachineControl{
 		controlMachineClient: fakeTypedMachineClient,
+		Recorder:             controller.recorder,
+		Namespace:            namespace,
 	}
 
 	return controller, fakeObjectTrackers
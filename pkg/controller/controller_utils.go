@@ -25,38 +25,55 @@ package controller
 import (
 	"context"
 	"encoding/binary"
+	"encoding/json"
 	"fmt"
+	"hash"
 	"hash/fnv"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/gardener/machine-controller-manager/pkg/apis/machine/v1alpha1"
+	machinescheme "github.com/gardener/machine-controller-manager/pkg/client/clientset/versioned/scheme"
 	machineapi "github.com/gardener/machine-controller-manager/pkg/client/clientset/versioned/typed/machine/v1alpha1"
 	fakemachineapi "github.com/gardener/machine-controller-manager/pkg/client/clientset/versioned/typed/machine/v1alpha1/fake"
+	"github.com/gardener/machine-controller-manager/pkg/metrics"
 	annotationsutils "github.com/gardener/machine-controller-manager/pkg/util/annotations"
 	hashutil "github.com/gardener/machine-controller-manager/pkg/util/hash"
+	taintutils "github.com/gardener/machine-controller-manager/pkg/util/taints"
 	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/time/rate"
 
+	"github.com/gardener/machine-controller-manager/pkg/util/nodeops"
 	"github.com/gardener/machine-controller-manager/pkg/util/provider/machineutils"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/validation"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/sets"
+	utilvalidation "k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/apimachinery/pkg/util/wait"
 	clientset "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/tools/reference"
+	"k8s.io/client-go/util/flowcontrol"
 	clientretry "k8s.io/client-go/util/retry"
 	"k8s.io/klog/v2"
 	"k8s.io/utils/clock"
+	"k8s.io/utils/lru"
 )
 
 const (
@@ -88,13 +105,6 @@ const (
 	SlowStartInitialBatchSize = 1
 )
 
-// Backoff is the backoff period used while updating nodes
-var Backoff = wait.Backoff{
-	Steps:    5,
-	Duration: 100 * time.Millisecond,
-	Jitter:   1.0,
-}
-
 // UpdateAnnotationBackoff is the backoff period used while updating the annotation
 var UpdateAnnotationBackoff = wait.Backoff{
 	Steps:    5,
@@ -102,6 +112,87 @@ var UpdateAnnotationBackoff = wait.Backoff{
 	Jitter:   1.0,
 }
 
+// backoffLock guards UpdateAnnotationBackoff against concurrent updates via its setter.
+var backoffLock sync.RWMutex
+
+// SetNodeUpdateBackoff sets the nodeops.Backoff used to retry conflicting updates of node conditions and taints,
+// e.g. to retry less aggressively in heavily loaded setups. The backoff must have at least one step and a positive
+// duration.
+func SetNodeUpdateBackoff(backoff wait.Backoff) error {
+	if err := validateBackoff(backoff); err != nil {
+		return fmt.Errorf("invalid node update backoff: %w", err)
+	}
+	nodeops.SetBackoff(backoff)
+	return nil
+}
+
+// SetAnnotationUpdateBackoff sets the UpdateAnnotationBackoff used while updating node annotations and taints,
+// e.g. to retry less aggressively in heavily loaded setups. The backoff must have at least one step and a
+// positive duration.
+func SetAnnotationUpdateBackoff(backoff wait.Backoff) error {
+	if err := validateBackoff(backoff); err != nil {
+		return fmt.Errorf("invalid annotation update backoff: %w", err)
+	}
+	backoffLock.Lock()
+	defer backoffLock.Unlock()
+	UpdateAnnotationBackoff = backoff
+	return nil
+}
+
+func validateBackoff(backoff wait.Backoff) error {
+	if backoff.Steps < 1 {
+		return fmt.Errorf("steps must be at least 1, got %d", backoff.Steps)
+	}
+	if backoff.Duration <= 0 {
+		return fmt.Errorf("duration must be positive, got %v", backoff.Duration)
+	}
+	return nil
+}
+
+// annotationUpdateBackoff returns the current UpdateAnnotationBackoff.
+func annotationUpdateBackoff() wait.Backoff {
+	backoffLock.RLock()
+	defer backoffLock.RUnlock()
+	return UpdateAnnotationBackoff
+}
+
+// nodeAnnotationRateLimiter is the package wide rate limiter gating node annotation writes.
+// A nil limiter disables rate limiting.
+var nodeAnnotationRateLimiter struct {
+	sync.RWMutex
+	limiter flowcontrol.RateLimiter
+}
+
+// NewNodeAnnotationRateLimiter returns a token bucket rate limiter for node annotation writes
+// allowing qps writes per second with the given burst. A non-positive qps returns nil, i.e. no limiting.
+func NewNodeAnnotationRateLimiter(qps float32, burst int) flowcontrol.RateLimiter {
+	if qps <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return flowcontrol.NewTokenBucketRateLimiter(qps, burst)
+}
+
+// SetNodeAnnotationRateLimiter sets the rate limiter which all node annotation writes across controllers
+// acquire a token from before writing. Passing nil disables rate limiting, which is the default.
+func SetNodeAnnotationRateLimiter(limiter flowcontrol.RateLimiter) {
+	nodeAnnotationRateLimiter.Lock()
+	defer nodeAnnotationRateLimiter.Unlock()
+	nodeAnnotationRateLimiter.limiter = limiter
+}
+
+func waitForNodeAnnotationRateLimiter(ctx context.Context) error {
+	nodeAnnotationRateLimiter.RLock()
+	limiter := nodeAnnotationRateLimiter.limiter
+	nodeAnnotationRateLimiter.RUnlock()
+	if limiter == nil {
+		return nil
+	}
+	return limiter.Wait(ctx)
+}
+
 var (
 	// KeyFunc is the variable that stores the function that retreives the object key from an object
 	KeyFunc = cache.DeletionHandlingMetaNamespaceKeyFunc
@@ -138,6 +229,9 @@ var ExpKeyFunc = func(obj interface{}) (string, error) {
 	return "", fmt.Errorf("Could not find key for obj %#v", obj)
 }
 
+// expectationsLogger logs the state of expectations, which is checked on every sync of a controller
+var expectationsLogger = NewRateLimitedLogger(4, DefaultRateLimitedLoggerTTL)
+
 // ExpectationsInterface is an interface that allows users to set and wait on expectations.
 // Only abstracted out for testing.
 // Warning: if using KeyFunc it is not safe to use a single ExpectationsInterface with different
@@ -158,6 +252,23 @@ type ExpectationsInterface interface {
 // ContExpectations is a cache mapping controllers to what they expect to see before being woken up for a sync.
 type ContExpectations struct {
 	cache.Store
+	clock clock.Clock
+	// timeout after which unfulfilled expectations expire, ExpectationsTimeout if zero.
+	timeout time.Duration
+	// metrics is updated with the state of the expectations, if set.
+	metrics *ExpectationsMetrics
+	// observedEventsLock guards the check-and-add of an event ID to observedEvents
+	observedEventsLock sync.Mutex
+	// observedEvents holds the most recently observed event IDs per controller key, evicting the oldest ones
+	observedEvents *lru.Cache
+}
+
+// Timeout returns the duration after which unfulfilled expectations of this store expire.
+func (r *ContExpectations) Timeout() time.Duration {
+	if r.timeout <= 0 {
+		return ExpectationsTimeout
+	}
+	return r.timeout
 }
 
 // GetExpectations returns the ControlleeExpectations of the given controller.
@@ -175,7 +286,9 @@ func (r *ContExpectations) DeleteExpectations(controllerKey string) {
 	if exp, exists, err := r.GetByKey(controllerKey); err == nil && exists {
 		if err := r.Delete(exp); err != nil {
 			klog.V(4).Infof("Error deleting expectations for controller %v: %v", controllerKey, err)
+			return
 		}
+		r.metrics.forget(controllerKey)
 	}
 }
 
@@ -184,14 +297,22 @@ func (r *ContExpectations) DeleteExpectations(controllerKey string) {
 // manager.
 func (r *ContExpectations) SatisfiedExpectations(controllerKey string) bool {
 	if exp, exists, err := r.GetExpectations(controllerKey); exists {
+		if exp.IsCorrupt() {
+			add, del := exp.GetExpectations()
+			klog.Warningf("Controller expectations %v are corrupt (add: %d, del: %d), resetting them", controllerKey, add, del)
+			metrics.MachineSetCorruptExpectations.Inc()
+			exp.Reset()
+			r.metrics.observe(exp)
+		}
 		if exp.Fulfilled() {
-			klog.V(4).Infof("Controller expectations fulfilled %#v", exp)
+			expectationsLogger.Infof("Controller expectations fulfilled %#v", exp)
 			return true
-		} else if exp.isExpired() {
-			klog.V(4).Infof("Controller expectations expired %#v", exp)
+		} else if exp.isExpired(r.clock, r.Timeout()) {
+			expectationsLogger.Infof("Controller expectations expired %#v", exp)
+			r.metrics.expiredObserved(controllerKey)
 			return true
 		} else {
-			klog.V(4).Infof("Controller still waiting on expectations %#v", exp)
+			expectationsLogger.Infof("Controller still waiting on expectations %#v", exp)
 			return false
 		}
 	} else if err != nil {
@@ -202,7 +323,7 @@ func (r *ContExpectations) SatisfiedExpectations(controllerKey string) bool {
 		//	- In this case it wakes up, creates/deletes controllees, and sets expectations again.
 		// When it has satisfied expectations and no controllees need to be created/destroyed > TTL, the expectations expire.
 		//	- In this case it continues without setting expectations till it needs to create/delete controllees.
-		klog.V(4).Infof("Controller %v either never recorded expectations, or the ttl expired.", controllerKey)
+		expectationsLogger.Infof("Controller %v either never recorded expectations, or the ttl expired.", controllerKey)
 	}
 	// Trigger a sync if we either encountered and error (which shouldn't happen since we're
 	// getting from local store) or this controller hasn't established expectations.
@@ -211,16 +332,19 @@ func (r *ContExpectations) SatisfiedExpectations(controllerKey string) bool {
 
 // TODO: Extend ExpirationCache to support explicit expiration.
 // TODO: Make this possible to disable in tests.
-// TODO: Support injection of clock.
-func (exp *ControlleeExpectations) isExpired() bool {
-	return clock.RealClock{}.Since(exp.timestamp) > ExpectationsTimeout
+func (exp *ControlleeExpectations) isExpired(c clock.PassiveClock, timeout time.Duration) bool {
+	return c.Since(exp.timestamp) > timeout
 }
 
 // SetExpectations registers new expectations for the given controller. Forgets existing expectations.
 func (r *ContExpectations) SetExpectations(controllerKey string, add, del int) error {
-	exp := &ControlleeExpectations{add: int64(add), del: int64(del), key: controllerKey, timestamp: clock.RealClock{}.Now()}
+	exp := &ControlleeExpectations{add: int64(add), del: int64(del), key: controllerKey, timestamp: r.clock.Now()}
 	klog.V(4).Infof("Setting expectations %#v", exp)
-	return r.Add(exp)
+	if err := r.Add(exp); err != nil {
+		return err
+	}
+	r.metrics.observe(exp)
+	return nil
 }
 
 // ExpectCreations adds creations to an existing expectation
@@ -237,6 +361,7 @@ func (r *ContExpectations) ExpectDeletions(controllerKey string, dels int) error
 func (r *ContExpectations) LowerExpectations(controllerKey string, add, del int) {
 	if exp, exists, err := r.GetExpectations(controllerKey); err == nil && exists {
 		exp.Add(int64(-add), int64(-del))
+		r.metrics.observe(exp)
 		// The expectations might've been modified since the update on the previous line.
 		klog.V(4).Infof("Lowered expectations %#v", exp)
 	}
@@ -246,6 +371,7 @@ func (r *ContExpectations) LowerExpectations(controllerKey string, add, del int)
 func (r *ContExpectations) RaiseExpectations(controllerKey string, add, del int) {
 	if exp, exists, err := r.GetExpectations(controllerKey); err == nil && exists {
 		exp.Add(int64(add), int64(del))
+		r.metrics.observe(exp)
 		// The expectations might've been modified since the update on the previous line.
 		klog.V(4).Infof("Raised expectations %#v", exp)
 	}
@@ -261,6 +387,135 @@ func (r *ContExpectations) DeletionObserved(controllerKey string) {
 	r.LowerExpectations(controllerKey, 0, 1)
 }
 
+// TotalOutstanding returns the sum of the outstanding creations and deletions across the expectations of all
+// controllers. The counters of every entry are read atomically without mutating it, and counters which have
+// been lowered below zero don't count as outstanding.
+func (r *ContExpectations) TotalOutstanding() (adds int64, dels int64) {
+	for _, obj := range r.List() {
+		exp, ok := obj.(*ControlleeExpectations)
+		if !ok {
+			continue
+		}
+		add, del := exp.GetExpectations()
+		if add > 0 {
+			adds += add
+		}
+		if del > 0 {
+			dels += del
+		}
+	}
+	return adds, dels
+}
+
+// Snapshot returns a copy of the expectations of all controllers, sorted by controller key, e.g. to dump them
+// when debugging a controller which appears stuck. Mutating the returned expectations doesn't affect the store.
+func (r *ContExpectations) Snapshot() []*ControlleeExpectations {
+	var snapshot []*ControlleeExpectations
+	for _, obj := range r.List() {
+		exp, ok := obj.(*ControlleeExpectations)
+		if !ok {
+			continue
+		}
+		add, del := exp.GetExpectations()
+		snapshot = append(snapshot, &ControlleeExpectations{add: add, del: del, key: exp.key, timestamp: exp.timestamp})
+	}
+	sort.Slice(snapshot, func(i, j int) bool {
+		return snapshot[i].key < snapshot[j].key
+	})
+	return snapshot
+}
+
+// ReconcileExpectationsFromList lowers the expectations of the given controller by the number of creations
+// and deletions observed on a full relist, in a single call. This is more robust than relying on individual
+// watch events, which might be dropped. The counts are clamped so that expectations are never raised,
+// and are never lowered below zero.
+func ReconcileExpectationsFromList(expectations ExpectationsInterface, controllerKey string, observedAdds, observedDels int) {
+	exp, exists, err := expectations.GetExpectations(controllerKey)
+	if err != nil || !exists {
+		return
+	}
+	add, del := exp.GetExpectations()
+	adds := clampObserved(observedAdds, add)
+	dels := clampObserved(observedDels, del)
+	if adds == 0 && dels == 0 {
+		return
+	}
+	expectations.LowerExpectations(controllerKey, adds, dels)
+}
+
+// clampObserved bounds the observed count to the range [0, outstanding].
+func clampObserved(observed int, outstanding int64) int {
+	if observed <= 0 || outstanding <= 0 {
+		return 0
+	}
+	if int64(observed) > outstanding {
+		return int(outstanding)
+	}
+	return observed
+}
+
+// ReplicaDiff returns the difference between the desired replicas and the number of active machines.
+// A positive diff is the number of machines to create, a negative diff the number of machines to delete.
+func ReplicaDiff(desiredReplicas int32, activeMachines []*v1alpha1.Machine) int {
+	return int(desiredReplicas) - len(activeMachines)
+}
+
+// ExpectationsFromDiff returns the add and del expectations matching the given replica diff, as returned by
+// ReplicaDiff, so that expectations are always set on the side which the controller is going to act on.
+func ExpectationsFromDiff(diff int) (adds, dels int) {
+	if diff > 0 {
+		return diff, 0
+	}
+	return 0, -diff
+}
+
+// CleanupExpectationsForMissingControllers deletes the expectations of all controllers whose key is not
+// in existingKeys. Expectations of deleted controllers are otherwise only cleaned up by their delete event,
+// so calling this periodically with the keys of the live controllers bounds the store against missed deletes.
+func CleanupExpectationsForMissingControllers(expectations *ContExpectations, existingKeys sets.String) {
+	for _, controllerKey := range expectations.ListKeys() {
+		if !existingKeys.Has(controllerKey) {
+			klog.V(4).Infof("Deleting expectations of missing controller %v", controllerKey)
+			expectations.DeleteExpectations(controllerKey)
+		}
+	}
+}
+
+// AvailableExpectationSlack returns how many more creations or deletions may be issued for the controller
+// without exceeding maxInFlight outstanding operations, as tracked by its expectations.
+// It returns maxInFlight if the controller has no expectations.
+func AvailableExpectationSlack(expectations ExpectationsInterface, controllerKey string, maxInFlight int) int {
+	exp, exists, err := expectations.GetExpectations(controllerKey)
+	if err != nil || !exists {
+		return maxInFlight
+	}
+	add, del := exp.GetExpectations()
+	inFlight := int64(0)
+	if add > 0 {
+		inFlight += add
+	}
+	if del > 0 {
+		inFlight += del
+	}
+	if slack := int64(maxInFlight) - inFlight; slack > 0 {
+		return int(slack)
+	}
+	return 0
+}
+
+// ScaleUpWithPendingCap returns how many machines to create to scale up from current to desired replicas without
+// the pending machines exceeding maxPending, i.e. such that pending + created <= maxPending. This paces a scale-up
+// by the machines still being provisioned, to avoid flooding the provider with simultaneous provisions.
+// It returns 0 if there is nothing to scale up or pending >= maxPending.
+func ScaleUpWithPendingCap(current, desired, pending, maxPending int32) int32 {
+	toCreate := desired - current
+	room := maxPending - pending
+	if toCreate <= 0 || room <= 0 {
+		return 0
+	}
+	return min(toCreate, room)
+}
+
 // Expectations are either fulfilled, or expire naturally.
 type Expectations interface {
 	Fulfilled() bool
@@ -293,9 +548,87 @@ func (exp *ControlleeExpectations) GetExpectations() (int64, int64) {
 	return atomic.LoadInt64(&exp.add), atomic.LoadInt64(&exp.del)
 }
 
-// NewContExpectations returns a store for ContExpectations.
-func NewContExpectations() *ContExpectations {
-	return &ContExpectations{cache.NewStore(ExpKeyFunc)}
+// Key returns the key of the controller the expectations belong to.
+func (exp *ControlleeExpectations) Key() string {
+	return exp.key
+}
+
+// Timestamp returns the time at which the expectations were set.
+func (exp *ControlleeExpectations) Timestamp() time.Time {
+	return exp.timestamp
+}
+
+// Equal returns true if both expectations belong to the same key and have the same add and del counters.
+// The timestamp is ignored.
+func (exp *ControlleeExpectations) Equal(other *ControlleeExpectations) bool {
+	if exp == nil || other == nil {
+		return exp == other
+	}
+	add, del := exp.GetExpectations()
+	otherAdd, otherDel := other.GetExpectations()
+	return exp.key == other.key && add == otherAdd && del == otherDel
+}
+
+// CorruptExpectationsThreshold is the lower bound for the add and del counters of sane expectations.
+// Counters below it indicate that creations or deletions have been observed more often than expected.
+const CorruptExpectationsThreshold = -BurstReplicas
+
+// IsCorrupt returns true if either counter has dropped below CorruptExpectationsThreshold.
+func (exp *ControlleeExpectations) IsCorrupt() bool {
+	add, del := exp.GetExpectations()
+	return add < CorruptExpectationsThreshold || del < CorruptExpectationsThreshold
+}
+
+// Reset re-zeros the add and del counters.
+func (exp *ControlleeExpectations) Reset() {
+	atomic.StoreInt64(&exp.add, 0)
+	atomic.StoreInt64(&exp.del, 0)
+}
+
+// TimeUntilExpiry returns the time remaining at now until the expectation expires after the given timeout,
+// clamped at zero for expectations which have already expired.
+func (exp *ControlleeExpectations) TimeUntilExpiry(timeout time.Duration, now time.Time) time.Duration {
+	remaining := timeout - now.Sub(exp.timestamp)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// MinExpectationsRequeueDelay is the minimum delay returned by RequeueDelayForExpectations, to avoid busy-looping
+// on expectations which are about to expire.
+const MinExpectationsRequeueDelay = time.Second
+
+// RequeueDelayForExpectations returns the delay after which a controller waiting on the given expectations should
+// be requeued, so that it doesn't sleep past their expiry after the given timeout. It is the smaller of the
+// defaultDelay and the time until expiry, but at least MinExpectationsRequeueDelay.
+func RequeueDelayForExpectations(exp *ControlleeExpectations, timeout time.Duration, now time.Time, defaultDelay time.Duration) time.Duration {
+	delay := defaultDelay
+	if untilExpiry := exp.TimeUntilExpiry(timeout, now); untilExpiry < delay {
+		delay = untilExpiry
+	}
+	if delay < MinExpectationsRequeueDelay {
+		return MinExpectationsRequeueDelay
+	}
+	return delay
+}
+
+// NewContExpectations returns a store for ContExpectations, whose unfulfilled expectations expire after the
+// given timeout. A zero timeout falls back to ExpectationsTimeout. If the registerer is not nil, the store exposes
+// ExpectationsMetrics about its expectations through it.
+func NewContExpectations(timeout time.Duration, registerer prometheus.Registerer) *ContExpectations {
+	r := NewContExpectationsWithClock(clock.RealClock{})
+	r.timeout = timeout
+	if registerer != nil {
+		r.metrics = NewExpectationsMetrics().register(registerer)
+	}
+	return r
+}
+
+// NewContExpectationsWithClock returns a store for ContExpectations, which uses the given clock
+// to timestamp and expire expectations.
+func NewContExpectationsWithClock(c clock.Clock) *ContExpectations {
+	return &ContExpectations{Store: cache.NewStore(ExpKeyFunc), clock: c, observedEvents: lru.New(observedEventsCacheSize)}
 }
 
 // UIDSetKeyFunc to parse out the key from a UIDSet.
@@ -352,7 +685,7 @@ func (u *UIDTrackingContExpectations) ExpectDeletions(rcKey string, deletedKeys
 	for _, k := range deletedKeys {
 		expectedUIDs.Insert(k)
 	}
-	klog.V(4).Infof("Controller %v waiting on deletions for: %+v", rcKey, deletedKeys)
+	expectationsLogger.Infof("Controller %v waiting on deletions for: %+v", rcKey, deletedKeys)
 	if err := u.uidStore.Add(&UIDSet{expectedUIDs, rcKey}); err != nil {
 		return err
 	}
@@ -392,6 +725,43 @@ func NewUIDTrackingContExpectations(ce ExpectationsInterface) *UIDTrackingContEx
 	return &UIDTrackingContExpectations{ExpectationsInterface: ce, uidStore: cache.NewStore(UIDSetKeyFunc)}
 }
 
+// observedEventsCacheSize bounds the number of event IDs remembered by ObserveOnce.
+const observedEventsCacheSize = 4096
+
+// eventObserver is implemented by expectations which remember the events observed through ObserveOnce.
+type eventObserver interface {
+	// firstObservation records the event identified by key and returns whether it was observed for the first time.
+	firstObservation(key string) bool
+}
+
+func (r *ContExpectations) firstObservation(key string) bool {
+	r.observedEventsLock.Lock()
+	defer r.observedEventsLock.Unlock()
+
+	if _, seen := r.observedEvents.Get(key); seen {
+		return false
+	}
+	r.observedEvents.Add(key, struct{}{})
+	return true
+}
+
+// ObserveOnce lowers the creation or deletion expectation of the controller only the first time the event
+// identified by eventID is observed, and returns whether it was the first observation. The observed event
+// IDs are kept in a bounded LRU cache of the expectations store, so an event is only deduplicated as long as
+// it hasn't been evicted. Expectations which don't remember observed events are lowered on every observation.
+func ObserveOnce(expectations ExpectationsInterface, controllerKey, eventID string, isCreate bool) bool {
+	if observer, ok := expectations.(eventObserver); ok && !observer.firstObservation(controllerKey+"/"+eventID) {
+		return false
+	}
+
+	if isCreate {
+		expectations.CreationObserved(controllerKey)
+	} else {
+		expectations.DeletionObserved(controllerKey)
+	}
+	return true
+}
+
 // Reasons for machine events
 const (
 	// FailedCreateMachineReason is added in an event and in a machine set condition
@@ -471,10 +841,70 @@ func validateControllerRef(controllerRef *metav1.OwnerReference) error {
 
 //--- For Machines ---//
 
+// APIErrorClass is the class of an error returned by the API server, which determines how an operation is retried.
+type APIErrorClass int
+
+const (
+	// APIErrorClassNone is the class of a nil error.
+	APIErrorClassNone APIErrorClass = iota
+	// APIErrorClassTransient is the class of errors which are expected to vanish on retry, e.g. conflicts, timeouts or throttling.
+	APIErrorClassTransient
+	// APIErrorClassQuota is the class of errors caused by an exceeded resource quota, which might be lifted eventually.
+	APIErrorClassQuota
+	// APIErrorClassPermanent is the class of errors which won't vanish without a change of the request, e.g. invalid objects.
+	APIErrorClassPermanent
+	// APIErrorClassUnknown is the class of all other errors.
+	APIErrorClassUnknown
+)
+
+// ClassifyAPIError returns the class of the given error returned by the API server.
+func ClassifyAPIError(err error) APIErrorClass {
+	switch {
+	case err == nil:
+		return APIErrorClassNone
+	case errors.IsConflict(err), errors.IsTimeout(err), errors.IsServerTimeout(err), errors.IsTooManyRequests(err),
+		errors.IsServiceUnavailable(err), errors.IsInternalError(err):
+		return APIErrorClassTransient
+	case errors.IsForbidden(err) && strings.Contains(err.Error(), "exceeded quota"):
+		return APIErrorClassQuota
+	case errors.IsInvalid(err), errors.IsBadRequest(err), errors.IsForbidden(err), errors.IsMethodNotSupported(err):
+		return APIErrorClassPermanent
+	default:
+		return APIErrorClassUnknown
+	}
+}
+
+// RetryAfterFromError returns the delay requested by the API server via Retry-After in the details of the given
+// error, e.g. when throttling with 429. It returns false if no delay was requested, in which case callers should
+// fall back to their regular backoff.
+func RetryAfterFromError(err error) (time.Duration, bool) {
+	if err == nil {
+		return 0, false
+	}
+	seconds, ok := errors.SuggestsClientDelay(err)
+	if !ok || seconds <= 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// ShouldRetryCreate returns true if the creation of a machine which failed with the given error should be retried.
+// Creations which failed permanently, e.g. due to an invalid machine template, are not retried.
+func ShouldRetryCreate(err error) bool {
+	switch ClassifyAPIError(err) {
+	case APIErrorClassNone, APIErrorClassPermanent:
+		return false
+	default:
+		return true
+	}
+}
+
 // RealMachineControl is the default implementation of machineControlInterface.
 type RealMachineControl struct {
 	controlMachineClient machineapi.MachineV1alpha1Interface
 	Recorder             record.EventRecorder
+	// Namespace is the namespace events about cluster-scoped objects are recorded in.
+	Namespace string
 }
 
 // MachineControlInterface is the reference to the realMachineControl
@@ -486,10 +916,21 @@ type MachineControlInterface interface {
 	CreateMachines(ctx context.Context, namespace string, template *v1alpha1.MachineTemplateSpec, object runtime.Object) error
 	// CreatemachinesWithControllerRef creates new machines according to the spec, and sets object as the machine's controller.
 	CreateMachinesWithControllerRef(ctx context.Context, namespace string, template *v1alpha1.MachineTemplateSpec, object runtime.Object, controllerRef *metav1.OwnerReference) error
+	// CreateMachinesReturning creates a new machine according to the spec and returns the created machine.
+	CreateMachinesReturning(ctx context.Context, namespace string, template *v1alpha1.MachineTemplateSpec, object runtime.Object) (*v1alpha1.Machine, error)
+	// CreateMachinesWithControllerRefReturning creates a new machine according to the spec, sets object as the machine's controller
+	// and returns the created machine.
+	CreateMachinesWithControllerRefReturning(ctx context.Context, namespace string, template *v1alpha1.MachineTemplateSpec, object runtime.Object, controllerRef *metav1.OwnerReference) (*v1alpha1.Machine, error)
 	// Deletemachine deletes the machine identified by machineID.
 	DeleteMachine(ctx context.Context, namespace string, machineID string, object runtime.Object) error
-	// Patchmachine patches the machine.
+	// DeleteMachines deletes the machines identified by machineIDs in slow-start batches.
+	DeleteMachines(ctx context.Context, namespace string, machineIDs []string, object runtime.Object) error
+	// Patchmachine patches the machine using a JSON merge patch.
 	PatchMachine(ctx context.Context, namespace string, name string, data []byte) error
+	// PatchMachineWithType patches the machine using the given patch type.
+	PatchMachineWithType(ctx context.Context, namespace string, name string, pt types.PatchType, data []byte) error
+	// PatchMachineStatus patches the status subresource of the machine using a JSON merge patch.
+	PatchMachineStatus(ctx context.Context, namespace string, name string, data []byte) error
 }
 
 func getMachinesLabelSet(template *v1alpha1.MachineTemplateSpec) labels.Set {
@@ -506,14 +947,75 @@ func getMachinesFinalizers(template *v1alpha1.MachineTemplateSpec) []string {
 	return desiredFinalizers
 }
 
-func getMachinesAnnotationSet(template *v1alpha1.MachineTemplateSpec, _ runtime.Object) labels.Set {
+func getMachinesAnnotationSet(template *v1alpha1.MachineTemplateSpec, parentObject runtime.Object) labels.Set {
 	desiredAnnotations := make(labels.Set)
 	for k, v := range template.Annotations {
 		desiredAnnotations[k] = v
 	}
+	if ms, ok := parentObject.(*v1alpha1.MachineSet); ok && ms.Annotations[machineutils.MachineSetMachinePriority] != "" {
+		if _, ok := desiredAnnotations[machineutils.MachinePriority]; !ok {
+			desiredAnnotations[machineutils.MachinePriority] = strconv.Itoa(DefaultMachinePriorityForCreate(ms))
+		}
+	}
 	return desiredAnnotations
 }
 
+const (
+	// defaultMachinePriority is the priority of machines without the MachinePriority annotation
+	defaultMachinePriority = 3
+	// minMachinePriorityForCreate is the lowest priority stamped on new machines, since
+	// machines with priority 1 are considered to be triggered for deletion
+	minMachinePriorityForCreate = 2
+	// maxMachinePriorityForCreate is the highest priority stamped on new machines
+	maxMachinePriorityForCreate = 5
+)
+
+// DefaultMachinePriorityForCreate returns the MachinePriority to be stamped on machines created by the machineSet,
+// as specified by its MachineSetMachinePriority annotation. It defaults to 3 if the annotation is missing or invalid.
+func DefaultMachinePriorityForCreate(ms *v1alpha1.MachineSet) int {
+	value, ok := ms.Annotations[machineutils.MachineSetMachinePriority]
+	if !ok || value == "" {
+		return defaultMachinePriority
+	}
+	priority, err := strconv.Atoi(value)
+	if err != nil {
+		klog.Errorf("Machine priority for create is taken to be the default value (%d). Couldn't convert %q annotation %q of machineSet %s to integer: %v", defaultMachinePriority, machineutils.MachineSetMachinePriority, value, ms.Name, err)
+		return defaultMachinePriority
+	}
+	if priority < minMachinePriorityForCreate || priority > maxMachinePriorityForCreate {
+		klog.Errorf("Machine priority for create is taken to be the default value (%d). %q annotation %d of machineSet %s is out of range [%d, %d]", defaultMachinePriority, machineutils.MachineSetMachinePriority, priority, ms.Name, minMachinePriorityForCreate, maxMachinePriorityForCreate)
+		return defaultMachinePriority
+	}
+	return priority
+}
+
+// ValidateUtilizationThresholds validates that the utilization thresholds are sorted in ascending order and
+// that there are at most 3 of them, so that every bucket maps to a priority in [2, 5].
+func ValidateUtilizationThresholds(thresholds []float64) error {
+	if maxThresholds := maxMachinePriorityForCreate - minMachinePriorityForCreate; len(thresholds) > maxThresholds {
+		return fmt.Errorf("%d utilization thresholds exceed the maximum of %d", len(thresholds), maxThresholds)
+	}
+	if !sort.Float64sAreSorted(thresholds) {
+		return fmt.Errorf("utilization thresholds %v are not sorted in ascending order", thresholds)
+	}
+	return nil
+}
+
+// ComputePriorityFromUtilization maps the utilization of a node in percent to a MachinePriority for its machine,
+// so that machines with a low utilization are deleted first on scale down. The ascending thresholds divide the
+// utilization into buckets, where the lowest bucket maps to priority 2 and every further bucket increments the
+// priority. Priority 1 is never returned, as it marks machines triggered for deletion. The default priority is
+// returned for invalid thresholds.
+func ComputePriorityFromUtilization(utilizationPercent float64, thresholds []float64) int {
+	if err := ValidateUtilizationThresholds(thresholds); err != nil {
+		klog.Errorf("Machine priority is taken to be the default value (%d): %v", defaultMachinePriority, err)
+		return defaultMachinePriority
+	}
+	return minMachinePriorityForCreate + sort.Search(len(thresholds), func(i int) bool {
+		return utilizationPercent < thresholds[i]
+	})
+}
+
 func getMachinesPrefix(controllerName string) string {
 	// use the dash (if the name isn't too long) to make the machine name a bit prettier
 	prefix := fmt.Sprintf("%s-", controllerName)
@@ -523,6 +1025,41 @@ func getMachinesPrefix(controllerName string) string {
 	return prefix
 }
 
+// RecreateMachineName returns a deterministic machine name for the given ordinal of a machineDeployment
+// rolled out with the Recreate strategy, so that machines are named predictably instead of via GenerateName.
+// The name is of the form <deploymentName>-<templateHash>-<ordinal>, where the deployment name is sanitized
+// and truncated so that the resulting name is a valid DNS-1123 label.
+func RecreateMachineName(deploymentName string, templateHash string, ordinal int) string {
+	suffix := fmt.Sprintf("%d", ordinal)
+	if hash := sanitizeDNS1123Label(templateHash); hash != "" {
+		suffix = hash + "-" + suffix
+	}
+	if len(suffix) > utilvalidation.DNS1123LabelMaxLength-2 {
+		suffix = strings.Trim(suffix[len(suffix)-(utilvalidation.DNS1123LabelMaxLength-2):], "-")
+	}
+
+	prefix := sanitizeDNS1123Label(deploymentName)
+	if prefix == "" {
+		prefix = "machine"
+	}
+	if maxPrefixLen := utilvalidation.DNS1123LabelMaxLength - len(suffix) - 1; len(prefix) > maxPrefixLen {
+		prefix = strings.TrimRight(prefix[:maxPrefixLen], "-")
+	}
+	return prefix + "-" + suffix
+}
+
+// sanitizeDNS1123Label lowercases the given string, replaces all characters not allowed
+// in a DNS-1123 label with dashes and trims leading and trailing dashes.
+func sanitizeDNS1123Label(s string) string {
+	sanitized := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' {
+			return r
+		}
+		return '-'
+	}, strings.ToLower(s))
+	return strings.Trim(sanitized, "-")
+}
+
 // CreateMachinesWithControllerRef creates a machine with controller reference
 func (r RealMachineControl) CreateMachinesWithControllerRef(ctx context.Context, namespace string, template *v1alpha1.MachineTemplateSpec, controllerObject runtime.Object, controllerRef *metav1.OwnerReference) error {
 	if err := validateControllerRef(controllerRef); err != nil {
@@ -531,6 +1068,14 @@ func (r RealMachineControl) CreateMachinesWithControllerRef(ctx context.Context,
 	return r.createMachines(ctx, namespace, template, controllerObject, controllerRef)
 }
 
+// CreateMachinesWithControllerRefReturning creates a machine with controller reference and returns the created machine
+func (r RealMachineControl) CreateMachinesWithControllerRefReturning(ctx context.Context, namespace string, template *v1alpha1.MachineTemplateSpec, controllerObject runtime.Object, controllerRef *metav1.OwnerReference) (*v1alpha1.Machine, error) {
+	if err := validateControllerRef(controllerRef); err != nil {
+		return nil, err
+	}
+	return r.createMachinesReturning(ctx, namespace, template, controllerObject, controllerRef)
+}
+
 // GetMachineFromTemplate passes the machine template spec to return the machine object
 func GetMachineFromTemplate(template *v1alpha1.MachineTemplateSpec, parentObject runtime.Object, controllerRef *metav1.OwnerReference) (*v1alpha1.Machine, error) {
 
@@ -565,42 +1110,151 @@ func GetMachineFromTemplate(template *v1alpha1.MachineTemplateSpec, parentObject
 	return machine, nil
 }
 
+// ValidateMachineLabels validates that the machine labels are present and well-formed,
+// as machines without labels cannot be selected by their owner.
+func ValidateMachineLabels(machineLabels map[string]string, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if len(machineLabels) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath, "Labels are required"))
+		return allErrs
+	}
+	allErrs = append(allErrs, metav1validation.ValidateLabels(machineLabels, fldPath)...)
+	return allErrs
+}
+
+// ValidateMachineClassRef validates that the machine class reference is set.
+func ValidateMachineClassRef(classSpec *v1alpha1.ClassSpec, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if classSpec.Kind == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("kind"), "Kind is required"))
+	}
+	if classSpec.Name == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("name"), "Name is required"))
+	}
+	return allErrs
+}
+
+// ValidateMachineTemplateForCreate runs cheap local validations on the machine template
+// before machines are created from it, so that invalid templates fail fast with a single
+// aggregated error instead of being rejected by the apiserver one field at a time.
+func ValidateMachineTemplateForCreate(template *v1alpha1.MachineTemplateSpec) field.ErrorList {
+	allErrs := field.ErrorList{}
+	allErrs = append(allErrs, ValidateMachineLabels(template.Labels, field.NewPath("metadata", "labels"))...)
+	allErrs = append(allErrs, ValidateMachineClassRef(&template.Spec.Class, field.NewPath("spec", "class"))...)
+	if err := ValidateFinalizers(template.Finalizers); err != nil {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("metadata", "finalizers"), template.Finalizers, err.Error()))
+	}
+	return allErrs
+}
+
+// ValidateFinalizers validates that each finalizer is a qualified name of the form <domain>/<name>.
+// The returned error lists all invalid finalizers.
+func ValidateFinalizers(finalizers []string) error {
+	var invalid []string
+	for _, finalizer := range finalizers {
+		if !strings.Contains(finalizer, "/") || len(utilvalidation.IsQualifiedName(finalizer)) != 0 {
+			invalid = append(invalid, finalizer)
+		}
+	}
+	if len(invalid) > 0 {
+		return fmt.Errorf("invalid finalizers %q, finalizers must be qualified names of the form <domain>/<name>", invalid)
+	}
+	return nil
+}
+
+// DetectNameCollisions returns the given machine names which are already taken by machines in the namespace,
+// in the order they were given. It lists the machines only once, so that a batch of machines with explicit
+// names can be checked before creating them instead of failing with AlreadyExists mid-batch.
+func DetectNameCollisions(ctx context.Context, client machineapi.MachineV1alpha1Interface, namespace string, names []string) ([]string, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	machines, err := client.Machines(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list machines in namespace %q: %w", namespace, err)
+	}
+	existing := sets.NewString()
+	for i := range machines.Items {
+		existing.Insert(machines.Items[i].Name)
+	}
+	var collisions []string
+	for _, name := range names {
+		if existing.Has(name) {
+			collisions = append(collisions, name)
+		}
+	}
+	return collisions, nil
+}
+
 // CreateMachines initiates a create machine for a RealMachineControl
 func (r RealMachineControl) CreateMachines(ctx context.Context, namespace string, template *v1alpha1.MachineTemplateSpec, object runtime.Object) error {
 	return r.createMachines(ctx, namespace, template, object, nil)
 }
 
+// CreateMachinesReturning initiates a create machine for a RealMachineControl and returns the created machine
+func (r RealMachineControl) CreateMachinesReturning(ctx context.Context, namespace string, template *v1alpha1.MachineTemplateSpec, object runtime.Object) (*v1alpha1.Machine, error) {
+	return r.createMachinesReturning(ctx, namespace, template, object, nil)
+}
+
+// CreateMachinesInBatch creates count machines according to the template in slow-start batches, starting with
+// SlowStartInitialBatchSize and doubling with each successful batch. Once a batch sees a failure, e.g. because the
+// quota is exceeded, the remaining batches are skipped. It returns the number of successfully created machines.
+func (r RealMachineControl) CreateMachinesInBatch(ctx context.Context, namespace string, template *v1alpha1.MachineTemplateSpec, count int, object runtime.Object) (int, error) {
+	return slowStartBatch(count, SlowStartInitialBatchSize, func() error {
+		return r.CreateMachines(ctx, namespace, template, object)
+	})
+}
+
 func (r RealMachineControl) createMachines(ctx context.Context, namespace string, template *v1alpha1.MachineTemplateSpec, object runtime.Object, controllerRef *metav1.OwnerReference) error {
+	_, err := r.createMachinesReturning(ctx, namespace, template, object, controllerRef)
+	return err
+}
+
+func (r RealMachineControl) createMachinesReturning(ctx context.Context, namespace string, template *v1alpha1.MachineTemplateSpec, object runtime.Object, controllerRef *metav1.OwnerReference) (*v1alpha1.Machine, error) {
+	if errs := ValidateMachineTemplateForCreate(template); len(errs) > 0 {
+		return nil, fmt.Errorf("unable to create machines, invalid template: %v", errs.ToAggregate())
+	}
+
 	machine, err := GetMachineFromTemplate(template, object, controllerRef)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if labels.Set(machine.Labels).AsSelectorPreValidated().Empty() {
-		return fmt.Errorf("unable to create machines, no labels")
-	}
+	// The controller may be shutting down once ctx is done, so no events are emitted on its behalf anymore.
+	recorder := recorderWithContext(ctx, r.RecorderFor(object))
 
 	var newMachine *v1alpha1.Machine
 	if newMachine, err = r.controlMachineClient.Machines(namespace).Create(ctx, machine, metav1.CreateOptions{}); err != nil {
 		klog.Error(err)
-		r.Recorder.Eventf(object, v1.EventTypeWarning, FailedCreateMachineReason, "Error creating: %v", err)
-		return err
+		recorder.Eventf(object, v1.EventTypeWarning, FailedCreateMachineReason, "Error creating: %v", err)
+		return nil, err
 	}
 	accessor, err := meta.Accessor(object)
 	if err != nil {
 		klog.Errorf("parentObject does not have ObjectMeta, %v", err)
-		return nil
+		return newMachine, nil
 	}
 
 	klog.V(3).Infof("Controller %v created machine %v", accessor.GetName(), newMachine.Name)
-	r.Recorder.Eventf(object, v1.EventTypeNormal, SuccessfulCreateMachineReason, "Created Machine: %v", newMachine.Name)
+	recorder.Eventf(object, v1.EventTypeNormal, SuccessfulCreateMachineReason, "Created Machine: %v", newMachine.Name)
 
-	return nil
+	return newMachine, nil
 }
 
-// PatchMachine applies a patch on machine
+// PatchMachine applies a JSON merge patch on machine
 func (r RealMachineControl) PatchMachine(ctx context.Context, namespace string, name string, data []byte) error {
-	_, err := r.controlMachineClient.Machines(namespace).Patch(ctx, name, types.MergePatchType, data, metav1.PatchOptions{})
+	return r.PatchMachineWithType(ctx, namespace, name, types.MergePatchType, data)
+}
+
+// PatchMachineWithType applies a patch of the given type on machine
+func (r RealMachineControl) PatchMachineWithType(ctx context.Context, namespace string, name string, pt types.PatchType, data []byte) error {
+	_, err := r.controlMachineClient.Machines(namespace).Patch(ctx, name, pt, data, metav1.PatchOptions{})
+	return err
+}
+
+// PatchMachineStatus applies a JSON merge patch on the status of machine, leaving its spec untouched
+func (r RealMachineControl) PatchMachineStatus(ctx context.Context, namespace string, name string, data []byte) error {
+	_, err := r.controlMachineClient.Machines(namespace).Patch(ctx, name, types.MergePatchType, data, metav1.PatchOptions{}, "status")
 	return err
 }
 
@@ -613,67 +1267,345 @@ func (r RealMachineControl) DeleteMachine(ctx context.Context, namespace string,
 	klog.V(3).Infof("Controller %v deleting machine %v", accessor.GetName(), machineID)
 
 	if err := r.controlMachineClient.Machines(namespace).Delete(ctx, machineID, metav1.DeleteOptions{}); err != nil {
-		r.Recorder.Eventf(object, v1.EventTypeWarning, FailedDeleteMachineReason, "Error deleting: %v", err)
+		r.RecorderFor(object).Eventf(object, v1.EventTypeWarning, FailedDeleteMachineReason, "Error deleting: %v", err)
 		return fmt.Errorf("unable to delete machines: %v", err)
 	}
-	r.Recorder.Eventf(object, v1.EventTypeNormal, SuccessfulDeleteMachineReason, "Deleted machine: %v", machineID)
+	r.RecorderFor(object).Eventf(object, v1.EventTypeNormal, SuccessfulDeleteMachineReason, "Deleted machine: %v", machineID)
 
 	return nil
 }
 
-// --- //
+// DeleteMachines deletes the machines identified by machineIDs in slow-start batches,
+// recording an event for every deleted machine.
+func (r RealMachineControl) DeleteMachines(ctx context.Context, namespace string, machineIDs []string, object runtime.Object) error {
+	return deleteMachinesInBatches(machineIDs, func(machineID string) error {
+		return r.DeleteMachine(ctx, namespace, machineID, object)
+	})
+}
 
-// -- Fake Machine Control -- //
+// deleteMachinesInBatches calls deleteFn for every machine ID in batches starting at SlowStartInitialBatchSize.
+// Once a batch fails, the remaining machines are skipped. The returned error names all machines
+// which failed to be deleted or were skipped.
+func deleteMachinesInBatches(machineIDs []string, deleteFn func(machineID string) error) error {
+	pending := make(chan string, len(machineIDs))
+	for _, machineID := range machineIDs {
+		pending <- machineID
+	}
+	close(pending)
+
+	var (
+		mutex  sync.Mutex
+		failed []string
+		errs   []error
+	)
+	_, _ = slowStartBatch(len(machineIDs), SlowStartInitialBatchSize, func() error {
+		machineID := <-pending
+		if err := deleteFn(machineID); err != nil {
+			mutex.Lock()
+			defer mutex.Unlock()
+			failed = append(failed, machineID)
+			errs = append(errs, err)
+			return err
+		}
+		return nil
+	})
+	if len(failed) == 0 {
+		return nil
+	}
 
-// FakeMachineControl is the fake implementation of machineControlInterface.
-type FakeMachineControl struct {
-	controlMachineClient *fakemachineapi.FakeMachineV1alpha1
-	Recorder             record.EventRecorder
+	var skipped []string
+	for machineID := range pending {
+		skipped = append(skipped, machineID)
+	}
+	sort.Strings(failed)
+	if len(skipped) > 0 {
+		return fmt.Errorf("unable to delete machines %s, skipped machines %s: %v", strings.Join(failed, ", "), strings.Join(skipped, ", "), utilerrors.NewAggregate(errs))
+	}
+	return fmt.Errorf("unable to delete machines %s: %v", strings.Join(failed, ", "), utilerrors.NewAggregate(errs))
 }
 
-// CreateMachines initiates a create machine for a RealMachineControl
-func (r FakeMachineControl) CreateMachines(ctx context.Context, namespace string, template *v1alpha1.MachineTemplateSpec, object runtime.Object) error {
-	return r.createMachines(ctx, namespace, template, object, nil)
+// RecorderFor returns the event recorder to be used for events about the given object.
+// Events about cluster-scoped objects are recorded in the configured Namespace instead of the default namespace.
+func (r RealMachineControl) RecorderFor(object runtime.Object) record.EventRecorder {
+	return recorderFor(r.Recorder, r.Namespace, object)
 }
 
-func (r FakeMachineControl) createMachines(ctx context.Context, namespace string, template *v1alpha1.MachineTemplateSpec, object runtime.Object, controllerRef *metav1.OwnerReference) error {
-	machine, err := GetFakeMachineFromTemplate(template, object, controllerRef)
-	if err != nil {
-		return err
+// recorderFor returns the recorder scoping events about cluster-scoped objects to namespace, if set.
+func recorderFor(recorder record.EventRecorder, namespace string, object runtime.Object) record.EventRecorder {
+	if namespace == "" || object == nil {
+		return recorder
 	}
-
-	if labels.Set(machine.Labels).AsSelectorPreValidated().Empty() {
-		return fmt.Errorf("unable to create machines, no labels")
+	accessor, err := meta.Accessor(object)
+	if err != nil || accessor.GetNamespace() != "" {
+		return recorder
 	}
+	return &namespacedEventRecorder{EventRecorder: recorder, namespace: namespace}
+}
 
-	var newMachine *v1alpha1.Machine
-	if newMachine, err = r.controlMachineClient.Machines(namespace).Create(ctx, machine, metav1.CreateOptions{}); err != nil {
-		klog.Error(err)
-		r.Recorder.Eventf(object, v1.EventTypeWarning, FailedCreateMachineReason, "Error creating: %v", err)
-		return err
-	}
-	accessor, err := meta.Accessor(object)
+// namespacedEventRecorder records events against references of the objects which are scoped to namespace.
+type namespacedEventRecorder struct {
+	record.EventRecorder
+	namespace string
+}
+
+func (n *namespacedEventRecorder) referenceFor(object runtime.Object) runtime.Object {
+	objectRef, err := reference.GetReference(machinescheme.Scheme, object)
 	if err != nil {
-		klog.Errorf("parentObject does not have ObjectMeta, %v", err)
-		return nil
+		klog.Warningf("Could not construct reference to %#v, recording event unscoped: %v", object, err)
+		return object
 	}
+	objectRef.Namespace = n.namespace
+	return objectRef
+}
 
-	klog.V(2).Infof("Controller %v created machine %v", accessor.GetName(), newMachine.Name)
+// Event records an event about object in the configured namespace.
+func (n *namespacedEventRecorder) Event(object runtime.Object, eventtype, reason, message string) {
+	n.EventRecorder.Event(n.referenceFor(object), eventtype, reason, message)
+}
 
-	return nil
+// Eventf records a formatted event about object in the configured namespace.
+func (n *namespacedEventRecorder) Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...interface{}) {
+	n.EventRecorder.Eventf(n.referenceFor(object), eventtype, reason, messageFmt, args...)
 }
 
-// CreateMachinesWithControllerRef creates a machine with controller reference
-func (r FakeMachineControl) CreateMachinesWithControllerRef(ctx context.Context, namespace string, template *v1alpha1.MachineTemplateSpec, controllerObject runtime.Object, controllerRef *metav1.OwnerReference) error {
+// AnnotatedEventf records a formatted and annotated event about object in the configured namespace.
+func (n *namespacedEventRecorder) AnnotatedEventf(object runtime.Object, annotations map[string]string, eventtype, reason, messageFmt string, args ...interface{}) {
+	n.EventRecorder.AnnotatedEventf(n.referenceFor(object), annotations, eventtype, reason, messageFmt, args...)
+}
+
+// recorderWithContext returns a recorder which drops events once ctx is done.
+func recorderWithContext(ctx context.Context, recorder record.EventRecorder) record.EventRecorder {
+	return &contextEventRecorder{EventRecorder: recorder, ctx: ctx}
+}
+
+// contextEventRecorder records events only as long as its context is not done. The events are still written
+// asynchronously by the event broadcaster, which doesn't take a context, so an event recorded before the
+// context is done is not cancelled.
+type contextEventRecorder struct {
+	record.EventRecorder
+	ctx context.Context
+}
+
+func (c *contextEventRecorder) done(reason string) bool {
+	if err := c.ctx.Err(); err != nil {
+		klog.V(3).Infof("Skipping %s event: %v", reason, err)
+		return true
+	}
+	return false
+}
+
+// Event records an event about object unless the context is done.
+func (c *contextEventRecorder) Event(object runtime.Object, eventtype, reason, message string) {
+	if !c.done(reason) {
+		c.EventRecorder.Event(object, eventtype, reason, message)
+	}
+}
+
+// Eventf records a formatted event about object unless the context is done.
+func (c *contextEventRecorder) Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...interface{}) {
+	if !c.done(reason) {
+		c.EventRecorder.Eventf(object, eventtype, reason, messageFmt, args...)
+	}
+}
+
+// AnnotatedEventf records a formatted and annotated event about object unless the context is done.
+func (c *contextEventRecorder) AnnotatedEventf(object runtime.Object, annotations map[string]string, eventtype, reason, messageFmt string, args ...interface{}) {
+	if !c.done(reason) {
+		c.EventRecorder.AnnotatedEventf(object, annotations, eventtype, reason, messageFmt, args...)
+	}
+}
+
+const (
+	// MachineSetNameAnnotation is the annotation stamped on a machine holding the name of its owning machineSet
+	MachineSetNameAnnotation = "machine.sapcloud.io/machineset"
+	// MachineDeploymentNameAnnotation is the annotation stamped on a machine holding the name of its owning machineDeployment
+	MachineDeploymentNameAnnotation = "machine.sapcloud.io/deployment"
+)
+
+// StampOwnershipAnnotations patches the machineSet and machineDeployment name annotations onto the machine,
+// so that the ownership chain stays queryable even if the owner references are stripped.
+// Empty names are skipped, and no API call is issued if the machine already carries the expected values.
+func StampOwnershipAnnotations(ctx context.Context, ctrl MachineControlInterface, machine *v1alpha1.Machine, machineSetName, deploymentName string) error {
+	desired := map[string]string{}
+	if machineSetName != "" && machine.Annotations[MachineSetNameAnnotation] != machineSetName {
+		desired[MachineSetNameAnnotation] = machineSetName
+	}
+	if deploymentName != "" && machine.Annotations[MachineDeploymentNameAnnotation] != deploymentName {
+		desired[MachineDeploymentNameAnnotation] = deploymentName
+	}
+	if len(desired) == 0 {
+		return nil
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": desired,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return clientretry.RetryOnConflict(annotationUpdateBackoff(), func() error {
+		return ctrl.PatchMachine(ctx, machine.Namespace, machine.Name, patch)
+	})
+}
+
+// mcmManagedMachineLabels are the machine labels maintained by MCM itself, which are never
+// removed while reconciling the machine labels toward the template.
+var mcmManagedMachineLabels = sets.New(
+	v1alpha1.DefaultMachineDeploymentUniqueLabelKey,
+	v1alpha1.NodeLabelKey,
+)
+
+// ReconcileMachineLabels patches the labels of the machine to the desired template labels, so that changes
+// of the template labels propagate to existing machines without recreating them. Labels missing on the machine
+// or having a different value are set, labels not present in desired are removed, except for the labels managed
+// by MCM such as the machine-template-hash. No API call is issued if the labels are already in sync.
+func ReconcileMachineLabels(ctx context.Context, ctrl MachineControlInterface, machine *v1alpha1.Machine, desired map[string]string) error {
+	delta := map[string]interface{}{}
+	for key, value := range desired {
+		if current, ok := machine.Labels[key]; !ok || current != value {
+			delta[key] = value
+		}
+	}
+	for key := range machine.Labels {
+		if _, ok := desired[key]; !ok && !mcmManagedMachineLabels.Has(key) {
+			// a null value removes the label with a merge patch
+			delta[key] = nil
+		}
+	}
+	if len(delta) == 0 {
+		return nil
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels": delta,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return clientretry.RetryOnConflict(annotationUpdateBackoff(), func() error {
+		return ctrl.PatchMachine(ctx, machine.Namespace, machine.Name, patch)
+	})
+}
+
+// ReconcileMachinePriority patches the MachinePriority annotation of the machine to the given priority,
+// e.g. as computed by ComputePriorityFromUtilization. No API call is issued if the annotation is already in sync.
+func ReconcileMachinePriority(ctx context.Context, ctrl MachineControlInterface, machine *v1alpha1.Machine, priority int) error {
+	value := strconv.Itoa(priority)
+	if machine.Annotations[machineutils.MachinePriority] == value {
+		return nil
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{
+				machineutils.MachinePriority: value,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return clientretry.RetryOnConflict(annotationUpdateBackoff(), func() error {
+		return ctrl.PatchMachine(ctx, machine.Namespace, machine.Name, patch)
+	})
+}
+
+// --- //
+
+// -- Fake Machine Control -- //
+
+// FakeMachineControl is the fake implementation of machineControlInterface.
+type FakeMachineControl struct {
+	controlMachineClient *fakemachineapi.FakeMachineV1alpha1
+	Recorder             record.EventRecorder
+	// Namespace is the namespace in which events about cluster-scoped objects are recorded, as for RealMachineControl.
+	Namespace string
+}
+
+// RecorderFor returns the event recorder to be used for events about the given object, as RealMachineControl does.
+func (r FakeMachineControl) RecorderFor(object runtime.Object) record.EventRecorder {
+	return recorderFor(r.Recorder, r.Namespace, object)
+}
+
+// CreateMachines initiates a create machine for a RealMachineControl
+func (r FakeMachineControl) CreateMachines(ctx context.Context, namespace string, template *v1alpha1.MachineTemplateSpec, object runtime.Object) error {
+	return r.createMachines(ctx, namespace, template, object, nil)
+}
+
+// CreateMachinesReturning creates a machine and returns the created machine
+func (r FakeMachineControl) CreateMachinesReturning(ctx context.Context, namespace string, template *v1alpha1.MachineTemplateSpec, object runtime.Object) (*v1alpha1.Machine, error) {
+	return r.createMachinesReturning(ctx, namespace, template, object, nil)
+}
+
+func (r FakeMachineControl) createMachines(ctx context.Context, namespace string, template *v1alpha1.MachineTemplateSpec, object runtime.Object, controllerRef *metav1.OwnerReference) error {
+	_, err := r.createMachinesReturning(ctx, namespace, template, object, controllerRef)
+	return err
+}
+
+func (r FakeMachineControl) createMachinesReturning(ctx context.Context, namespace string, template *v1alpha1.MachineTemplateSpec, object runtime.Object, controllerRef *metav1.OwnerReference) (*v1alpha1.Machine, error) {
+	if errs := ValidateMachineTemplateForCreate(template); len(errs) > 0 {
+		return nil, fmt.Errorf("unable to create machines, invalid template: %v", errs.ToAggregate())
+	}
+
+	machine, err := GetFakeMachineFromTemplate(template, object, controllerRef)
+	if err != nil {
+		return nil, err
+	}
+
+	var newMachine *v1alpha1.Machine
+	if newMachine, err = r.controlMachineClient.Machines(namespace).Create(ctx, machine, metav1.CreateOptions{}); err != nil {
+		klog.Error(err)
+		r.RecorderFor(object).Eventf(object, v1.EventTypeWarning, FailedCreateMachineReason, "Error creating: %v", err)
+		return nil, err
+	}
+	accessor, err := meta.Accessor(object)
+	if err != nil {
+		klog.Errorf("parentObject does not have ObjectMeta, %v", err)
+		return newMachine, nil
+	}
+
+	klog.V(2).Infof("Controller %v created machine %v", accessor.GetName(), newMachine.Name)
+
+	return newMachine, nil
+}
+
+// CreateMachinesWithControllerRef creates a machine with controller reference
+func (r FakeMachineControl) CreateMachinesWithControllerRef(ctx context.Context, namespace string, template *v1alpha1.MachineTemplateSpec, controllerObject runtime.Object, controllerRef *metav1.OwnerReference) error {
 	if err := validateControllerRef(controllerRef); err != nil {
 		return err
 	}
 	return r.createMachines(ctx, namespace, template, controllerObject, controllerRef)
 }
 
-// PatchMachine applies a patch on machine
+// CreateMachinesWithControllerRefReturning creates a machine with controller reference and returns the created machine
+func (r FakeMachineControl) CreateMachinesWithControllerRefReturning(ctx context.Context, namespace string, template *v1alpha1.MachineTemplateSpec, controllerObject runtime.Object, controllerRef *metav1.OwnerReference) (*v1alpha1.Machine, error) {
+	if err := validateControllerRef(controllerRef); err != nil {
+		return nil, err
+	}
+	return r.createMachinesReturning(ctx, namespace, template, controllerObject, controllerRef)
+}
+
+// PatchMachine applies a JSON merge patch on machine
 func (r FakeMachineControl) PatchMachine(ctx context.Context, namespace string, name string, data []byte) error {
-	_, err := r.controlMachineClient.Machines(namespace).Patch(ctx, name, types.MergePatchType, data, metav1.PatchOptions{})
+	return r.PatchMachineWithType(ctx, namespace, name, types.MergePatchType, data)
+}
+
+// PatchMachineWithType applies a patch of the given type on machine
+func (r FakeMachineControl) PatchMachineWithType(ctx context.Context, namespace string, name string, pt types.PatchType, data []byte) error {
+	_, err := r.controlMachineClient.Machines(namespace).Patch(ctx, name, pt, data, metav1.PatchOptions{})
+	return err
+}
+
+// PatchMachineStatus applies a JSON merge patch on the status of machine, leaving its spec untouched
+func (r FakeMachineControl) PatchMachineStatus(ctx context.Context, namespace string, name string, data []byte) error {
+	_, err := r.controlMachineClient.Machines(namespace).Patch(ctx, name, types.MergePatchType, data, metav1.PatchOptions{}, "status")
 	return err
 }
 
@@ -686,13 +1618,26 @@ func (r FakeMachineControl) DeleteMachine(ctx context.Context, namespace string,
 	klog.V(2).Infof("Controller %v deleting machine %v", accessor.GetName(), machineID)
 
 	if err := r.controlMachineClient.Machines(namespace).Delete(ctx, machineID, metav1.DeleteOptions{}); err != nil {
-		r.Recorder.Eventf(object, v1.EventTypeWarning, FailedDeleteMachineReason, "Error deleting: %v", err)
+		r.RecorderFor(object).Eventf(object, v1.EventTypeWarning, FailedDeleteMachineReason, "Error deleting: %v", err)
 		return fmt.Errorf("unable to delete machines: %v", err)
 	}
 
 	return nil
 }
 
+// DeleteMachines deletes the machines identified by machineIDs in slow-start batches
+func (r FakeMachineControl) DeleteMachines(ctx context.Context, namespace string, machineIDs []string, object runtime.Object) error {
+	return deleteMachinesInBatches(machineIDs, func(machineID string) error {
+		return r.DeleteMachine(ctx, namespace, machineID, object)
+	})
+}
+
+// MachineNameGenerator returns the name of a machine created by the FakeMachineControl from the given prefix.
+// It defaults to appending a random 5 character suffix and may be replaced by tests needing deterministic names.
+var MachineNameGenerator = func(prefix string) string {
+	return prefix + "-" + uuid.New().String()[:5]
+}
+
 // GetFakeMachineFromTemplate passes the machine template spec to return the machine object
 func GetFakeMachineFromTemplate(template *v1alpha1.MachineTemplateSpec, parentObject runtime.Object, controllerRef *metav1.OwnerReference) (*v1alpha1.Machine, error) {
 
@@ -706,12 +1651,11 @@ func GetFakeMachineFromTemplate(template *v1alpha1.MachineTemplateSpec, parentOb
 		return nil, fmt.Errorf("parentObject does not have ObjectMeta, %v", err)
 	}
 	prefix := getMachinesPrefix(accessor.GetName())
-	prefix = prefix + "-" + uuid.New().String()[:5]
 	machine := &v1alpha1.Machine{
 		ObjectMeta: metav1.ObjectMeta{
 			Labels:      desiredLabels,
 			Annotations: desiredAnnotations,
-			Name:        prefix,
+			Name:        MachineNameGenerator(prefix),
 			Finalizers:  desiredFinalizers,
 		},
 		Spec: v1alpha1.MachineSpec{
@@ -728,54 +1672,185 @@ func GetFakeMachineFromTemplate(template *v1alpha1.MachineTemplateSpec, parentOb
 
 // --- //
 
-// ActiveMachines type allows custom sorting of machines so a controller can pick the best ones to delete.
-type ActiveMachines []*v1alpha1.Machine
+// -- Rate Limited Machine Control -- //
 
-func (s ActiveMachines) Len() int      { return len(s) }
-func (s ActiveMachines) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+// RateLimitedMachineControl decorates a MachineControlInterface and paces the creation and deletion
+// of machines according to the given QPS and burst, to keep the calls within the provider's rate limits.
+// Calls block until a token is available or the context is done.
+type RateLimitedMachineControl struct {
+	MachineControlInterface
+	limiter *rate.Limiter
+}
 
-func (s ActiveMachines) Less(i, j int) bool {
-	// Default priority for machine objects
-	machineIPriority := 3
-	machineJPriority := 3
+// NewRateLimitedMachineControl returns a RateLimitedMachineControl wrapping machineControl.
+// A non-positive qps disables rate limiting.
+func NewRateLimitedMachineControl(machineControl MachineControlInterface, qps float64, burst int) *RateLimitedMachineControl {
+	limit := rate.Limit(qps)
+	if qps <= 0 {
+		limit = rate.Inf
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimitedMachineControl{
+		MachineControlInterface: machineControl,
+		limiter:                 rate.NewLimiter(limit, burst),
+	}
+}
 
-	if s[i].Annotations != nil && s[i].Annotations[machineutils.MachinePriority] != "" {
-		num, err := strconv.Atoi(s[i].Annotations[machineutils.MachinePriority])
-		if err == nil {
-			machineIPriority = num
-		} else {
-			klog.Errorf("Machine priority is taken to be the default value (3). Couldn't convert machine priority to integer for machine:%s. Error message - %s", s[i].Name, err)
-		}
+// CreateMachines waits for the rate limiter and creates new machines according to the spec
+func (r *RateLimitedMachineControl) CreateMachines(ctx context.Context, namespace string, template *v1alpha1.MachineTemplateSpec, object runtime.Object) error {
+	if err := r.limiter.Wait(ctx); err != nil {
+		return fmt.Errorf("unable to create machines, rate limiter wait failed: %v", err)
 	}
+	return r.MachineControlInterface.CreateMachines(ctx, namespace, template, object)
+}
 
-	if s[j].Annotations != nil && s[j].Annotations[machineutils.MachinePriority] != "" {
-		num, err := strconv.Atoi(s[j].Annotations[machineutils.MachinePriority])
-		if err == nil {
-			machineJPriority = num
-		} else {
-			klog.Errorf("Machine priority is taken to be the default value (3). Couldn't convert machine priority to integer for machine:%s. Error message - %s", s[j].Name, err)
-		}
+// CreateMachinesWithControllerRef waits for the rate limiter and creates new machines with the controller reference
+func (r *RateLimitedMachineControl) CreateMachinesWithControllerRef(ctx context.Context, namespace string, template *v1alpha1.MachineTemplateSpec, controllerObject runtime.Object, controllerRef *metav1.OwnerReference) error {
+	if err := r.limiter.Wait(ctx); err != nil {
+		return fmt.Errorf("unable to create machines, rate limiter wait failed: %v", err)
+	}
+	return r.MachineControlInterface.CreateMachinesWithControllerRef(ctx, namespace, template, controllerObject, controllerRef)
+}
+
+// CreateMachinesReturning waits for the rate limiter, creates a new machine and returns it
+func (r *RateLimitedMachineControl) CreateMachinesReturning(ctx context.Context, namespace string, template *v1alpha1.MachineTemplateSpec, object runtime.Object) (*v1alpha1.Machine, error) {
+	if err := r.limiter.Wait(ctx); err != nil {
+		return nil, fmt.Errorf("unable to create machines, rate limiter wait failed: %v", err)
+	}
+	return r.MachineControlInterface.CreateMachinesReturning(ctx, namespace, template, object)
+}
+
+// CreateMachinesWithControllerRefReturning waits for the rate limiter, creates a new machine with the controller reference and returns it
+func (r *RateLimitedMachineControl) CreateMachinesWithControllerRefReturning(ctx context.Context, namespace string, template *v1alpha1.MachineTemplateSpec, controllerObject runtime.Object, controllerRef *metav1.OwnerReference) (*v1alpha1.Machine, error) {
+	if err := r.limiter.Wait(ctx); err != nil {
+		return nil, fmt.Errorf("unable to create machines, rate limiter wait failed: %v", err)
+	}
+	return r.MachineControlInterface.CreateMachinesWithControllerRefReturning(ctx, namespace, template, controllerObject, controllerRef)
+}
+
+// DeleteMachine waits for the rate limiter and deletes the machine identified by machineID
+func (r *RateLimitedMachineControl) DeleteMachine(ctx context.Context, namespace string, machineID string, object runtime.Object) error {
+	if err := r.limiter.Wait(ctx); err != nil {
+		return fmt.Errorf("unable to delete machine %s, rate limiter wait failed: %v", machineID, err)
+	}
+	return r.MachineControlInterface.DeleteMachine(ctx, namespace, machineID, object)
+}
+
+// DeleteMachines deletes the machines identified by machineIDs in slow-start batches, waiting for the rate limiter before each deletion
+func (r *RateLimitedMachineControl) DeleteMachines(ctx context.Context, namespace string, machineIDs []string, object runtime.Object) error {
+	return deleteMachinesInBatches(machineIDs, func(machineID string) error {
+		return r.DeleteMachine(ctx, namespace, machineID, object)
+	})
+}
+
+// --- //
+
+// -- Routing Machine Control -- //
+
+// MachineControlRouter selects the MachineControlInterface backend handling a call for the given object.
+type MachineControlRouter func(namespace string, object runtime.Object) MachineControlInterface
+
+// RoutingMachineControl dispatches each call to one of several MachineControlInterface backends selected by a router,
+// e.g. to canary a new create/delete path for some machine sets during a migration.
+type RoutingMachineControl struct {
+	backends []MachineControlInterface
+	router   MachineControlRouter
+}
+
+// NewRoutingMachineControl returns a RoutingMachineControl dispatching calls to the backend selected by router.
+// If router is nil, or selects no backend, calls are dispatched to the first backend.
+func NewRoutingMachineControl(router MachineControlRouter, backends ...MachineControlInterface) (*RoutingMachineControl, error) {
+	if len(backends) == 0 {
+		return nil, fmt.Errorf("routing machine control requires at least one backend")
 	}
+	return &RoutingMachineControl{
+		backends: backends,
+		router:   router,
+	}, nil
+}
 
-	// Map containing machinePhase priority
-	// the lower the priority, the more likely
-	// it is to be deleted
-	m := map[v1alpha1.MachinePhase]int{
-		v1alpha1.MachineTerminating:      0,
-		v1alpha1.MachineFailed:           1,
-		v1alpha1.MachineCrashLoopBackOff: 2,
-		v1alpha1.MachineUnknown:          3,
-		v1alpha1.MachinePending:          4,
-		v1alpha1.MachineAvailable:        5,
-		v1alpha1.MachineRunning:          6,
+func (r *RoutingMachineControl) route(namespace string, object runtime.Object) MachineControlInterface {
+	if r.router != nil {
+		if backend := r.router(namespace, object); backend != nil {
+			return backend
+		}
 	}
+	return r.backends[0]
+}
+
+// CreateMachines creates new machines through the backend selected for the object
+func (r *RoutingMachineControl) CreateMachines(ctx context.Context, namespace string, template *v1alpha1.MachineTemplateSpec, object runtime.Object) error {
+	return r.route(namespace, object).CreateMachines(ctx, namespace, template, object)
+}
+
+// CreateMachinesWithControllerRef creates new machines with the controller reference through the backend selected for the controller object
+func (r *RoutingMachineControl) CreateMachinesWithControllerRef(ctx context.Context, namespace string, template *v1alpha1.MachineTemplateSpec, controllerObject runtime.Object, controllerRef *metav1.OwnerReference) error {
+	return r.route(namespace, controllerObject).CreateMachinesWithControllerRef(ctx, namespace, template, controllerObject, controllerRef)
+}
 
+// CreateMachinesReturning creates a new machine through the backend selected for the object and returns it
+func (r *RoutingMachineControl) CreateMachinesReturning(ctx context.Context, namespace string, template *v1alpha1.MachineTemplateSpec, object runtime.Object) (*v1alpha1.Machine, error) {
+	return r.route(namespace, object).CreateMachinesReturning(ctx, namespace, template, object)
+}
+
+// CreateMachinesWithControllerRefReturning creates a new machine with the controller reference through the backend selected for the controller object and returns it
+func (r *RoutingMachineControl) CreateMachinesWithControllerRefReturning(ctx context.Context, namespace string, template *v1alpha1.MachineTemplateSpec, controllerObject runtime.Object, controllerRef *metav1.OwnerReference) (*v1alpha1.Machine, error) {
+	return r.route(namespace, controllerObject).CreateMachinesWithControllerRefReturning(ctx, namespace, template, controllerObject, controllerRef)
+}
+
+// DeleteMachine deletes the machine through the backend selected for the object
+func (r *RoutingMachineControl) DeleteMachine(ctx context.Context, namespace string, machineID string, object runtime.Object) error {
+	return r.route(namespace, object).DeleteMachine(ctx, namespace, machineID, object)
+}
+
+// DeleteMachines deletes the machines through the backend selected for the object
+func (r *RoutingMachineControl) DeleteMachines(ctx context.Context, namespace string, machineIDs []string, object runtime.Object) error {
+	return r.route(namespace, object).DeleteMachines(ctx, namespace, machineIDs, object)
+}
+
+// PatchMachine patches the machine through the backend selected for the namespace, as no owning object is known
+func (r *RoutingMachineControl) PatchMachine(ctx context.Context, namespace string, name string, data []byte) error {
+	return r.route(namespace, nil).PatchMachine(ctx, namespace, name, data)
+}
+
+// PatchMachineWithType patches the machine through the backend selected for the namespace, as no owning object is known
+func (r *RoutingMachineControl) PatchMachineWithType(ctx context.Context, namespace string, name string, pt types.PatchType, data []byte) error {
+	return r.route(namespace, nil).PatchMachineWithType(ctx, namespace, name, pt, data)
+}
+
+// PatchMachineStatus patches the machine status through the backend selected for the namespace, as no owning object is known
+func (r *RoutingMachineControl) PatchMachineStatus(ctx context.Context, namespace string, name string, data []byte) error {
+	return r.route(namespace, nil).PatchMachineStatus(ctx, namespace, name, data)
+}
+
+// --- //
+
+// ActiveMachines type allows custom sorting of machines so a controller can pick the best ones to delete.
+type ActiveMachines []*v1alpha1.Machine
+
+func (s ActiveMachines) Len() int      { return len(s) }
+func (s ActiveMachines) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+
+func (s ActiveMachines) Less(i, j int) bool {
+	machineICost := getMachineDeletionCost(s[i])
+	machineJCost := getMachineDeletionCost(s[j])
+	machineIPriority := getMachinePriority(s[i])
+	machineJPriority := getMachinePriority(s[j])
+	m := machinePhaseDeletionPriority
+
+	// Case-0: The deletion-cost annotation takes precedence over all other criteria.
 	// Case-1: Initially we try to prioritize machine deletion based on
 	// machinePriority annotation.
 	// Case-2: If both priorities are equal, then we look at their machinePhase
-	// and prioritize as mentioned in the above map
+	// and prioritize as mentioned in machinePhaseDeletionPriority
 	// Case-3: If both Case-1 & Case-2 is false, we prioritize based on creation time
-	if machineIPriority != machineJPriority {
+	// Case-4: If the creation times are equal as well, we fall back to the machine name
+	// to keep the order total and deterministic
+	if machineICost != machineJCost {
+		return machineICost < machineJCost
+	} else if machineIPriority != machineJPriority {
 		return machineIPriority < machineJPriority
 	} else if m[s[i].Status.CurrentStatus.Phase] != m[s[j].Status.CurrentStatus.Phase] {
 		return m[s[i].Status.CurrentStatus.Phase] < m[s[j].Status.CurrentStatus.Phase]
@@ -783,7 +1858,189 @@ func (s ActiveMachines) Less(i, j int) bool {
 		return s[i].CreationTimestamp.Before(&s[j].CreationTimestamp)
 	}
 
-	return false
+	return s[i].Name < s[j].Name
+}
+
+// MachinesByReadiness type allows sorting machines so that machines which are not ready are deleted first.
+// Failed, CrashLoopBackOff and Unknown machines are sorted first and Running and Available machines last,
+// using the creation timestamp and name as tie breakers. Unlike ActiveMachines, it ignores the
+// machinePriority annotation.
+type MachinesByReadiness []*v1alpha1.Machine
+
+func (s MachinesByReadiness) Len() int      { return len(s) }
+func (s MachinesByReadiness) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+
+func (s MachinesByReadiness) Less(i, j int) bool {
+	readinessI := machineReadinessRank(s[i])
+	readinessJ := machineReadinessRank(s[j])
+	if readinessI != readinessJ {
+		return readinessI < readinessJ
+	} else if !s[i].CreationTimestamp.Equal(&s[j].CreationTimestamp) {
+		return s[i].CreationTimestamp.Before(&s[j].CreationTimestamp)
+	}
+	return s[i].Name < s[j].Name
+}
+
+// machineReadinessRank returns 0 for machines which failed to become ready, 2 for ready machines and 1 otherwise.
+func machineReadinessRank(machine *v1alpha1.Machine) int {
+	switch machine.Status.CurrentStatus.Phase {
+	case v1alpha1.MachineFailed, v1alpha1.MachineCrashLoopBackOff, v1alpha1.MachineUnknown:
+		return 0
+	case v1alpha1.MachineRunning, v1alpha1.MachineAvailable:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// ByMachineSetConcentration returns a function sorting machines spread across multiple machine sets in deletion order,
+// so that the machine set closest to empty is retired first instead of thinning out all of them. machineSetOf returns
+// the name of the machine set of a machine.
+func ByMachineSetConcentration(machineSetOf func(*v1alpha1.Machine) string) func(machines []*v1alpha1.Machine) {
+	return func(machines []*v1alpha1.Machine) {
+		counts := make(map[string]int)
+		for _, machine := range machines {
+			counts[machineSetOf(machine)]++
+		}
+		sortActiveMachinesByKey(machines, func(machine *v1alpha1.Machine) int {
+			return counts[machineSetOf(machine)]
+		})
+	}
+}
+
+// sortActiveMachinesByKey sorts machines in the ActiveMachines order, with the given key ranked right below the
+// deletion-cost and machinePriority annotations: machines with a lower key are deleted first, unless their
+// annotations differ. Machines with equal keys are ordered by the remaining ActiveMachines criteria.
+func sortActiveMachinesByKey(machines []*v1alpha1.Machine, key func(*v1alpha1.Machine) int) {
+	sort.Sort(activeMachinesByKey{ActiveMachines: machines, key: key})
+}
+
+// activeMachinesByKey sorts machines by a key composed with the ActiveMachines order.
+type activeMachinesByKey struct {
+	ActiveMachines
+	key func(*v1alpha1.Machine) int
+}
+
+func (s activeMachinesByKey) Less(i, j int) bool {
+	if getMachineDeletionCost(s.ActiveMachines[i]) == getMachineDeletionCost(s.ActiveMachines[j]) &&
+		getMachinePriority(s.ActiveMachines[i]) == getMachinePriority(s.ActiveMachines[j]) {
+		keyI := s.key(s.ActiveMachines[i])
+		keyJ := s.key(s.ActiveMachines[j])
+		if keyI != keyJ {
+			return keyI < keyJ
+		}
+	}
+	return s.ActiveMachines.Less(i, j)
+}
+
+// ByNodeCordonState returns a function sorting machines in deletion order, so that machines whose node is already
+// cordoned, e.g. by a prior maintenance, are deleted before machines on schedulable nodes, which may still run
+// workload. isCordoned returns whether the node of a machine is cordoned.
+func ByNodeCordonState(isCordoned func(*v1alpha1.Machine) bool) func(machines []*v1alpha1.Machine) {
+	return func(machines []*v1alpha1.Machine) {
+		sortActiveMachinesByKey(machines, func(machine *v1alpha1.Machine) int {
+			if isCordoned(machine) {
+				return 0
+			}
+			return 1
+		})
+	}
+}
+
+// SelectMachinesOnNodes returns the machines backed by one of the given nodes in the ActiveMachines order, e.g. to
+// replace them gracefully before a planned maintenance of the nodes. Deletion protected machines are not selected,
+// as replacing them deletes them. The passed slice is not modified.
+func SelectMachinesOnNodes(machines []*v1alpha1.Machine, nodeNames sets.String) []*v1alpha1.Machine {
+	var selected []*v1alpha1.Machine
+	for _, machine := range machines {
+		if IsDeletionProtected(machine) {
+			continue
+		}
+		if nodeName := machine.Labels[v1alpha1.NodeLabelKey]; nodeName != "" && nodeNames.Has(nodeName) {
+			selected = append(selected, machine)
+		}
+	}
+	sort.Sort(ActiveMachines(selected))
+	return selected
+}
+
+// machinePhaseDeletionPriority maps a machinePhase to its deletion priority,
+// the lower the priority, the more likely it is to be deleted
+var machinePhaseDeletionPriority = map[v1alpha1.MachinePhase]int{
+	v1alpha1.MachineTerminating:      0,
+	v1alpha1.MachineFailed:           1,
+	v1alpha1.MachineCrashLoopBackOff: 2,
+	v1alpha1.MachineUnknown:          3,
+	v1alpha1.MachinePending:          4,
+	v1alpha1.MachineAvailable:        5,
+	v1alpha1.MachineRunning:          6,
+}
+
+// getMachinePriority returns the value of the machinePriority annotation of the machine,
+// defaulting to 3 if it is unset or invalid
+func getMachinePriority(machine *v1alpha1.Machine) int {
+	// Default priority for machine objects
+	priority := defaultMachinePriority
+	if machine.Annotations != nil && machine.Annotations[machineutils.MachinePriority] != "" {
+		num, err := strconv.Atoi(machine.Annotations[machineutils.MachinePriority])
+		if err == nil {
+			priority = num
+		} else {
+			klog.Errorf("Machine priority is taken to be the default value (3). Couldn't convert machine priority to integer for machine:%s. Error message - %s", machine.Name, err)
+		}
+	}
+	return priority
+}
+
+// getMachineDeletionCost returns the value of the deletion-cost annotation of the machine,
+// or 0 if the annotation is missing or invalid.
+func getMachineDeletionCost(machine *v1alpha1.Machine) int {
+	value, ok := machine.Annotations[machineutils.MachineDeletionCost]
+	if !ok {
+		return 0
+	}
+	cost, err := strconv.Atoi(value)
+	if err != nil {
+		klog.Errorf("Machine deletion cost is taken to be 0. Couldn't convert deletion cost %q to integer for machine:%s. Error message - %s", value, machine.Name, err)
+		return 0
+	}
+	return cost
+}
+
+const (
+	// deletionScoreAgeBits is the number of bits of the deletion score reserved for the machine age
+	deletionScoreAgeBits = 34
+	// deletionScorePhaseSlots is the number of distinct phase priorities in the deletion score
+	deletionScorePhaseSlots = 8
+	// maxDeletionScorePriority is the highest machinePriority distinguished by the deletion score
+	maxDeletionScorePriority = 255
+	// deletionScoreCostShift is the position of the deletion cost in the deletion score, above the
+	// age, phase and priority
+	deletionScoreCostShift = deletionScoreAgeBits + 11
+	// deletionScoreCostOffset bounds the deletion costs distinguished by the deletion score to
+	// [-deletionScoreCostOffset, deletionScoreCostOffset), and shifts them to be non-negative
+	deletionScoreCostOffset = 1 << 17
+)
+
+// DeletionPriorityScore returns a composite score for the machine derived from the same factors
+// ActiveMachines uses to order machines for deletion: the deletion-cost annotation, then the
+// machinePriority annotation, then the machine phase, then its age. The lower the score, the
+// sooner the machine is deleted, so the machine with the lowest score is the one the sort would
+// pick first. Machines created within the same second share the age component of the score, and
+// machines with equal scores are ordered by name by the sort. The machinePriority is clamped to
+// [0, 255] and the deletion cost to [-131072, 131071], so that the score never overflows or
+// turns negative.
+func DeletionPriorityScore(machine *v1alpha1.Machine) int {
+	creation := machine.CreationTimestamp.Unix()
+	if creation < 0 {
+		creation = 0
+	} else if creation >= 1<<deletionScoreAgeBits {
+		creation = 1<<deletionScoreAgeBits - 1
+	}
+	cost := min(max(getMachineDeletionCost(machine), -deletionScoreCostOffset), deletionScoreCostOffset-1) + deletionScoreCostOffset
+	priority := min(max(getMachinePriority(machine), 0), maxDeletionScorePriority)
+	rank := int64(priority)*deletionScorePhaseSlots + int64(machinePhaseDeletionPriority[machine.Status.CurrentStatus.Phase])
+	return int(int64(cost)<<deletionScoreCostShift + rank<<deletionScoreAgeBits + creation)
 }
 
 // MachineKey is the function used to get the machine name from machine object
@@ -817,6 +2074,27 @@ func (o MachineSetsByCreationTimestamp) Less(i, j int) bool {
 	return o[i].CreationTimestamp.Before(&o[j].CreationTimestamp)
 }
 
+// MachineSetsByRevision sorts a list of MachineSet by revision in ascending order, using their creation timestamp or name as a tie breaker.
+// Machine sets whose revision can't be parsed are sorted as revision 0.
+type MachineSetsByRevision []*v1alpha1.MachineSet
+
+func (o MachineSetsByRevision) Len() int      { return len(o) }
+func (o MachineSetsByRevision) Swap(i, j int) { o[i], o[j] = o[j], o[i] }
+func (o MachineSetsByRevision) Less(i, j int) bool {
+	revision1, err1 := Revision(o[i])
+	revision2, err2 := Revision(o[j])
+	if err1 != nil {
+		revision1 = 0
+	}
+	if err2 != nil {
+		revision2 = 0
+	}
+	if revision1 == revision2 {
+		return MachineSetsByCreationTimestamp(o).Less(i, j)
+	}
+	return revision1 < revision2
+}
+
 // MachineSetsBySizeOlder sorts a list of MachineSet by size in descending order, using their creation timestamp or name as a tie breaker.
 // By using the creation timestamp, this sorts from old to new machine sets.
 type MachineSetsBySizeOlder []*v1alpha1.MachineSet
@@ -843,6 +2121,37 @@ func (o MachineSetsBySizeNewer) Less(i, j int) bool {
 	return (o[i].Spec.Replicas) > (o[j].Spec.Replicas)
 }
 
+// MachineSetsByPriority sorts a list of MachineSet by their MachineSetPriority annotation in ascending order, so that
+// the machine set with the lowest priority is scaled down first. Machine sets without a valid annotation have the
+// default priority 3. Ties are broken like MachineSetsBySizeOlder.
+type MachineSetsByPriority []*v1alpha1.MachineSet
+
+func (o MachineSetsByPriority) Len() int      { return len(o) }
+func (o MachineSetsByPriority) Swap(i, j int) { o[i], o[j] = o[j], o[i] }
+func (o MachineSetsByPriority) Less(i, j int) bool {
+	priority1 := getMachineSetPriority(o[i])
+	priority2 := getMachineSetPriority(o[j])
+	if priority1 == priority2 {
+		return MachineSetsBySizeOlder(o).Less(i, j)
+	}
+	return priority1 < priority2
+}
+
+// getMachineSetPriority returns the value of the MachineSetPriority annotation of the machine set,
+// defaulting to 3 if it is unset or invalid
+func getMachineSetPriority(ms *v1alpha1.MachineSet) int {
+	value, ok := ms.Annotations[machineutils.MachineSetPriority]
+	if !ok || value == "" {
+		return defaultMachinePriority
+	}
+	priority, err := strconv.Atoi(value)
+	if err != nil {
+		klog.Errorf("MachineSet priority is taken to be the default value (%d). Couldn't convert priority %q of machineSet %s to integer: %v", defaultMachinePriority, value, ms.Name, err)
+		return defaultMachinePriority
+	}
+	return priority
+}
+
 // FilterActiveMachineSets returns machine sets that have (or at least ought to have) machines.
 func FilterActiveMachineSets(machineSets []*v1alpha1.MachineSet) []*v1alpha1.MachineSet {
 	activeFilter := func(is *v1alpha1.MachineSet) bool {
@@ -851,6 +2160,18 @@ func FilterActiveMachineSets(machineSets []*v1alpha1.MachineSet) []*v1alpha1.Mac
 	return FilterMachineSets(machineSets, activeFilter)
 }
 
+// FilterActiveMachines returns the machines which are not being deleted and are active as per
+// machineutils.IsMachineActive, i.e. neither terminating nor failed.
+func FilterActiveMachines(machines []*v1alpha1.Machine) []*v1alpha1.Machine {
+	var filtered []*v1alpha1.Machine
+	for _, machine := range machines {
+		if machine != nil && machine.DeletionTimestamp == nil && machineutils.IsMachineActive(machine) {
+			filtered = append(filtered, machine)
+		}
+	}
+	return filtered
+}
+
 type filterIS func(is *v1alpha1.MachineSet) bool
 
 // FilterMachineSets returns machine sets that are filtered by filterFn (all returned ones should match filterFn).
@@ -864,6 +2185,35 @@ func FilterMachineSets(ISes []*v1alpha1.MachineSet, filterFn filterIS) []*v1alph
 	return filtered
 }
 
+// FindOverlappingMachineSets returns the pairs of machine sets in the same namespace whose label selectors
+// can match a common machine, i.e. where either selector matches the other machine set's template labels.
+// Machine sets with overlapping selectors fight over their machines and must not be operated on.
+func FindOverlappingMachineSets(machineSets []*v1alpha1.MachineSet) [][2]*v1alpha1.MachineSet {
+	selectors := make([]labels.Selector, len(machineSets))
+	for i, is := range machineSets {
+		selector, err := metav1.LabelSelectorAsSelector(is.Spec.Selector)
+		if err != nil {
+			klog.Warningf("Invalid label selector of machine set %s/%s: %v", is.Namespace, is.Name, err)
+			selector = labels.Nothing()
+		}
+		selectors[i] = selector
+	}
+
+	var overlapping [][2]*v1alpha1.MachineSet
+	for i := range machineSets {
+		for j := i + 1; j < len(machineSets); j++ {
+			if machineSets[i].Namespace != machineSets[j].Namespace {
+				continue
+			}
+			if selectors[i].Matches(labels.Set(machineSets[j].Spec.Template.Labels)) ||
+				selectors[j].Matches(labels.Set(machineSets[i].Spec.Template.Labels)) {
+				overlapping = append(overlapping, [2]*v1alpha1.MachineSet{machineSets[i], machineSets[j]})
+			}
+		}
+	}
+	return overlapping
+}
+
 // WaitForCacheSync is a wrapper around cache.WaitForCacheSync that generates log messages
 // indicating that the controller identified by controllerName is waiting for syncs, followed by
 // either a successful or failed sync.
@@ -879,22 +2229,48 @@ func WaitForCacheSync(controllerName string, stopCh <-chan struct{}, cacheSyncs
 	return true
 }
 
-// ComputeHash returns a hash value calculated from machine template and a collisionCount to avoid hash collision
+// ComputeHash returns a hash value calculated from machine template and a collisionCount to avoid hash collision.
+// Map fields are hashed in sorted key order, so the hash doesn't depend on the insertion order of labels or annotations.
 func ComputeHash(template *v1alpha1.MachineTemplateSpec, collisionCount *int32) uint32 {
 	machineTemplateSpecHasher := fnv.New32a()
-	hashutil.DeepHashObject(machineTemplateSpecHasher, *template)
+	hashMachineTemplateSpec(machineTemplateSpecHasher, template, collisionCount)
+	return machineTemplateSpecHasher.Sum32()
+}
+
+// ComputeHash64 returns a 64-bit hash value calculated from machine template and a collisionCount to avoid hash collision.
+// It is less likely to collide than ComputeHash, but yields different values, hence callers have to opt in explicitly.
+func ComputeHash64(template *v1alpha1.MachineTemplateSpec, collisionCount *int32) uint64 {
+	machineTemplateSpecHasher := fnv.New64a()
+	hashMachineTemplateSpec(machineTemplateSpecHasher, template, collisionCount)
+	return machineTemplateSpecHasher.Sum64()
+}
+
+// hashMachineTemplateSpec writes the machine template and the collisionCount, if set, to the hasher.
+func hashMachineTemplateSpec(hasher hash.Hash, template *v1alpha1.MachineTemplateSpec, collisionCount *int32) {
+	hashutil.DeepHashObject(hasher, *template)
 
 	// Add collisionCount in the hash if it exists.
 	if collisionCount != nil {
 		collisionCountBytes := make([]byte, 8)
 		binary.LittleEndian.PutUint32(collisionCountBytes, uint32(*collisionCount)) // #nosec G115 (CWE-190) -- collisionCount cannot be negative
-		_, err := machineTemplateSpecHasher.Write(collisionCountBytes)
+		_, err := hasher.Write(collisionCountBytes)
 		if err != nil {
 			klog.Warningf("Unable to write collision count: %v", err)
 		}
 	}
+}
 
-	return machineTemplateSpecHasher.Sum32()
+// ShardForKey returns the shard in [0, totalShards) responsible for the given key, e.g. a machineSet's
+// namespace/name key. The assignment only depends on the key and totalShards, hence it is stable across restarts.
+// A non-positive totalShards is treated as a single shard.
+func ShardForKey(key string, totalShards int) int {
+	if totalShards <= 1 {
+		return 0
+	}
+	hasher := fnv.New32a()
+	// Write on a hash.Hash never returns an error
+	_, _ = hasher.Write([]byte(key))
+	return int(hasher.Sum32() % uint32(totalShards)) // #nosec G115 (CWE-190) -- totalShards is positive
 }
 
 // AddOrUpdateAnnotationOnNode add annotations to the node. If annotation was added into node, it'll issue API calls
@@ -904,7 +2280,11 @@ func AddOrUpdateAnnotationOnNode(ctx context.Context, c clientset.Interface, nod
 		return nil
 	}
 	firstTry := true
-	return clientretry.RetryOnConflict(UpdateAnnotationBackoff, func() error {
+	return clientretry.RetryOnConflict(annotationUpdateBackoff(), func() error {
+		// Stop retrying once the caller is no longer interested in the result.
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		var err error
 		var oldNode *v1.Node
 		// First we try getting node from the API server cache, as it's cheaper. If it fails
@@ -944,9 +2324,13 @@ func UpdateNodeAnnotations(ctx context.Context, c clientset.Interface, nodeName
 	newNodeClone := oldNode.DeepCopy()
 	newNodeClone.Annotations = newNode.Annotations
 
+	if err := waitForNodeAnnotationRateLimiter(ctx); err != nil {
+		return fmt.Errorf("failed to update annotations for node %q while waiting for rate limiter: %v", nodeName, err)
+	}
+
 	_, err := c.CoreV1().Nodes().Update(ctx, newNodeClone, metav1.UpdateOptions{})
 	if err != nil {
-		return fmt.Errorf("failed to create or update annotations for node %q: %v", nodeName, err)
+		return fmt.Errorf("failed to create or update annotations for node %q: %w", nodeName, err)
 	}
 
 	return err
@@ -964,7 +2348,11 @@ func RemoveAnnotationsOffNode(ctx context.Context, c clientset.Interface, nodeNa
 	}
 
 	firstTry := true
-	return clientretry.RetryOnConflict(UpdateAnnotationBackoff, func() error {
+	return clientretry.RetryOnConflict(annotationUpdateBackoff(), func() error {
+		// Stop retrying once the caller is no longer interested in the result.
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		var err error
 		var oldNode *v1.Node
 		// First we try getting node from the API server cache, as it's cheaper. If it fails
@@ -1001,6 +2389,118 @@ func RemoveAnnotationsOffNode(ctx context.Context, c clientset.Interface, nodeNa
 	})
 }
 
+// AddOrUpdateTaintOnNode adds the taints to the node, replacing existing taints with the same key and effect.
+// If the taints of the node are unchanged, it'll not issue the Update call.
+func AddOrUpdateTaintOnNode(ctx context.Context, c clientset.Interface, nodeName string, taints ...v1.Taint) error {
+	if len(taints) == 0 || nodeName == "" {
+		return nil
+	}
+	return updateTaintsOnNode(ctx, c, nodeName, "adding", taintutils.AddOrUpdateTaint, taints)
+}
+
+// RemoveTaintOffNode is for cleaning up taints temporarily added to node, matched by key and effect.
+// It won't fail if a taint doesn't exist, and won't issue the Update call if none of the taints is present.
+func RemoveTaintOffNode(ctx context.Context, c clientset.Interface, nodeName string, taints ...v1.Taint) error {
+	if len(taints) == 0 || nodeName == "" {
+		return nil
+	}
+	return updateTaintsOnNode(ctx, c, nodeName, "removing", taintutils.RemoveTaint, taints)
+}
+
+// updateTaintsOnNode applies the taints one by one to the node using apply and updates the node if anything changed,
+// retrying on conflicts.
+func updateTaintsOnNode(ctx context.Context, c clientset.Interface, nodeName, operation string, apply func(*v1.Node, *v1.Taint) (*v1.Node, bool, error), taints []v1.Taint) error {
+	firstTry := true
+	return clientretry.RetryOnConflict(annotationUpdateBackoff(), func() error {
+		// Stop retrying once the caller is no longer interested in the result.
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var err error
+		var oldNode *v1.Node
+		// First we try getting node from the API server cache, as it's cheaper. If it fails
+		// we get it from etcd to be sure to have fresh data.
+		if firstTry {
+			oldNode, err = c.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{ResourceVersion: "0"})
+			firstTry = false
+		} else {
+			oldNode, err = c.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+		}
+		if errors.IsNotFound(err) {
+			klog.Warningf("Node %s not found while %s taints. Err: %v", nodeName, operation, err)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		newNode := oldNode
+		updated := false
+		for i := range taints {
+			var ok bool
+			newNode, ok, err = apply(newNode, &taints[i])
+			if err != nil {
+				return err
+			}
+			updated = updated || ok
+		}
+		if !updated {
+			return nil
+		}
+
+		newNodeClone := oldNode.DeepCopy()
+		newNodeClone.Spec.Taints = newNode.Spec.Taints
+		if _, err = c.CoreV1().Nodes().Update(ctx, newNodeClone, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed to update taints for node %q: %w", nodeName, err)
+		}
+		return nil
+	})
+}
+
+// ReconcileNodeCordon cordons or uncordons the node of the machine to match shouldBeCordoned, e.g. to uncordon
+// the node of a machine which got reprieved from deletion. If the node is already in the desired state,
+// it'll not issue any API calls.
+func ReconcileNodeCordon(ctx context.Context, c clientset.Interface, machine *v1alpha1.Machine, shouldBeCordoned bool) error {
+	nodeName := machine.Labels[v1alpha1.NodeLabelKey]
+	if nodeName == "" {
+		return nil
+	}
+
+	firstTry := true
+	return clientretry.RetryOnConflict(annotationUpdateBackoff(), func() error {
+		// Stop retrying once the caller is no longer interested in the result.
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var err error
+		var node *v1.Node
+		// First we try getting node from the API server cache, as it's cheaper. If it fails
+		// we get it from etcd to be sure to have fresh data.
+		if firstTry {
+			node, err = c.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{ResourceVersion: "0"})
+			firstTry = false
+		} else {
+			node, err = c.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+		}
+		if errors.IsNotFound(err) {
+			klog.Warningf("Node %s not found while reconciling cordon. Err: %v", nodeName, err)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if node.Spec.Unschedulable == shouldBeCordoned {
+			return nil
+		}
+		newNode := node.DeepCopy()
+		newNode.Spec.Unschedulable = shouldBeCordoned
+		klog.V(3).Infof("Setting unschedulable of node %q of machine %q to %t", nodeName, machine.Name, shouldBeCordoned)
+		_, err = c.CoreV1().Nodes().Update(ctx, newNode, metav1.UpdateOptions{})
+		return err
+	})
+}
+
 // GetAnnotationsFromNode returns all the annotations of the provided node.
 func GetAnnotationsFromNode(ctx context.Context, c clientset.Interface, nodeName string) (map[string]string, error) {
 
@@ -1021,3 +2521,24 @@ func GetAnnotationsFromNode(ctx context.Context, c clientset.Interface, nodeName
 
 	return node.Annotations, nil
 }
+
+// GetLabelsFromNode returns all the labels of the provided node.
+func GetLabelsFromNode(ctx context.Context, c clientset.Interface, nodeName string) (map[string]string, error) {
+
+	// Short circuit if node name is not set for limiting API calls.
+	if nodeName == "" {
+		return nil, nil
+	}
+
+	node, err := c.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		klog.Warningf("Node %s not found while fetching labels. Err: %v", nodeName, err)
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return node.Labels, nil
+}
@@ -6,17 +6,35 @@ package controller
 
 import (
 	"context"
+	"fmt"
 	"sort"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/gardener/machine-controller-manager/pkg/util/nodeops"
 	"github.com/gardener/machine-controller-manager/pkg/util/provider/machineutils"
 
 	machinev1 "github.com/gardener/machine-controller-manager/pkg/apis/machine/v1alpha1"
+	fakemachineclientset "github.com/gardener/machine-controller-manager/pkg/client/clientset/versioned/fake"
+	fakemachineapi "github.com/gardener/machine-controller-manager/pkg/client/clientset/versioned/typed/machine/v1alpha1/fake"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	utilvalidation "k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/wait"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/flowcontrol"
+	testingclock "k8s.io/utils/clock/testing"
+	"k8s.io/utils/ptr"
 )
 
 const testNamespace = "test"
@@ -206,6 +224,24 @@ var _ = Describe("#controllerUtils", func() {
 			sortedMachinesInOrderOfCreationTimeStamp[2].DeepCopy(),
 		}
 
+		creationTimestamp := metav1.Now()
+		newIdenticalMachine := func(name string) *machinev1.Machine {
+			return &machinev1.Machine{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace, CreationTimestamp: creationTimestamp},
+				Status:     machinev1.MachineStatus{CurrentStatus: machinev1.CurrentStatus{Phase: machinev1.MachineRunning}},
+			}
+		}
+		sortedMachinesInOrderOfName := []*machinev1.Machine{
+			newIdenticalMachine("machine-a"),
+			newIdenticalMachine("machine-b"),
+			newIdenticalMachine("machine-c"),
+		}
+		unsortedMachinesInOrderOfName := []*machinev1.Machine{
+			sortedMachinesInOrderOfName[2].DeepCopy(),
+			sortedMachinesInOrderOfName[0].DeepCopy(),
+			sortedMachinesInOrderOfName[1].DeepCopy(),
+		}
+
 		DescribeTable("###sort",
 			func(data *data) {
 				sort.Sort(ActiveMachines(data.inputMachines))
@@ -224,7 +260,97 @@ var _ = Describe("#controllerUtils", func() {
 				inputMachines:  unsortedMachinesInOrderOfCreationTimeStamp,
 				outputMachines: sortedMachinesInOrderOfCreationTimeStamp,
 			}),
+			Entry("sort on name for otherwise identical machines", &data{
+				inputMachines:  unsortedMachinesInOrderOfName,
+				outputMachines: sortedMachinesInOrderOfName,
+			}),
 		)
+
+		Describe("###deletion cost", func() {
+			newAnnotatedMachine := func(name string, annotations map[string]string) *machinev1.Machine {
+				machine := newIdenticalMachine(name)
+				machine.Annotations = annotations
+				return machine
+			}
+			names := func(machines []*machinev1.Machine) []string {
+				var result []string
+				for _, machine := range machines {
+					result = append(result, machine.Name)
+				}
+				return result
+			}
+
+			It("should delete machines with a lower deletion cost first, including negative costs", func() {
+				machines := []*machinev1.Machine{
+					newAnnotatedMachine("machine-positive", map[string]string{machineutils.MachineDeletionCost: "10"}),
+					newAnnotatedMachine("machine-default", nil),
+					newAnnotatedMachine("machine-negative", map[string]string{machineutils.MachineDeletionCost: "-5"}),
+				}
+				sort.Sort(ActiveMachines(machines))
+				Expect(names(machines)).To(Equal([]string{"machine-negative", "machine-default", "machine-positive"}))
+			})
+
+			It("should prefer the deletion cost over the machine priority", func() {
+				machines := []*machinev1.Machine{
+					newAnnotatedMachine("machine-low-priority", map[string]string{machineutils.MachinePriority: "1", machineutils.MachineDeletionCost: "1"}),
+					newAnnotatedMachine("machine-low-cost", map[string]string{machineutils.MachinePriority: "5", machineutils.MachineDeletionCost: "-1"}),
+				}
+				sort.Sort(ActiveMachines(machines))
+				Expect(names(machines)).To(Equal([]string{"machine-low-cost", "machine-low-priority"}))
+			})
+
+			It("should fall back to the machine priority for equal deletion costs", func() {
+				machines := []*machinev1.Machine{
+					newAnnotatedMachine("machine-a", map[string]string{machineutils.MachinePriority: "5"}),
+					newAnnotatedMachine("machine-b", map[string]string{machineutils.MachinePriority: "1", machineutils.MachineDeletionCost: "0"}),
+				}
+				sort.Sort(ActiveMachines(machines))
+				Expect(names(machines)).To(Equal([]string{"machine-b", "machine-a"}))
+			})
+
+			It("should take an invalid deletion cost to be 0", func() {
+				machines := []*machinev1.Machine{
+					newAnnotatedMachine("machine-invalid", map[string]string{machineutils.MachineDeletionCost: "invalid"}),
+					newAnnotatedMachine("machine-positive", map[string]string{machineutils.MachineDeletionCost: "1"}),
+					newAnnotatedMachine("machine-negative", map[string]string{machineutils.MachineDeletionCost: "-1"}),
+				}
+				sort.Sort(ActiveMachines(machines))
+				Expect(names(machines)).To(Equal([]string{"machine-negative", "machine-invalid", "machine-positive"}))
+			})
+		})
+	})
+
+	Describe("##MachinesByReadiness", func() {
+		now := metav1.Now()
+		newPhaseMachine := func(name string, phase machinev1.MachinePhase, age time.Duration, priority string) *machinev1.Machine {
+			return &machinev1.Machine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              name,
+					Namespace:         testNamespace,
+					CreationTimestamp: metav1.NewTime(now.Add(-age)),
+					Annotations:       map[string]string{machineutils.MachinePriority: priority},
+				},
+				Status: machinev1.MachineStatus{CurrentStatus: machinev1.CurrentStatus{Phase: phase}},
+			}
+		}
+
+		It("should sort machines which are not ready to the front, ignoring the priority annotation", func() {
+			machines := []*machinev1.Machine{
+				newPhaseMachine("running", machinev1.MachineRunning, time.Hour, "1"),
+				newPhaseMachine("failed", machinev1.MachineFailed, time.Minute, "5"),
+				newPhaseMachine("available", machinev1.MachineAvailable, 2*time.Hour, "1"),
+				newPhaseMachine("pending", machinev1.MachinePending, time.Minute, "3"),
+				newPhaseMachine("unknown", machinev1.MachineUnknown, time.Hour, "5"),
+				newPhaseMachine("crashloop", machinev1.MachineCrashLoopBackOff, 2*time.Minute, "5"),
+			}
+			sort.Sort(MachinesByReadiness(machines))
+
+			var names []string
+			for _, machine := range machines {
+				names = append(names, machine.Name)
+			}
+			Expect(names).To(Equal([]string{"unknown", "crashloop", "failed", "pending", "available", "running"}))
+		})
 	})
 
 	Describe("##AddOrUpdateAnnotationOnNode", func() {
@@ -609,4 +735,2069 @@ var _ = Describe("#controllerUtils", func() {
 			Expect(FilterActiveMachineSets(testMachineSets)).To(HaveLen(1))
 		})
 	})
-})
+
+	Describe("##FilterActiveMachines", func() {
+		newPhaseMachine := func(name string, phase machinev1.MachinePhase) *machinev1.Machine {
+			return &machinev1.Machine{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace},
+				Status:     machinev1.MachineStatus{CurrentStatus: machinev1.CurrentStatus{Phase: phase}},
+			}
+		}
+
+		It("should only return the running, available and pending machines", func() {
+			running := newPhaseMachine("running", machinev1.MachineRunning)
+			available := newPhaseMachine("available", machinev1.MachineAvailable)
+			pending := newPhaseMachine("pending", machinev1.MachinePending)
+			deleted := newPhaseMachine("deleted", machinev1.MachineRunning)
+			deleted.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+			machines := []*machinev1.Machine{
+				running,
+				newPhaseMachine("terminating", machinev1.MachineTerminating),
+				available,
+				newPhaseMachine("failed", machinev1.MachineFailed),
+				deleted,
+				pending,
+			}
+
+			Expect(FilterActiveMachines(machines)).To(Equal([]*machinev1.Machine{running, available, pending}))
+		})
+
+		It("should return nothing for no machines", func() {
+			Expect(FilterActiveMachines(nil)).To(BeEmpty())
+		})
+	})
+
+	Describe("##StampOwnershipAnnotations", func() {
+		var (
+			stop    chan struct{}
+			machine *machinev1.Machine
+		)
+
+		BeforeEach(func() {
+			stop = make(chan struct{})
+			machine = &machinev1.Machine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "machine-0",
+					Namespace: testNamespace,
+				},
+			}
+		})
+
+		AfterEach(func() {
+			close(stop)
+		})
+
+		It("should stamp both annotations when they are missing", func() {
+			c, trackers := createController(stop, testNamespace, []runtime.Object{machine}, nil, nil)
+			defer trackers.Stop()
+
+			Expect(StampOwnershipAnnotations(context.TODO(), c.machineControl, machine, "machineset-0", "machinedeployment-0")).To(Succeed())
+
+			actual, err := c.controlMachineClient.Machines(testNamespace).Get(context.TODO(), machine.Name, metav1.GetOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actual.Annotations).To(HaveKeyWithValue(MachineSetNameAnnotation, "machineset-0"))
+			Expect(actual.Annotations).To(HaveKeyWithValue(MachineDeploymentNameAnnotation, "machinedeployment-0"))
+		})
+
+		It("should skip empty names", func() {
+			c, trackers := createController(stop, testNamespace, []runtime.Object{machine}, nil, nil)
+			defer trackers.Stop()
+
+			Expect(StampOwnershipAnnotations(context.TODO(), c.machineControl, machine, "machineset-0", "")).To(Succeed())
+
+			actual, err := c.controlMachineClient.Machines(testNamespace).Get(context.TODO(), machine.Name, metav1.GetOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actual.Annotations).To(HaveKeyWithValue(MachineSetNameAnnotation, "machineset-0"))
+			Expect(actual.Annotations).ToNot(HaveKey(MachineDeploymentNameAnnotation))
+		})
+
+		It("should not patch when the annotations are already correct", func() {
+			machine.Annotations = map[string]string{
+				MachineSetNameAnnotation:        "machineset-0",
+				MachineDeploymentNameAnnotation: "machinedeployment-0",
+			}
+			// a missing machine makes any patch fail, so success proves no API call was made
+			c, trackers := createController(stop, testNamespace, nil, nil, nil)
+			defer trackers.Stop()
+
+			Expect(StampOwnershipAnnotations(context.TODO(), c.machineControl, machine, "machineset-0", "machinedeployment-0")).To(Succeed())
+		})
+	})
+
+	Describe("##ReconcileExpectationsFromList", func() {
+		const controllerKey = "test/machineset-0"
+		var expectations *ContExpectations
+
+		BeforeEach(func() {
+			expectations = NewContExpectations(0, nil)
+			Expect(expectations.SetExpectations(controllerKey, 5, 3)).To(Succeed())
+		})
+
+		getExpectations := func() (int64, int64) {
+			exp, exists, err := expectations.GetExpectations(controllerKey)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(exists).To(BeTrue())
+			return exp.GetExpectations()
+		}
+
+		It("should lower expectations by the observed counts", func() {
+			ReconcileExpectationsFromList(expectations, controllerKey, 2, 1)
+			add, del := getExpectations()
+			Expect(add).To(Equal(int64(3)))
+			Expect(del).To(Equal(int64(2)))
+		})
+
+		It("should never raise expectations for negative observations", func() {
+			ReconcileExpectationsFromList(expectations, controllerKey, -2, -1)
+			add, del := getExpectations()
+			Expect(add).To(Equal(int64(5)))
+			Expect(del).To(Equal(int64(3)))
+		})
+
+		It("should not lower expectations below zero", func() {
+			ReconcileExpectationsFromList(expectations, controllerKey, 10, 10)
+			add, del := getExpectations()
+			Expect(add).To(Equal(int64(0)))
+			Expect(del).To(Equal(int64(0)))
+		})
+
+		It("should do nothing when no expectations exist for the controller", func() {
+			ReconcileExpectationsFromList(expectations, "test/unknown", 1, 1)
+			_, exists, err := expectations.GetExpectations("test/unknown")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(exists).To(BeFalse())
+		})
+	})
+
+	Describe("##ValidateMachineTemplateForCreate", func() {
+		var template *machinev1.MachineTemplateSpec
+
+		BeforeEach(func() {
+			template = &machinev1.MachineTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						"test-label": "test-label",
+					},
+					Finalizers: []string{"machine.sapcloud.io/machine-controller-manager"},
+				},
+				Spec: machinev1.MachineSpec{
+					Class: machinev1.ClassSpec{
+						Kind: "MachineClass",
+						Name: "test-machine-class",
+					},
+				},
+			}
+		})
+
+		It("should return no errors for a valid template", func() {
+			Expect(ValidateMachineTemplateForCreate(template)).To(BeEmpty())
+		})
+
+		It("should aggregate errors for missing labels, class reference and invalid finalizers", func() {
+			template.Labels = nil
+			template.Spec.Class = machinev1.ClassSpec{}
+			template.Finalizers = []string{"invalid finalizer"}
+
+			errs := ValidateMachineTemplateForCreate(template)
+			Expect(errs).To(HaveLen(4))
+			Expect(errs[0].Field).To(Equal("metadata.labels"))
+			Expect(errs[1].Field).To(Equal("spec.class.kind"))
+			Expect(errs[2].Field).To(Equal("spec.class.name"))
+			Expect(errs[3].Field).To(Equal("metadata.finalizers"))
+		})
+
+		It("should return an error for malformed labels", func() {
+			template.Labels = map[string]string{"invalid key!": "value"}
+
+			errs := ValidateMachineTemplateForCreate(template)
+			Expect(errs).To(HaveLen(1))
+			Expect(errs[0].Field).To(Equal("metadata.labels"))
+		})
+
+		It("should return an error for an empty class name", func() {
+			template.Spec.Class.Name = ""
+
+			errs := ValidateMachineTemplateForCreate(template)
+			Expect(errs).To(HaveLen(1))
+			Expect(errs[0].Field).To(Equal("spec.class.name"))
+		})
+
+		It("should return an error for an empty class kind", func() {
+			template.Spec.Class.Kind = ""
+
+			errs := ValidateMachineTemplateForCreate(template)
+			Expect(errs).To(HaveLen(1))
+			Expect(errs[0].Field).To(Equal("spec.class.kind"))
+		})
+
+		DescribeTable("should reject the template before creating fake machines",
+			func(mutate func(template *machinev1.MachineTemplateSpec)) {
+				fakeClient := fakemachineclientset.NewSimpleClientset()
+				machineControl := FakeMachineControl{controlMachineClient: fakeClient.MachineV1alpha1().(*fakemachineapi.FakeMachineV1alpha1)}
+				mutate(template)
+
+				machine, err := machineControl.CreateMachinesReturning(context.TODO(), testNamespace, template, nil)
+				Expect(err).To(HaveOccurred())
+				Expect(machine).To(BeNil())
+				Expect(fakeClient.Actions()).To(BeEmpty())
+			},
+			Entry("for an empty class name", func(template *machinev1.MachineTemplateSpec) { template.Spec.Class.Name = "" }),
+			Entry("for missing labels", func(template *machinev1.MachineTemplateSpec) { template.Labels = nil }),
+			Entry("for invalid finalizers", func(template *machinev1.MachineTemplateSpec) { template.Finalizers = []string{"invalid finalizer"} }),
+		)
+	})
+
+	Describe("##DeletionPriorityScore", func() {
+		withDeletionCost := func(machine *machinev1.Machine, cost string) *machinev1.Machine {
+			metav1.SetMetaDataAnnotation(&machine.ObjectMeta, machineutils.MachineDeletionCost, cost)
+			return machine
+		}
+
+		newScoredMachine := func(name string, priority string, phase machinev1.MachinePhase, age time.Duration) *machinev1.Machine {
+			machine := &machinev1.Machine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              name,
+					Namespace:         testNamespace,
+					CreationTimestamp: metav1.NewTime(time.Now().Add(-age).Truncate(time.Second)),
+				},
+				Status: machinev1.MachineStatus{
+					CurrentStatus: machinev1.CurrentStatus{
+						Phase: phase,
+					},
+				},
+			}
+			if priority != "" {
+				machine.Annotations = map[string]string{machineutils.MachinePriority: priority}
+			}
+			return machine
+		}
+
+		DescribeTable("should score the machine to be deleted first lower",
+			func(first, second *machinev1.Machine) {
+				Expect(DeletionPriorityScore(first)).To(BeNumerically("<", DeletionPriorityScore(second)))
+			},
+			Entry("lower priority annotation wins over phase and age",
+				newScoredMachine("m1", "1", machinev1.MachineRunning, time.Minute),
+				newScoredMachine("m2", "", machinev1.MachineFailed, time.Hour)),
+			Entry("invalid priority annotation is treated as the default",
+				newScoredMachine("m1", "", machinev1.MachineFailed, time.Minute),
+				newScoredMachine("m2", "invalid", machinev1.MachineRunning, time.Minute)),
+			Entry("phase wins over age for equal priorities",
+				newScoredMachine("m1", "", machinev1.MachineTerminating, time.Minute),
+				newScoredMachine("m2", "", machinev1.MachinePending, time.Hour)),
+			Entry("older machine wins for equal priorities and phases",
+				newScoredMachine("m1", "", machinev1.MachineRunning, time.Hour),
+				newScoredMachine("m2", "", machinev1.MachineRunning, time.Minute)),
+			Entry("negative priority annotation wins over the lowest supported one",
+				newScoredMachine("m1", "-4", machinev1.MachineRunning, time.Minute),
+				newScoredMachine("m2", "1", machinev1.MachineFailed, time.Hour)),
+			Entry("huge priority annotation loses against the default",
+				newScoredMachine("m1", "", machinev1.MachineRunning, time.Minute),
+				newScoredMachine("m2", "9223372036854775807", machinev1.MachineFailed, time.Hour)),
+			Entry("lower deletion cost wins over priority, phase and age",
+				withDeletionCost(newScoredMachine("m1", "255", machinev1.MachineRunning, time.Minute), "-1"),
+				newScoredMachine("m2", "1", machinev1.MachineFailed, time.Hour)),
+			Entry("huge deletion cost loses against a positive one",
+				withDeletionCost(newScoredMachine("m1", "", machinev1.MachineRunning, time.Minute), "100"),
+				withDeletionCost(newScoredMachine("m2", "", machinev1.MachineFailed, time.Hour), "9223372036854775807")),
+		)
+
+		It("should never return a negative score", func() {
+			Expect(DeletionPriorityScore(newScoredMachine("m1", "-9223372036854775808", machinev1.MachineFailed, time.Hour))).To(BeNumerically(">=", 0))
+			Expect(DeletionPriorityScore(newScoredMachine("m2", "9223372036854775807", machinev1.MachineRunning, 0))).To(BeNumerically(">", 0))
+			Expect(DeletionPriorityScore(withDeletionCost(newScoredMachine("m3", "", machinev1.MachineFailed, time.Hour), "-9223372036854775808"))).To(BeNumerically(">=", 0))
+			Expect(DeletionPriorityScore(withDeletionCost(newScoredMachine("m4", "9223372036854775807", machinev1.MachineRunning, 0), "9223372036854775807"))).To(BeNumerically(">", 0))
+		})
+
+		It("should score the machine first sorted by ActiveMachines lowest", func() {
+			var machines []*machinev1.Machine
+			for i, priority := range []string{"", "2", "-1", "5", "300"} {
+				for j, phase := range []machinev1.MachinePhase{machinev1.MachineRunning, machinev1.MachineFailed, machinev1.MachinePending, machinev1.MachineTerminating} {
+					for k, age := range []time.Duration{time.Minute, time.Hour, 24 * time.Hour} {
+						machines = append(machines, newScoredMachine(fmt.Sprintf("m-%d-%d-%d", i, j, k), priority, phase, age))
+					}
+				}
+			}
+			for i, cost := range []string{"10", "-10"} {
+				machines = append(machines, withDeletionCost(newScoredMachine(fmt.Sprintf("m-cost-%d", i), "5", machinev1.MachineRunning, time.Minute), cost))
+			}
+			lowest := machines[0]
+			for _, machine := range machines[1:] {
+				if DeletionPriorityScore(machine) < DeletionPriorityScore(lowest) {
+					lowest = machine
+				}
+			}
+
+			sort.Sort(ActiveMachines(machines))
+			Expect(lowest.Name).To(Equal(machines[0].Name))
+		})
+
+		It("should be consistent with the ActiveMachines sort", func() {
+			machines := []*machinev1.Machine{
+				newScoredMachine("m1", "", machinev1.MachineRunning, time.Minute),
+				newScoredMachine("m2", "2", machinev1.MachineRunning, time.Hour),
+				newScoredMachine("m3", "", machinev1.MachineFailed, time.Minute),
+				newScoredMachine("m4", "", machinev1.MachineRunning, time.Hour),
+				newScoredMachine("m5", "5", machinev1.MachineTerminating, time.Hour),
+				newScoredMachine("m6", "", machinev1.MachineUnknown, 2*time.Hour),
+			}
+			sort.Sort(ActiveMachines(machines))
+			for i := 1; i < len(machines); i++ {
+				Expect(DeletionPriorityScore(machines[i-1])).To(BeNumerically("<", DeletionPriorityScore(machines[i])))
+			}
+		})
+	})
+
+	Describe("##RecreateMachineName", func() {
+		DescribeTable("should return deterministic DNS-1123 label names",
+			func(deploymentName, templateHash string, ordinal int, expected string) {
+				name := RecreateMachineName(deploymentName, templateHash, ordinal)
+				Expect(name).To(Equal(expected))
+				Expect(utilvalidation.IsDNS1123Label(name)).To(BeEmpty())
+				Expect(RecreateMachineName(deploymentName, templateHash, ordinal)).To(Equal(name))
+			},
+			Entry("simple name", "shoot-worker", "5d8f7b", 0, "shoot-worker-5d8f7b-0"),
+			Entry("invalid characters are sanitized", "Shoot_Worker.z1", "5D8F7B", 3, "shoot-worker-z1-5d8f7b-3"),
+			Entry("empty hash", "shoot-worker", "", 1, "shoot-worker-1"),
+			Entry("empty deployment name", "", "5d8f7b", 2, "machine-5d8f7b-2"),
+			Entry("long deployment name is truncated",
+				strings.Repeat("a", 70), "5d8f7b", 12,
+				strings.Repeat("a", 53)+"-5d8f7b-12"),
+			Entry("truncation does not leave a trailing dash",
+				strings.Repeat("a", 52)+"-bbbb", "5d8f7b", 12,
+				strings.Repeat("a", 52)+"-5d8f7b-12"),
+			Entry("long hash keeps the ordinal",
+				"worker", strings.Repeat("f", 70), 7,
+				"w-"+strings.Repeat("f", 59)+"-7"),
+		)
+	})
+
+	Describe("##NodeAnnotationRateLimiter", func() {
+		AfterEach(func() {
+			SetNodeAnnotationRateLimiter(nil)
+		})
+
+		It("should return no limiter for a non-positive rate", func() {
+			Expect(NewNodeAnnotationRateLimiter(0, 10)).To(BeNil())
+		})
+
+		It("should acquire from the limiter before every node annotation write", func() {
+			stop := make(chan struct{})
+			defer close(stop)
+
+			c, trackers := createController(stop, testNamespace, nil, nil, []runtime.Object{newNode(1, &corev1.NodeSpec{}, nil)})
+			defer trackers.Stop()
+			waitForCacheSync(stop, c)
+
+			limiter := &countingRateLimiter{RateLimiter: flowcontrol.NewFakeAlwaysRateLimiter()}
+			SetNodeAnnotationRateLimiter(limiter)
+
+			Expect(AddOrUpdateAnnotationOnNode(context.TODO(), c.targetCoreClient, "node-0", map[string]string{"anno0": "anno0"})).To(Succeed())
+			Expect(limiter.waits).To(Equal(1))
+			// no write is issued, as the annotation is already present
+			Expect(AddOrUpdateAnnotationOnNode(context.TODO(), c.targetCoreClient, "node-0", map[string]string{"anno0": "anno0"})).To(Succeed())
+			Expect(limiter.waits).To(Equal(1))
+			Expect(RemoveAnnotationsOffNode(context.TODO(), c.targetCoreClient, "node-0", map[string]string{"anno0": "anno0"})).To(Succeed())
+			Expect(limiter.waits).To(Equal(2))
+		})
+
+		It("should not write the node if no token could be acquired", func() {
+			stop := make(chan struct{})
+			defer close(stop)
+
+			c, trackers := createController(stop, testNamespace, nil, nil, []runtime.Object{newNode(1, &corev1.NodeSpec{}, nil)})
+			defer trackers.Stop()
+			waitForCacheSync(stop, c)
+
+			SetNodeAnnotationRateLimiter(NewNodeAnnotationRateLimiter(0.001, 1))
+			Expect(AddOrUpdateAnnotationOnNode(context.TODO(), c.targetCoreClient, "node-0", map[string]string{"anno0": "anno0"})).To(Succeed())
+
+			ctx, cancel := context.WithTimeout(context.TODO(), 50*time.Millisecond)
+			defer cancel()
+			Expect(AddOrUpdateAnnotationOnNode(ctx, c.targetCoreClient, "node-0", map[string]string{"anno1": "anno1"})).NotTo(Succeed())
+
+			node, err := c.targetCoreClient.CoreV1().Nodes().Get(context.TODO(), "node-0", metav1.GetOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(node.Annotations).To(Equal(map[string]string{"anno0": "anno0"}))
+		})
+	})
+
+	Describe("##FindOverlappingMachineSets", func() {
+		newSelectorMachineSet := func(name, namespace string, selector, templateLabels map[string]string) *machinev1.MachineSet {
+			return &machinev1.MachineSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: namespace,
+				},
+				Spec: machinev1.MachineSetSpec{
+					Selector: &metav1.LabelSelector{MatchLabels: selector},
+					Template: machinev1.MachineTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{Labels: templateLabels},
+					},
+				},
+			}
+		}
+
+		It("should return no pairs for disjoint selectors", func() {
+			machineSets := []*machinev1.MachineSet{
+				newSelectorMachineSet("ms-1", testNamespace, map[string]string{"pool": "a"}, map[string]string{"pool": "a"}),
+				newSelectorMachineSet("ms-2", testNamespace, map[string]string{"pool": "b"}, map[string]string{"pool": "b"}),
+			}
+			Expect(FindOverlappingMachineSets(machineSets)).To(BeEmpty())
+		})
+
+		It("should return the pair if a selector matches the other machine set's template labels", func() {
+			ms1 := newSelectorMachineSet("ms-1", testNamespace, map[string]string{"pool": "a"}, map[string]string{"pool": "a"})
+			ms2 := newSelectorMachineSet("ms-2", testNamespace, map[string]string{"pool": "a", "hash": "2"}, map[string]string{"pool": "a", "hash": "2"})
+			ms3 := newSelectorMachineSet("ms-3", testNamespace, map[string]string{"pool": "c"}, map[string]string{"pool": "c"})
+
+			Expect(FindOverlappingMachineSets([]*machinev1.MachineSet{ms1, ms2, ms3})).To(Equal([][2]*machinev1.MachineSet{{ms1, ms2}}))
+		})
+
+		It("should ignore machine sets in different namespaces", func() {
+			machineSets := []*machinev1.MachineSet{
+				newSelectorMachineSet("ms-1", testNamespace, map[string]string{"pool": "a"}, map[string]string{"pool": "a"}),
+				newSelectorMachineSet("ms-2", "other", map[string]string{"pool": "a"}, map[string]string{"pool": "a"}),
+			}
+			Expect(FindOverlappingMachineSets(machineSets)).To(BeEmpty())
+		})
+
+		It("should ignore machine sets with invalid selectors", func() {
+			invalid := newSelectorMachineSet("ms-1", testNamespace, nil, map[string]string{"pool": "a"})
+			invalid.Spec.Selector.MatchExpressions = []metav1.LabelSelectorRequirement{{Key: "pool", Operator: "Invalid"}}
+			machineSets := []*machinev1.MachineSet{
+				invalid,
+				newSelectorMachineSet("ms-2", testNamespace, map[string]string{"pool": "b"}, map[string]string{"pool": "b"}),
+			}
+			Expect(FindOverlappingMachineSets(machineSets)).To(BeEmpty())
+		})
+	})
+
+	Describe("##RateLimitedMachineControl", func() {
+		It("should pace machine creations and deletions", func() {
+			inner := &countingMachineControl{}
+			machineControl := NewRateLimitedMachineControl(inner, 20, 1)
+
+			start := time.Now()
+			Expect(machineControl.CreateMachines(context.TODO(), testNamespace, nil, nil)).To(Succeed())
+			Expect(machineControl.CreateMachinesWithControllerRef(context.TODO(), testNamespace, nil, nil, nil)).To(Succeed())
+			Expect(machineControl.DeleteMachine(context.TODO(), testNamespace, "machine-0", nil)).To(Succeed())
+
+			Expect(time.Since(start)).To(BeNumerically(">=", 90*time.Millisecond))
+			Expect(inner.creates).To(Equal(2))
+			Expect(inner.deletes).To(Equal(1))
+		})
+
+		It("should not limit calls if no rate is configured", func() {
+			inner := &countingMachineControl{}
+			machineControl := NewRateLimitedMachineControl(inner, 0, 0)
+
+			start := time.Now()
+			for i := 0; i < 10; i++ {
+				Expect(machineControl.DeleteMachine(context.TODO(), testNamespace, "machine-0", nil)).To(Succeed())
+			}
+			Expect(time.Since(start)).To(BeNumerically("<", 50*time.Millisecond))
+			Expect(inner.deletes).To(Equal(10))
+		})
+
+		It("should abort the wait if the context is cancelled", func() {
+			inner := &countingMachineControl{}
+			machineControl := NewRateLimitedMachineControl(inner, 0.001, 1)
+			Expect(machineControl.CreateMachines(context.TODO(), testNamespace, nil, nil)).To(Succeed())
+
+			ctx, cancel := context.WithCancel(context.TODO())
+			cancel()
+			Expect(machineControl.CreateMachines(ctx, testNamespace, nil, nil)).NotTo(Succeed())
+			Expect(machineControl.DeleteMachine(ctx, testNamespace, "machine-0", nil)).NotTo(Succeed())
+			Expect(inner.creates).To(Equal(1))
+			Expect(inner.deletes).To(Equal(0))
+		})
+	})
+
+	Describe("##ReconcileMachineLabels", func() {
+		var (
+			stop    chan struct{}
+			machine *machinev1.Machine
+		)
+
+		BeforeEach(func() {
+			stop = make(chan struct{})
+			machine = &machinev1.Machine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "machine-0",
+					Namespace: testNamespace,
+					Labels: map[string]string{
+						"pool":  "old",
+						"stale": "stale",
+						machinev1.DefaultMachineDeploymentUniqueLabelKey: "1234",
+						machinev1.NodeLabelKey:                           "node-0",
+					},
+				},
+			}
+		})
+
+		AfterEach(func() {
+			close(stop)
+		})
+
+		It("should set changed labels, remove stale ones and preserve MCM-managed labels", func() {
+			c, trackers := createController(stop, testNamespace, []runtime.Object{machine}, nil, nil)
+			defer trackers.Stop()
+
+			desired := map[string]string{"pool": "new", "zone": "z1"}
+			Expect(ReconcileMachineLabels(context.TODO(), c.machineControl, machine, desired)).To(Succeed())
+
+			actual, err := c.controlMachineClient.Machines(testNamespace).Get(context.TODO(), machine.Name, metav1.GetOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actual.Labels).To(Equal(map[string]string{
+				"pool": "new",
+				"zone": "z1",
+				machinev1.DefaultMachineDeploymentUniqueLabelKey: "1234",
+				machinev1.NodeLabelKey:                           "node-0",
+			}))
+		})
+
+		It("should not patch when the labels are already in sync", func() {
+			// a missing machine makes any patch fail, so success proves no API call was made
+			c, trackers := createController(stop, testNamespace, nil, nil, nil)
+			defer trackers.Stop()
+
+			desired := map[string]string{"pool": "old", "stale": "stale"}
+			Expect(ReconcileMachineLabels(context.TODO(), c.machineControl, machine, desired)).To(Succeed())
+		})
+	})
+
+	Describe("##CleanupExpectationsForMissingControllers", func() {
+		It("should delete only the expectations of missing controllers", func() {
+			expectations := NewContExpectations(0, nil)
+			Expect(expectations.SetExpectations("test/machineset-0", 1, 0)).To(Succeed())
+			Expect(expectations.SetExpectations("test/machineset-1", 0, 1)).To(Succeed())
+			Expect(expectations.SetExpectations("test/machineset-2", 1, 1)).To(Succeed())
+
+			CleanupExpectationsForMissingControllers(expectations, sets.NewString("test/machineset-1", "test/machineset-3"))
+
+			Expect(expectations.ListKeys()).To(ConsistOf("test/machineset-1"))
+		})
+
+		It("should delete all expectations if no controller exists", func() {
+			expectations := NewContExpectations(0, nil)
+			Expect(expectations.SetExpectations("test/machineset-0", 1, 0)).To(Succeed())
+
+			CleanupExpectationsForMissingControllers(expectations, sets.NewString())
+
+			Expect(expectations.ListKeys()).To(BeEmpty())
+		})
+	})
+
+	Describe("##TimeUntilExpiry", func() {
+		now := time.Now()
+		exp := &ControlleeExpectations{key: "test/machineset-0", timestamp: now.Add(-2 * time.Minute)}
+
+		DescribeTable("should return the remaining time until expiry",
+			func(timeout, expected time.Duration) {
+				Expect(exp.TimeUntilExpiry(timeout, now)).To(Equal(expected))
+			},
+			Entry("before expiry", 5*time.Minute, 3*time.Minute),
+			Entry("exactly at expiry", 2*time.Minute, time.Duration(0)),
+			Entry("clamped at zero after expiry", time.Minute, time.Duration(0)),
+		)
+	})
+
+	Describe("##RoutingMachineControl", func() {
+		var (
+			oldBackend, newBackend *countingMachineControl
+			canaryMachineSet       *machinev1.MachineSet
+			otherMachineSet        *machinev1.MachineSet
+		)
+
+		BeforeEach(func() {
+			oldBackend = &countingMachineControl{}
+			newBackend = &countingMachineControl{}
+			canaryMachineSet = &machinev1.MachineSet{ObjectMeta: metav1.ObjectMeta{Name: "canary", Namespace: testNamespace}}
+			otherMachineSet = &machinev1.MachineSet{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: testNamespace}}
+		})
+
+		It("should return an error without backends", func() {
+			_, err := NewRoutingMachineControl(nil)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should route calls by object", func() {
+			router := func(_ string, object runtime.Object) MachineControlInterface {
+				if ms, ok := object.(*machinev1.MachineSet); ok && ms.Name == "canary" {
+					return newBackend
+				}
+				return oldBackend
+			}
+			machineControl, err := NewRoutingMachineControl(router, oldBackend, newBackend)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(machineControl.CreateMachines(context.TODO(), testNamespace, nil, canaryMachineSet)).To(Succeed())
+			Expect(machineControl.CreateMachinesWithControllerRef(context.TODO(), testNamespace, nil, otherMachineSet, nil)).To(Succeed())
+			Expect(machineControl.DeleteMachine(context.TODO(), testNamespace, "machine-0", canaryMachineSet)).To(Succeed())
+			Expect(machineControl.PatchMachine(context.TODO(), testNamespace, "machine-0", nil)).To(Succeed())
+
+			Expect(newBackend.creates).To(Equal(1))
+			Expect(newBackend.deletes).To(Equal(1))
+			Expect(oldBackend.creates).To(Equal(1))
+			Expect(oldBackend.patches).To(Equal(1))
+		})
+
+		It("should default to the first backend without router", func() {
+			machineControl, err := NewRoutingMachineControl(nil, oldBackend, newBackend)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(machineControl.CreateMachines(context.TODO(), testNamespace, nil, canaryMachineSet)).To(Succeed())
+			Expect(machineControl.DeleteMachine(context.TODO(), testNamespace, "machine-0", otherMachineSet)).To(Succeed())
+
+			Expect(oldBackend.creates).To(Equal(1))
+			Expect(oldBackend.deletes).To(Equal(1))
+			Expect(newBackend.creates).To(Equal(0))
+		})
+
+		It("should default to the first backend if the router selects none", func() {
+			machineControl, err := NewRoutingMachineControl(func(string, runtime.Object) MachineControlInterface { return nil }, oldBackend, newBackend)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(machineControl.CreateMachines(context.TODO(), testNamespace, nil, canaryMachineSet)).To(Succeed())
+			Expect(oldBackend.creates).To(Equal(1))
+		})
+	})
+
+	Describe("##ExpectationsFromDiff", func() {
+		DescribeTable("should derive the expectations from the replica diff",
+			func(desiredReplicas int32, activeMachines int, expectedAdds, expectedDels int) {
+				diff := ReplicaDiff(desiredReplicas, make([]*machinev1.Machine, activeMachines))
+				adds, dels := ExpectationsFromDiff(diff)
+				Expect(adds).To(Equal(expectedAdds))
+				Expect(dels).To(Equal(expectedDels))
+			},
+			Entry("too few machines", int32(5), 2, 3, 0),
+			Entry("too many machines", int32(2), 5, 0, 3),
+			Entry("desired machines", int32(3), 3, 0, 0),
+			Entry("scale to zero", int32(0), 4, 0, 4),
+		)
+	})
+
+	Describe("##IsCorrupt", func() {
+		DescribeTable("should detect counters below the threshold",
+			func(add, del int64, expected bool) {
+				exp := &ControlleeExpectations{add: add, del: del, key: "test/machineset-0"}
+				Expect(exp.IsCorrupt()).To(Equal(expected))
+			},
+			Entry("zero counters", int64(0), int64(0), false),
+			Entry("pending counters", int64(3), int64(2), false),
+			Entry("add at the threshold", int64(CorruptExpectationsThreshold), int64(0), false),
+			Entry("add below the threshold", int64(CorruptExpectationsThreshold-1), int64(0), true),
+			Entry("del at the threshold", int64(0), int64(CorruptExpectationsThreshold), false),
+			Entry("del below the threshold", int64(0), int64(CorruptExpectationsThreshold-1), true),
+		)
+
+		It("should re-zero the counters on Reset", func() {
+			exp := &ControlleeExpectations{add: CorruptExpectationsThreshold - 5, del: 7, key: "test/machineset-0"}
+			exp.Reset()
+			add, del := exp.GetExpectations()
+			Expect(add).To(BeZero())
+			Expect(del).To(BeZero())
+			Expect(exp.IsCorrupt()).To(BeFalse())
+		})
+
+		It("should reset corrupt expectations while checking them", func() {
+			expectations := NewContExpectations(0, nil)
+			Expect(expectations.SetExpectations("test/machineset-0", 0, 0)).To(Succeed())
+			expectations.LowerExpectations("test/machineset-0", BurstReplicas+1, 0)
+
+			Expect(expectations.SatisfiedExpectations("test/machineset-0")).To(BeTrue())
+			exp, exists, err := expectations.GetExpectations("test/machineset-0")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(exists).To(BeTrue())
+			Expect(exp.IsCorrupt()).To(BeFalse())
+			add, del := exp.GetExpectations()
+			Expect(add).To(BeZero())
+			Expect(del).To(BeZero())
+		})
+	})
+
+	Describe("##RecorderFor", func() {
+		var (
+			recorder       *capturingRecorder
+			machineControl RealMachineControl
+		)
+
+		BeforeEach(func() {
+			recorder = &capturingRecorder{}
+			machineControl = RealMachineControl{Recorder: recorder, Namespace: testNamespace}
+		})
+
+		It("should record events about namespaced objects unchanged", func() {
+			machine := &machinev1.Machine{ObjectMeta: metav1.ObjectMeta{Name: "machine-0", Namespace: "other"}}
+			machineControl.RecorderFor(machine).Eventf(machine, corev1.EventTypeNormal, SuccessfulDeleteMachineReason, "Deleted machine: %v", "machine-0")
+			Expect(recorder.objects).To(ConsistOf(machine))
+		})
+
+		It("should scope events about cluster-scoped objects to the configured namespace", func() {
+			machine := &machinev1.Machine{ObjectMeta: metav1.ObjectMeta{Name: "machine-0"}}
+			machineControl.RecorderFor(machine).Eventf(machine, corev1.EventTypeNormal, SuccessfulDeleteMachineReason, "Deleted machine: %v", "machine-0")
+			Expect(recorder.objects).To(HaveLen(1))
+			involvedObject, ok := recorder.objects[0].(*corev1.ObjectReference)
+			Expect(ok).To(BeTrue())
+			Expect(involvedObject.Namespace).To(Equal(testNamespace))
+			Expect(involvedObject.Kind).To(Equal("Machine"))
+			Expect(involvedObject.Name).To(Equal("machine-0"))
+		})
+
+		It("should record events unchanged without a configured namespace", func() {
+			machineControl.Namespace = ""
+			machine := &machinev1.Machine{ObjectMeta: metav1.ObjectMeta{Name: "machine-0"}}
+			machineControl.RecorderFor(machine).Event(machine, corev1.EventTypeNormal, SuccessfulDeleteMachineReason, "Deleted machine")
+			Expect(recorder.objects).To(ConsistOf(machine))
+		})
+
+		It("should scope events of the fake machine control about cluster-scoped objects", func() {
+			fakeClient := fakemachineclientset.NewSimpleClientset()
+			fakeClient.PrependReactor("delete", "machines", func(k8stesting.Action) (bool, runtime.Object, error) {
+				return true, nil, fmt.Errorf("delete failed")
+			})
+			fakeMachineControl := FakeMachineControl{
+				controlMachineClient: fakeClient.MachineV1alpha1().(*fakemachineapi.FakeMachineV1alpha1),
+				Recorder:             recorder,
+				Namespace:            testNamespace,
+			}
+			machineSet := &machinev1.MachineSet{ObjectMeta: metav1.ObjectMeta{Name: "machineset-0"}}
+
+			Expect(fakeMachineControl.DeleteMachine(context.TODO(), testNamespace, "machine-0", machineSet)).To(HaveOccurred())
+			Expect(recorder.objects).To(HaveLen(1))
+			involvedObject, ok := recorder.objects[0].(*corev1.ObjectReference)
+			Expect(ok).To(BeTrue())
+			Expect(involvedObject.Namespace).To(Equal(testNamespace))
+			Expect(involvedObject.Name).To(Equal("machineset-0"))
+		})
+	})
+
+	Describe("##DefaultMachinePriorityForCreate", func() {
+		newMachineSetWithPriority := func(priority string) *machinev1.MachineSet {
+			ms := &machinev1.MachineSet{ObjectMeta: metav1.ObjectMeta{Name: "machineset-0", Namespace: testNamespace}}
+			if priority != "" {
+				ms.Annotations = map[string]string{machineutils.MachineSetMachinePriority: priority}
+			}
+			return ms
+		}
+
+		DescribeTable("should return the priority for machines created by the machineSet",
+			func(priority string, expected int) {
+				Expect(DefaultMachinePriorityForCreate(newMachineSetWithPriority(priority))).To(Equal(expected))
+			},
+			Entry("default without annotation", "", 3),
+			Entry("annotated low priority", "2", 2),
+			Entry("annotated high priority", "5", 5),
+			Entry("default for a non-integer value", "high", 3),
+			Entry("default for priority 1, which triggers deletion", "1", 3),
+			Entry("default for a value above the range", "6", 3),
+		)
+
+		It("should stamp the priority on machines created from the machineSet", func() {
+			template := &machinev1.MachineTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"key": "value"}}}
+			machine, err := GetMachineFromTemplate(template, newMachineSetWithPriority("5"), nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(machine.Annotations).To(HaveKeyWithValue(machineutils.MachinePriority, "5"))
+			Expect(getMachinePriority(machine)).To(Equal(5))
+		})
+
+		It("should prefer the priority of the machine template", func() {
+			template := &machinev1.MachineTemplateSpec{ObjectMeta: metav1.ObjectMeta{
+				Labels:      map[string]string{"key": "value"},
+				Annotations: map[string]string{machineutils.MachinePriority: "4"},
+			}}
+			machine, err := GetMachineFromTemplate(template, newMachineSetWithPriority("2"), nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(machine.Annotations).To(HaveKeyWithValue(machineutils.MachinePriority, "4"))
+		})
+
+		It("should not stamp a priority without the machineSet annotation", func() {
+			template := &machinev1.MachineTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"key": "value"}}}
+			machine, err := GetMachineFromTemplate(template, newMachineSetWithPriority(""), nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(machine.Annotations).ToNot(HaveKey(machineutils.MachinePriority))
+		})
+	})
+
+	Describe("##ShouldRetryCreate", func() {
+		machines := machinev1.Resource("machines")
+
+		DescribeTable("should classify API errors",
+			func(err error, expectedClass APIErrorClass, expectedRetry bool) {
+				Expect(ClassifyAPIError(err)).To(Equal(expectedClass))
+				Expect(ShouldRetryCreate(err)).To(Equal(expectedRetry))
+			},
+			Entry("no error", nil, APIErrorClassNone, false),
+			Entry("conflict", apierrors.NewConflict(machines, "machine-0", fmt.Errorf("conflict")), APIErrorClassTransient, true),
+			Entry("server timeout", apierrors.NewServerTimeout(machines, "create", 1), APIErrorClassTransient, true),
+			Entry("timeout", apierrors.NewTimeoutError("timeout", 1), APIErrorClassTransient, true),
+			Entry("too many requests", apierrors.NewTooManyRequests("throttled", 1), APIErrorClassTransient, true),
+			Entry("exceeded quota", apierrors.NewForbidden(machines, "machine-0", fmt.Errorf("exceeded quota: compute")), APIErrorClassQuota, true),
+			Entry("forbidden", apierrors.NewForbidden(machines, "machine-0", fmt.Errorf("not allowed")), APIErrorClassPermanent, false),
+			Entry("invalid", apierrors.NewInvalid(machinev1.SchemeGroupVersion.WithKind("Machine").GroupKind(), "machine-0", nil), APIErrorClassPermanent, false),
+			Entry("bad request", apierrors.NewBadRequest("bad"), APIErrorClassPermanent, false),
+			Entry("unknown error", fmt.Errorf("connection refused"), APIErrorClassUnknown, true),
+		)
+	})
+
+	Describe("##RetryAfterFromError", func() {
+		DescribeTable("should extract the requested delay",
+			func(err error, expectedDelay time.Duration, expectedOK bool) {
+				delay, ok := RetryAfterFromError(err)
+				Expect(ok).To(Equal(expectedOK))
+				Expect(delay).To(Equal(expectedDelay))
+			},
+			Entry("no error", nil, time.Duration(0), false),
+			Entry("too many requests with Retry-After", apierrors.NewTooManyRequests("throttled", 5), 5*time.Second, true),
+			Entry("too many requests without Retry-After", apierrors.NewTooManyRequests("throttled", 0), time.Duration(0), false),
+			Entry("server timeout with Retry-After", apierrors.NewServerTimeout(machinev1.Resource("machines"), "create", 2), 2*time.Second, true),
+			Entry("conflict", apierrors.NewConflict(machinev1.Resource("machines"), "machine-0", fmt.Errorf("conflict")), time.Duration(0), false),
+			Entry("non API error", fmt.Errorf("connection refused"), time.Duration(0), false),
+		)
+	})
+
+	Describe("##ValidateFinalizers", func() {
+		DescribeTable("should validate the finalizers",
+			func(finalizers []string, expectedInvalid []string) {
+				err := ValidateFinalizers(finalizers)
+				if len(expectedInvalid) == 0 {
+					Expect(err).ToNot(HaveOccurred())
+					return
+				}
+				Expect(err).To(HaveOccurred())
+				for _, finalizer := range expectedInvalid {
+					Expect(err.Error()).To(ContainSubstring(strconv.Quote(finalizer)))
+				}
+			},
+			Entry("no finalizers", nil, nil),
+			Entry("qualified finalizers", []string{"machine.sapcloud.io/machine-controller-manager", "example.com/finalizer"}, nil),
+			Entry("unqualified finalizer", []string{"machine.sapcloud.io/machine-controller-manager", "finalizer"}, []string{"finalizer"}),
+			Entry("malformed finalizers", []string{"example.com/invalid finalizer", "example.com/"}, []string{"example.com/invalid finalizer", "example.com/"}),
+		)
+	})
+
+	Describe("##AvailableExpectationSlack", func() {
+		var expectations *ContExpectations
+
+		BeforeEach(func() {
+			expectations = NewContExpectations(0, nil)
+		})
+
+		It("should return maxInFlight without expectations", func() {
+			Expect(AvailableExpectationSlack(expectations, "test/machineset-0", 10)).To(Equal(10))
+		})
+
+		DescribeTable("should return the remaining slack",
+			func(add, del int, expected int) {
+				Expect(expectations.SetExpectations("test/machineset-0", add, del)).To(Succeed())
+				Expect(AvailableExpectationSlack(expectations, "test/machineset-0", 10)).To(Equal(expected))
+			},
+			Entry("fulfilled expectations", 0, 0, 10),
+			Entry("outstanding creations", 4, 0, 6),
+			Entry("outstanding creations and deletions", 3, 2, 5),
+			Entry("exactly maxInFlight outstanding", 10, 0, 0),
+			Entry("more than maxInFlight outstanding", 12, 3, 0),
+			Entry("over-observed deletions", 3, -2, 7),
+		)
+	})
+
+	Describe("##ScaleUpWithPendingCap", func() {
+		DescribeTable("should cap the machines to create by the pending machines",
+			func(current, desired, pending, maxPending, expected int32) {
+				Expect(ScaleUpWithPendingCap(current, desired, pending, maxPending)).To(Equal(expected))
+			},
+			Entry("no pending machines", int32(2), int32(5), int32(0), int32(10), int32(3)),
+			Entry("room for some of the machines", int32(2), int32(10), int32(3), int32(5), int32(2)),
+			Entry("exactly maxPending after the scale-up", int32(0), int32(4), int32(1), int32(5), int32(4)),
+			Entry("pending at maxPending", int32(2), int32(5), int32(5), int32(5), int32(0)),
+			Entry("pending beyond maxPending", int32(2), int32(5), int32(7), int32(5), int32(0)),
+			Entry("nothing to scale up", int32(5), int32(5), int32(0), int32(10), int32(0)),
+			Entry("scale-down", int32(5), int32(3), int32(0), int32(10), int32(0)),
+		)
+	})
+
+	Describe("##ReconcileNodeCordon", func() {
+		machine := &machinev1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "machine-0",
+				Namespace: testNamespace,
+				Labels:    map[string]string{machinev1.NodeLabelKey: "node-0"},
+			},
+		}
+
+		DescribeTable("should set the node's unschedulable state",
+			func(unschedulable, shouldBeCordoned bool) {
+				stop := make(chan struct{})
+				defer close(stop)
+
+				c, trackers := createController(stop, testNamespace, nil, nil, []runtime.Object{newNode(1, &corev1.NodeSpec{Unschedulable: unschedulable}, nil)})
+				defer trackers.Stop()
+				waitForCacheSync(stop, c)
+
+				Expect(ReconcileNodeCordon(context.TODO(), c.targetCoreClient, machine, shouldBeCordoned)).To(Succeed())
+				node, err := c.targetCoreClient.CoreV1().Nodes().Get(context.TODO(), "node-0", metav1.GetOptions{})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(node.Spec.Unschedulable).To(Equal(shouldBeCordoned))
+			},
+			Entry("cordon a schedulable node", false, true),
+			Entry("uncordon a reprieved node", true, false),
+			Entry("keep a cordoned node", true, true),
+			Entry("keep a schedulable node", false, false),
+		)
+
+		It("should ignore machines without a node", func() {
+			stop := make(chan struct{})
+			defer close(stop)
+
+			c, trackers := createController(stop, testNamespace, nil, nil, nil)
+			defer trackers.Stop()
+
+			Expect(ReconcileNodeCordon(context.TODO(), c.targetCoreClient, &machinev1.Machine{}, true)).To(Succeed())
+			Expect(ReconcileNodeCordon(context.TODO(), c.targetCoreClient, machine, true)).To(Succeed())
+		})
+
+		It("should stop retrying once the context is cancelled", func() {
+			targetClient := k8sfake.NewSimpleClientset(newNode(1, &corev1.NodeSpec{}, nil))
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+			gets := 0
+			targetClient.PrependReactor("get", "nodes", func(_ k8stesting.Action) (bool, runtime.Object, error) {
+				gets++
+				return false, nil, nil
+			})
+			// Cancel the context on the first conflict
+			targetClient.PrependReactor("update", "nodes", func(_ k8stesting.Action) (bool, runtime.Object, error) {
+				cancel()
+				return true, nil, apierrors.NewConflict(corev1.Resource("nodes"), "node-0", fmt.Errorf("conflict"))
+			})
+
+			Expect(ReconcileNodeCordon(ctx, targetClient, machine, true)).To(MatchError(context.Canceled))
+			Expect(gets).To(Equal(1))
+		})
+	})
+
+	Describe("##ByMachineSetConcentration", func() {
+		now := time.Now()
+		newMachineOfSet := func(name, machineSet string, age time.Duration) *machinev1.Machine {
+			return &machinev1.Machine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              name,
+					Namespace:         testNamespace,
+					Labels:            map[string]string{"machineset": machineSet},
+					CreationTimestamp: metav1.NewTime(now.Add(-age)),
+				},
+				Status: machinev1.MachineStatus{CurrentStatus: machinev1.CurrentStatus{Phase: machinev1.MachineRunning}},
+			}
+		}
+		sortByConcentration := ByMachineSetConcentration(func(machine *machinev1.Machine) string {
+			return machine.Labels["machineset"]
+		})
+		names := func(machines []*machinev1.Machine) []string {
+			var machineNames []string
+			for _, machine := range machines {
+				machineNames = append(machineNames, machine.Name)
+			}
+			return machineNames
+		}
+
+		It("should empty the smaller machine set first", func() {
+			machines := []*machinev1.Machine{
+				newMachineOfSet("large-0", "large", 3*time.Hour),
+				newMachineOfSet("small-0", "small", time.Hour),
+				newMachineOfSet("large-1", "large", 2*time.Hour),
+				newMachineOfSet("small-1", "small", 2*time.Hour),
+				newMachineOfSet("large-2", "large", time.Hour),
+			}
+			sortByConcentration(machines)
+			Expect(names(machines)).To(Equal([]string{"small-1", "small-0", "large-0", "large-1", "large-2"}))
+		})
+
+		It("should still prefer machines with a lower priority", func() {
+			prioritized := newMachineOfSet("large-0", "large", time.Hour)
+			prioritized.Annotations = map[string]string{machineutils.MachinePriority: "1"}
+			machines := []*machinev1.Machine{
+				newMachineOfSet("small-0", "small", time.Hour),
+				prioritized,
+				newMachineOfSet("large-1", "large", time.Hour),
+			}
+			sortByConcentration(machines)
+			Expect(names(machines)).To(Equal([]string{"large-0", "small-0", "large-1"}))
+		})
+	})
+
+	Describe("##ByNodeCordonState", func() {
+		now := time.Now()
+		cordonedNodes := sets.NewString("node-cordoned-0", "node-cordoned-1")
+		newMachineOnNode := func(name, nodeName string, phase machinev1.MachinePhase, age time.Duration) *machinev1.Machine {
+			return &machinev1.Machine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              name,
+					Namespace:         testNamespace,
+					Labels:            map[string]string{machinev1.NodeLabelKey: nodeName},
+					CreationTimestamp: metav1.NewTime(now.Add(-age)),
+				},
+				Status: machinev1.MachineStatus{CurrentStatus: machinev1.CurrentStatus{Phase: phase}},
+			}
+		}
+		sortByCordonState := ByNodeCordonState(func(machine *machinev1.Machine) bool {
+			return cordonedNodes.Has(machine.Labels[machinev1.NodeLabelKey])
+		})
+		names := func(machines []*machinev1.Machine) []string {
+			var machineNames []string
+			for _, machine := range machines {
+				machineNames = append(machineNames, machine.Name)
+			}
+			return machineNames
+		}
+
+		It("should select machines on cordoned nodes first", func() {
+			machines := []*machinev1.Machine{
+				newMachineOnNode("schedulable-0", "node-0", machinev1.MachineRunning, 3*time.Hour),
+				newMachineOnNode("cordoned-0", "node-cordoned-0", machinev1.MachineRunning, time.Hour),
+				newMachineOnNode("schedulable-1", "node-1", machinev1.MachinePending, time.Hour),
+				newMachineOnNode("cordoned-1", "node-cordoned-1", machinev1.MachineRunning, 2*time.Hour),
+			}
+			sortByCordonState(machines)
+			Expect(names(machines)).To(Equal([]string{"cordoned-1", "cordoned-0", "schedulable-1", "schedulable-0"}))
+		})
+
+		It("should still prefer machines with a lower priority", func() {
+			prioritized := newMachineOnNode("schedulable-0", "node-0", machinev1.MachineRunning, time.Hour)
+			prioritized.Annotations = map[string]string{machineutils.MachinePriority: "1"}
+			machines := []*machinev1.Machine{
+				newMachineOnNode("cordoned-0", "node-cordoned-0", machinev1.MachineRunning, time.Hour),
+				newMachineOnNode("schedulable-1", "node-1", machinev1.MachineRunning, time.Hour),
+				prioritized,
+			}
+			sortByCordonState(machines)
+			Expect(names(machines)).To(Equal([]string{"schedulable-0", "cordoned-0", "schedulable-1"}))
+		})
+	})
+
+	Describe("##SelectMachinesOnNodes", func() {
+		now := time.Now()
+		newMachineOnNode := func(name, nodeName string, phase machinev1.MachinePhase, age time.Duration) *machinev1.Machine {
+			machine := &machinev1.Machine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              name,
+					Namespace:         testNamespace,
+					CreationTimestamp: metav1.NewTime(now.Add(-age)),
+				},
+				Status: machinev1.MachineStatus{CurrentStatus: machinev1.CurrentStatus{Phase: phase}},
+			}
+			if nodeName != "" {
+				machine.Labels = map[string]string{machinev1.NodeLabelKey: nodeName}
+			}
+			return machine
+		}
+
+		It("should return the machines on the given nodes in deletion order", func() {
+			machines := []*machinev1.Machine{
+				newMachineOnNode("machine-0", "node-0", machinev1.MachineRunning, 2*time.Hour),
+				newMachineOnNode("machine-1", "node-1", machinev1.MachineRunning, time.Hour),
+				newMachineOnNode("machine-2", "node-2", machinev1.MachineUnknown, time.Hour),
+				newMachineOnNode("machine-3", "", machinev1.MachinePending, time.Hour),
+			}
+
+			selected := SelectMachinesOnNodes(machines, sets.NewString("node-0", "node-2"))
+			Expect(selected).To(Equal([]*machinev1.Machine{machines[2], machines[0]}))
+			Expect(machines[0].Name).To(Equal("machine-0"))
+		})
+
+		It("should return no machines for no nodes", func() {
+			machines := []*machinev1.Machine{newMachineOnNode("machine-0", "node-0", machinev1.MachineRunning, time.Hour)}
+
+			Expect(SelectMachinesOnNodes(machines, sets.NewString())).To(BeEmpty())
+		})
+
+		It("should not return deletion protected machines", func() {
+			machines := []*machinev1.Machine{
+				newMachineOnNode("machine-0", "node-0", machinev1.MachineRunning, time.Hour),
+				newMachineOnNode("machine-1", "node-0", machinev1.MachineRunning, time.Hour),
+			}
+			machines[0].Annotations = map[string]string{machineutils.MachineNoDelete: "true"}
+
+			Expect(SelectMachinesOnNodes(machines, sets.NewString("node-0"))).To(Equal([]*machinev1.Machine{machines[1]}))
+		})
+	})
+
+	Describe("##TotalOutstanding", func() {
+		It("should return zero without expectations", func() {
+			adds, dels := NewContExpectations(0, nil).TotalOutstanding()
+			Expect(adds).To(BeZero())
+			Expect(dels).To(BeZero())
+		})
+
+		It("should sum the outstanding expectations of all controllers", func() {
+			expectations := NewContExpectations(0, nil)
+			Expect(expectations.SetExpectations("test/machineset-0", 3, 1)).To(Succeed())
+			Expect(expectations.SetExpectations("test/machineset-1", 2, 4)).To(Succeed())
+			Expect(expectations.SetExpectations("test/machineset-2", 0, 0)).To(Succeed())
+			expectations.LowerExpectations("test/machineset-2", 2, 0)
+
+			adds, dels := expectations.TotalOutstanding()
+			Expect(adds).To(Equal(int64(5)))
+			Expect(dels).To(Equal(int64(5)))
+
+			// the entries are left untouched
+			exp, exists, err := expectations.GetExpectations("test/machineset-2")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(exists).To(BeTrue())
+			add, del := exp.GetExpectations()
+			Expect(add).To(Equal(int64(-2)))
+			Expect(del).To(BeZero())
+		})
+	})
+
+	Describe("##ComputePriorityFromUtilization", func() {
+		thresholds := []float64{25, 50, 75}
+
+		DescribeTable("should map the utilization to a priority bucket",
+			func(utilization float64, thresholds []float64, expected int) {
+				Expect(ComputePriorityFromUtilization(utilization, thresholds)).To(Equal(expected))
+			},
+			Entry("idle node", 0.0, thresholds, 2),
+			Entry("below the first threshold", 24.9, thresholds, 2),
+			Entry("at the first threshold", 25.0, thresholds, 3),
+			Entry("between thresholds", 60.0, thresholds, 4),
+			Entry("above the last threshold", 99.0, thresholds, 5),
+			Entry("no thresholds", 50.0, []float64{}, 2),
+			Entry("unsorted thresholds", 10.0, []float64{50, 25}, 3),
+			Entry("too many thresholds", 99.0, []float64{20, 40, 60, 80}, 3),
+		)
+
+		It("should validate that the thresholds are sorted", func() {
+			Expect(ValidateUtilizationThresholds(thresholds)).To(Succeed())
+			Expect(ValidateUtilizationThresholds([]float64{50, 25})).To(HaveOccurred())
+		})
+
+		It("should reject more thresholds than there are priorities", func() {
+			Expect(ValidateUtilizationThresholds([]float64{20, 40, 60, 80})).To(MatchError(ContainSubstring("exceed the maximum of 3")))
+		})
+	})
+
+	Describe("##ReconcileMachinePriority", func() {
+		var (
+			stop    chan struct{}
+			machine *machinev1.Machine
+		)
+
+		BeforeEach(func() {
+			stop = make(chan struct{})
+			machine = &machinev1.Machine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "machine-0",
+					Namespace:   testNamespace,
+					Annotations: map[string]string{machineutils.MachinePriority: "3"},
+				},
+			}
+		})
+
+		AfterEach(func() {
+			close(stop)
+		})
+
+		It("should patch the priority annotation", func() {
+			c, trackers := createController(stop, testNamespace, []runtime.Object{machine}, nil, nil)
+			defer trackers.Stop()
+
+			Expect(ReconcileMachinePriority(context.TODO(), c.machineControl, machine, 2)).To(Succeed())
+
+			actual, err := c.controlMachineClient.Machines(testNamespace).Get(context.TODO(), machine.Name, metav1.GetOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actual.Annotations).To(HaveKeyWithValue(machineutils.MachinePriority, "2"))
+		})
+
+		It("should not patch when the priority is already in sync", func() {
+			// a missing machine makes any patch fail, so success proves no API call was made
+			c, trackers := createController(stop, testNamespace, nil, nil, nil)
+			defer trackers.Stop()
+
+			Expect(ReconcileMachinePriority(context.TODO(), c.machineControl, machine, 3)).To(Succeed())
+		})
+	})
+
+	Describe("##RequeueDelayForExpectations", func() {
+		now := time.Now()
+		exp := &ControlleeExpectations{key: "test/machineset-0", timestamp: now.Add(-2 * time.Minute)}
+
+		DescribeTable("should requeue no later than the expiry",
+			func(timeout, defaultDelay, expected time.Duration) {
+				Expect(RequeueDelayForExpectations(exp, timeout, now, defaultDelay)).To(Equal(expected))
+			},
+			Entry("default delay before expiry", 5*time.Minute, 30*time.Second, 30*time.Second),
+			Entry("time until expiry before the default delay", 3*time.Minute, 5*time.Minute, time.Minute),
+			Entry("minimum delay for expired expectations", time.Minute, 30*time.Second, MinExpectationsRequeueDelay),
+			Entry("minimum delay for a tiny default delay", 5*time.Minute, time.Millisecond, MinExpectationsRequeueDelay),
+		)
+	})
+
+	Describe("##NewContExpectationsWithClock", func() {
+		It("should expire expectations based on the injected clock", func() {
+			fakeClock := testingclock.NewFakeClock(time.Now())
+			expectations := NewContExpectationsWithClock(fakeClock)
+			Expect(expectations.SetExpectations("test/machineset-0", 1, 0)).To(Succeed())
+
+			Expect(expectations.SatisfiedExpectations("test/machineset-0")).To(BeFalse())
+			fakeClock.Step(ExpectationsTimeout)
+			Expect(expectations.SatisfiedExpectations("test/machineset-0")).To(BeFalse())
+			fakeClock.Step(time.Second)
+			Expect(expectations.SatisfiedExpectations("test/machineset-0")).To(BeTrue())
+		})
+
+		It("should timestamp expectations with the injected clock", func() {
+			now := time.Now().Add(-time.Hour)
+			expectations := NewContExpectationsWithClock(testingclock.NewFakeClock(now))
+			Expect(expectations.SetExpectations("test/machineset-0", 1, 0)).To(Succeed())
+
+			exp, exists, err := expectations.GetExpectations("test/machineset-0")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(exists).To(BeTrue())
+			Expect(exp.TimeUntilExpiry(ExpectationsTimeout, now)).To(Equal(ExpectationsTimeout))
+		})
+
+		It("should expire expectations after the configured timeout", func() {
+			fakeClock := testingclock.NewFakeClock(time.Now())
+			expectations := NewContExpectations(10*time.Second, nil)
+			expectations.clock = fakeClock
+			Expect(expectations.Timeout()).To(Equal(10 * time.Second))
+			Expect(expectations.SetExpectations("test/machineset-0", 1, 0)).To(Succeed())
+
+			fakeClock.Step(10 * time.Second)
+			Expect(expectations.SatisfiedExpectations("test/machineset-0")).To(BeFalse())
+			fakeClock.Step(time.Second)
+			Expect(expectations.SatisfiedExpectations("test/machineset-0")).To(BeTrue())
+		})
+
+		It("should fall back to ExpectationsTimeout for a zero timeout", func() {
+			Expect(NewContExpectationsWithClock(testingclock.NewFakeClock(time.Now())).Timeout()).To(Equal(ExpectationsTimeout))
+			Expect(NewContExpectations(0, nil).Timeout()).To(Equal(ExpectationsTimeout))
+		})
+	})
+
+	Describe("##DeleteMachines", func() {
+		var (
+			recorder       *record.FakeRecorder
+			machineControl RealMachineControl
+			machineSet     *machinev1.MachineSet
+		)
+
+		BeforeEach(func() {
+			var machines []runtime.Object
+			for i := 0; i < 5; i++ {
+				machines = append(machines, &machinev1.Machine{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("machine-%d", i), Namespace: testNamespace}})
+			}
+			recorder = record.NewFakeRecorder(10)
+			machineControl = RealMachineControl{
+				controlMachineClient: fakemachineclientset.NewSimpleClientset(machines...).MachineV1alpha1(),
+				Recorder:             recorder,
+			}
+			machineSet = &machinev1.MachineSet{ObjectMeta: metav1.ObjectMeta{Name: "machineset-0", Namespace: testNamespace}}
+		})
+
+		It("should delete all machines and record an event per deletion", func() {
+			Expect(machineControl.DeleteMachines(context.TODO(), testNamespace, []string{"machine-0", "machine-1", "machine-2", "machine-3", "machine-4"}, machineSet)).To(Succeed())
+
+			machines, err := machineControl.controlMachineClient.Machines(testNamespace).List(context.TODO(), metav1.ListOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(machines.Items).To(BeEmpty())
+			Expect(recorder.Events).To(HaveLen(5))
+			Expect(<-recorder.Events).To(ContainSubstring(SuccessfulDeleteMachineReason))
+		})
+
+		It("should name the machines which failed to be deleted", func() {
+			err := machineControl.DeleteMachines(context.TODO(), testNamespace, []string{"machine-0", "missing-0", "missing-1"}, machineSet)
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("unable to delete machines missing-0, missing-1"))
+			_, err = machineControl.controlMachineClient.Machines(testNamespace).Get(context.TODO(), "machine-0", metav1.GetOptions{})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("should skip the remaining machines once a batch failed", func() {
+			err := machineControl.DeleteMachines(context.TODO(), testNamespace, []string{"missing-0", "machine-0", "machine-1"}, machineSet)
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("unable to delete machines missing-0, skipped machines machine-0, machine-1"))
+			_, err = machineControl.controlMachineClient.Machines(testNamespace).Get(context.TODO(), "machine-0", metav1.GetOptions{})
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	Describe("##CreateMachinesInBatch", func() {
+		var (
+			fakeClient     *fakemachineclientset.Clientset
+			machineControl RealMachineControl
+			machineSet     *machinev1.MachineSet
+			template       *machinev1.MachineTemplateSpec
+		)
+
+		BeforeEach(func() {
+			fakeClient = fakemachineclientset.NewSimpleClientset()
+			machineControl = RealMachineControl{
+				controlMachineClient: fakeClient.MachineV1alpha1(),
+				Recorder:             record.NewFakeRecorder(100),
+			}
+			machineSet = &machinev1.MachineSet{ObjectMeta: metav1.ObjectMeta{Name: "machineset-0", Namespace: testNamespace}}
+			template = &machinev1.MachineTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"pool": "a"}},
+				Spec:       machinev1.MachineSpec{Class: machinev1.ClassSpec{Kind: "MachineClass", Name: "machine-class"}},
+			}
+		})
+
+		It("should stop creating machines once a batch exceeds the quota", func() {
+			var mutex sync.Mutex
+			creates := 0
+			fakeClient.PrependReactor("create", "machines", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				mutex.Lock()
+				defer mutex.Unlock()
+				creates++
+				if creates > 4 {
+					return true, nil, apierrors.NewForbidden(machinev1.Resource("machines"), "", fmt.Errorf("exceeded quota"))
+				}
+				return true, action.(k8stesting.CreateAction).GetObject(), nil
+			})
+
+			created, err := machineControl.CreateMachinesInBatch(context.TODO(), testNamespace, template, 100, machineSet)
+			Expect(err).To(HaveOccurred())
+			Expect(created).To(Equal(4))
+			Expect(creates).To(Equal(1 + 2 + 4))
+		})
+
+		It("should create all machines if no batch fails", func() {
+			fakeClient.PrependReactor("create", "machines", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				return true, action.(k8stesting.CreateAction).GetObject(), nil
+			})
+
+			created, err := machineControl.CreateMachinesInBatch(context.TODO(), testNamespace, template, 5, machineSet)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(created).To(Equal(5))
+		})
+	})
+
+	Describe("##CreateMachinesReturning", func() {
+		var (
+			fakeClient    *fakemachineclientset.Clientset
+			machineSet    *machinev1.MachineSet
+			controllerRef *metav1.OwnerReference
+			template      *machinev1.MachineTemplateSpec
+		)
+
+		BeforeEach(func() {
+			fakeClient = fakemachineclientset.NewSimpleClientset()
+			machineSet = &machinev1.MachineSet{ObjectMeta: metav1.ObjectMeta{Name: "machineset-0", Namespace: testNamespace, UID: "machineset-uid"}}
+			controllerRef = metav1.NewControllerRef(machineSet, machinev1.SchemeGroupVersion.WithKind("MachineSet"))
+			template = &machinev1.MachineTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"pool": "a"}},
+				Spec:       machinev1.MachineSpec{Class: machinev1.ClassSpec{Kind: "MachineClass", Name: "machine-class"}},
+			}
+		})
+
+		It("should return the machine created by the real machine control with its generated name", func() {
+			// the fake clientset doesn't generate names like the APIServer
+			fakeClient.PrependReactor("create", "machines", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				machine := action.(k8stesting.CreateAction).GetObject().(*machinev1.Machine).DeepCopy()
+				machine.Name = machine.GenerateName + "abcde"
+				return true, machine, nil
+			})
+			machineControl := RealMachineControl{controlMachineClient: fakeClient.MachineV1alpha1(), Recorder: record.NewFakeRecorder(10)}
+
+			machine, err := machineControl.CreateMachinesWithControllerRefReturning(context.TODO(), testNamespace, template, machineSet, controllerRef)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(machine.Name).To(Equal("machineset-0-abcde"))
+			Expect(metav1.IsControlledBy(machine, machineSet)).To(BeTrue())
+
+			machine, err = machineControl.CreateMachinesReturning(context.TODO(), testNamespace, template, machineSet)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(machine.Name).To(Equal("machineset-0-abcde"))
+			Expect(machine.OwnerReferences).To(BeEmpty())
+		})
+
+		It("should return the machine created by the fake machine control", func() {
+			machineControl := FakeMachineControl{controlMachineClient: fakeClient.MachineV1alpha1().(*fakemachineapi.FakeMachineV1alpha1)}
+
+			machine, err := machineControl.CreateMachinesWithControllerRefReturning(context.TODO(), testNamespace, template, machineSet, controllerRef)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(machine.Name).To(HavePrefix("machineset-0--"))
+			Expect(metav1.IsControlledBy(machine, machineSet)).To(BeTrue())
+
+			_, err = fakeClient.MachineV1alpha1().Machines(testNamespace).Get(context.TODO(), machine.Name, metav1.GetOptions{})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should not return a machine if the creation failed", func() {
+			machineControl := RealMachineControl{controlMachineClient: fakeClient.MachineV1alpha1(), Recorder: record.NewFakeRecorder(10)}
+
+			machine, err := machineControl.CreateMachinesWithControllerRefReturning(context.TODO(), testNamespace, template, machineSet, nil)
+			Expect(err).To(HaveOccurred())
+			Expect(machine).To(BeNil())
+		})
+
+		It("should create the machine but not record an event once the context is cancelled", func() {
+			fakeClient.PrependReactor("create", "machines", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				machine := action.(k8stesting.CreateAction).GetObject().(*machinev1.Machine).DeepCopy()
+				machine.Name = machine.GenerateName + "abcde"
+				return true, machine, nil
+			})
+			recorder := record.NewFakeRecorder(10)
+			machineControl := RealMachineControl{controlMachineClient: fakeClient.MachineV1alpha1(), Recorder: recorder}
+			ctx, cancel := context.WithCancel(context.TODO())
+			cancel()
+
+			machine, err := machineControl.CreateMachinesWithControllerRefReturning(ctx, testNamespace, template, machineSet, controllerRef)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(machine.Name).To(Equal("machineset-0-abcde"))
+			Expect(recorder.Events).To(BeEmpty())
+		})
+
+		It("should not record a failure event once the context is cancelled", func() {
+			fakeClient.PrependReactor("create", "machines", func(k8stesting.Action) (bool, runtime.Object, error) {
+				return true, nil, context.Canceled
+			})
+			recorder := record.NewFakeRecorder(10)
+			machineControl := RealMachineControl{controlMachineClient: fakeClient.MachineV1alpha1(), Recorder: recorder}
+			ctx, cancel := context.WithCancel(context.TODO())
+			cancel()
+
+			_, err := machineControl.CreateMachinesWithControllerRefReturning(ctx, testNamespace, template, machineSet, controllerRef)
+			Expect(err).To(MatchError(context.Canceled))
+			Expect(recorder.Events).To(BeEmpty())
+		})
+
+		It("should record events as long as the context is not done", func() {
+			recorder := record.NewFakeRecorder(10)
+			recorderWithContext(context.TODO(), recorder).Eventf(machineSet, corev1.EventTypeNormal, SuccessfulCreateMachineReason, "Created Machine: %v", "machine-0")
+			Expect(recorder.Events).To(Receive(Equal("Normal SuccessfulCreate Created Machine: machine-0")))
+		})
+	})
+
+	Describe("##node annotation retries", func() {
+		var (
+			targetClient *k8sfake.Clientset
+			gets         int
+			ctx          context.Context
+			cancel       context.CancelFunc
+		)
+
+		BeforeEach(func() {
+			node := newNode(1, &corev1.NodeSpec{}, nil)
+			node.Annotations = map[string]string{"anno1": "value1"}
+			targetClient = k8sfake.NewSimpleClientset(node)
+			gets = 0
+			ctx, cancel = context.WithCancel(context.TODO())
+			DeferCleanup(cancel)
+
+			targetClient.PrependReactor("get", "nodes", func(_ k8stesting.Action) (bool, runtime.Object, error) {
+				gets++
+				return false, nil, nil
+			})
+			// Cancel the context on the first conflict
+			targetClient.PrependReactor("update", "nodes", func(_ k8stesting.Action) (bool, runtime.Object, error) {
+				cancel()
+				return true, nil, apierrors.NewConflict(corev1.Resource("nodes"), "node-0", fmt.Errorf("conflict"))
+			})
+		})
+
+		It("should stop adding annotations once the context is cancelled", func() {
+			err := AddOrUpdateAnnotationOnNode(ctx, targetClient, "node-0", map[string]string{"anno2": "value2"})
+			Expect(err).To(MatchError(context.Canceled))
+			Expect(gets).To(Equal(1))
+		})
+
+		It("should stop removing annotations once the context is cancelled", func() {
+			err := RemoveAnnotationsOffNode(ctx, targetClient, "node-0", map[string]string{"anno1": "value1"})
+			Expect(err).To(MatchError(context.Canceled))
+			Expect(gets).To(Equal(1))
+		})
+	})
+
+	Describe("##SetAnnotationUpdateBackoff", func() {
+		BeforeEach(func() {
+			original := UpdateAnnotationBackoff
+			DeferCleanup(func() {
+				Expect(SetAnnotationUpdateBackoff(original)).To(Succeed())
+			})
+		})
+
+		It("should use the configured backoff while adding annotations", func() {
+			node := newNode(1, &corev1.NodeSpec{}, nil)
+			targetClient := k8sfake.NewSimpleClientset(node)
+			updates := 0
+			targetClient.PrependReactor("update", "nodes", func(_ k8stesting.Action) (bool, runtime.Object, error) {
+				updates++
+				return true, nil, apierrors.NewConflict(corev1.Resource("nodes"), "node-0", fmt.Errorf("conflict"))
+			})
+
+			Expect(SetAnnotationUpdateBackoff(wait.Backoff{Steps: 3, Duration: time.Millisecond})).To(Succeed())
+			err := AddOrUpdateAnnotationOnNode(context.TODO(), targetClient, "node-0", map[string]string{"anno1": "value1"})
+			Expect(apierrors.IsConflict(err)).To(BeTrue())
+			Expect(updates).To(Equal(3))
+		})
+
+		DescribeTable("should reject invalid backoffs",
+			func(backoff wait.Backoff) {
+				Expect(SetAnnotationUpdateBackoff(backoff)).ToNot(Succeed())
+				Expect(SetNodeUpdateBackoff(backoff)).ToNot(Succeed())
+				Expect(UpdateAnnotationBackoff.Steps).To(Equal(5))
+			},
+			Entry("no steps", wait.Backoff{Steps: 0, Duration: time.Millisecond}),
+			Entry("zero duration", wait.Backoff{Steps: 3}),
+			Entry("negative duration", wait.Backoff{Steps: 3, Duration: -time.Millisecond}),
+		)
+	})
+
+	Describe("##SetNodeUpdateBackoff", func() {
+		BeforeEach(func() {
+			original := nodeops.Backoff
+			DeferCleanup(func() {
+				Expect(SetNodeUpdateBackoff(original)).To(Succeed())
+			})
+		})
+
+		It("should use the configured node update backoff while updating node conditions", func() {
+			node := newNode(1, &corev1.NodeSpec{}, nil)
+			targetClient := k8sfake.NewSimpleClientset(node)
+			updates := 0
+			targetClient.PrependReactor("update", "nodes", func(_ k8stesting.Action) (bool, runtime.Object, error) {
+				updates++
+				return true, nil, apierrors.NewConflict(corev1.Resource("nodes"), "node-0", fmt.Errorf("conflict"))
+			})
+
+			Expect(SetNodeUpdateBackoff(wait.Backoff{Steps: 2, Duration: time.Millisecond})).To(Succeed())
+			err := nodeops.AddOrUpdateConditionsOnNode(context.TODO(), targetClient, "node-0", corev1.NodeCondition{Type: corev1.NodeReady, Status: corev1.ConditionTrue})
+			Expect(apierrors.IsConflict(err)).To(BeTrue())
+			Expect(updates).To(Equal(2))
+		})
+
+		It("should use the configured node update backoff while updating node taints", func() {
+			node := newNode(1, &corev1.NodeSpec{}, nil)
+			targetClient := k8sfake.NewSimpleClientset(node)
+			updates := 0
+			targetClient.PrependReactor("update", "nodes", func(_ k8stesting.Action) (bool, runtime.Object, error) {
+				updates++
+				return true, nil, apierrors.NewConflict(corev1.Resource("nodes"), "node-0", fmt.Errorf("conflict"))
+			})
+
+			Expect(SetNodeUpdateBackoff(wait.Backoff{Steps: 4, Duration: time.Millisecond})).To(Succeed())
+			err := nodeops.AddOrUpdateTaintOnNode(context.TODO(), targetClient, "node-0", &corev1.Taint{Key: "key", Effect: corev1.TaintEffectNoSchedule})
+			Expect(apierrors.IsConflict(err)).To(BeTrue())
+			Expect(updates).To(Equal(4))
+		})
+	})
+
+	Describe("##GetLabelsFromNode", func() {
+		It("should return the labels of the node", func() {
+			node := newNode(1, &corev1.NodeSpec{}, nil)
+			node.Labels = map[string]string{"label1": "value1"}
+			targetClient := k8sfake.NewSimpleClientset(node)
+
+			labels, err := GetLabelsFromNode(context.TODO(), targetClient, "node-0")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(labels).To(Equal(map[string]string{"label1": "value1"}))
+		})
+
+		It("should return no labels and no error if the node is not found", func() {
+			targetClient := k8sfake.NewSimpleClientset()
+
+			labels, err := GetLabelsFromNode(context.TODO(), targetClient, "node-0")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(labels).To(BeNil())
+		})
+
+		It("should not call the API server for an empty node name", func() {
+			targetClient := k8sfake.NewSimpleClientset()
+
+			labels, err := GetLabelsFromNode(context.TODO(), targetClient, "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(labels).To(BeNil())
+			Expect(targetClient.Actions()).To(BeEmpty())
+		})
+	})
+
+	Describe("##node taints", func() {
+		unschedulableTaint := corev1.Taint{Key: "node.machine.sapcloud.io/unschedulable", Effect: corev1.TaintEffectNoSchedule}
+
+		var (
+			targetClient *k8sfake.Clientset
+			updates      int
+		)
+
+		setupNode := func(taints ...corev1.Taint) {
+			node := newNode(1, &corev1.NodeSpec{Taints: taints}, nil)
+			targetClient = k8sfake.NewSimpleClientset(node)
+			updates = 0
+			targetClient.PrependReactor("update", "nodes", func(_ k8stesting.Action) (bool, runtime.Object, error) {
+				updates++
+				return false, nil, nil
+			})
+		}
+		getTaints := func() []corev1.Taint {
+			node, err := targetClient.CoreV1().Nodes().Get(context.TODO(), "node-0", metav1.GetOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			return node.Spec.Taints
+		}
+
+		It("should add a taint to the node", func() {
+			setupNode()
+
+			Expect(AddOrUpdateTaintOnNode(context.TODO(), targetClient, "node-0", unschedulableTaint)).To(Succeed())
+			Expect(updates).To(Equal(1))
+			Expect(getTaints()).To(ConsistOf(unschedulableTaint))
+		})
+
+		It("should update a taint with the same key and effect", func() {
+			setupNode(unschedulableTaint)
+			updated := corev1.Taint{Key: unschedulableTaint.Key, Value: "drain", Effect: corev1.TaintEffectNoSchedule}
+
+			Expect(AddOrUpdateTaintOnNode(context.TODO(), targetClient, "node-0", updated)).To(Succeed())
+			Expect(getTaints()).To(ConsistOf(updated))
+		})
+
+		It("should not update the node when adding a duplicate taint", func() {
+			setupNode(unschedulableTaint)
+
+			Expect(AddOrUpdateTaintOnNode(context.TODO(), targetClient, "node-0", unschedulableTaint, unschedulableTaint)).To(Succeed())
+			Expect(updates).To(Equal(0))
+			Expect(getTaints()).To(ConsistOf(unschedulableTaint))
+		})
+
+		It("should remove a taint from the node", func() {
+			otherTaint := corev1.Taint{Key: "other", Effect: corev1.TaintEffectNoExecute}
+			setupNode(unschedulableTaint, otherTaint)
+
+			Expect(RemoveTaintOffNode(context.TODO(), targetClient, "node-0", unschedulableTaint)).To(Succeed())
+			Expect(updates).To(Equal(1))
+			Expect(getTaints()).To(ConsistOf(otherTaint))
+		})
+
+		It("should not update the node when removing a taint which isn't present", func() {
+			setupNode()
+
+			Expect(RemoveTaintOffNode(context.TODO(), targetClient, "node-0", unschedulableTaint)).To(Succeed())
+			Expect(updates).To(Equal(0))
+		})
+
+		It("should not fail if the node is not found", func() {
+			targetClient = k8sfake.NewSimpleClientset()
+
+			Expect(AddOrUpdateTaintOnNode(context.TODO(), targetClient, "node-0", unschedulableTaint)).To(Succeed())
+			Expect(RemoveTaintOffNode(context.TODO(), targetClient, "node-0", unschedulableTaint)).To(Succeed())
+		})
+	})
+
+	Describe("##ShardForKey", func() {
+		It("should be deterministic", func() {
+			Expect(ShardForKey("test/machineset-0", 5)).To(Equal(ShardForKey("test/machineset-0", 5)))
+		})
+
+		It("should map every key to shard 0 without sharding", func() {
+			Expect(ShardForKey("test/machineset-0", 1)).To(Equal(0))
+			Expect(ShardForKey("test/machineset-0", 0)).To(Equal(0))
+			Expect(ShardForKey("test/machineset-0", -1)).To(Equal(0))
+		})
+
+		It("should distribute keys roughly uniformly across the shards", func() {
+			const (
+				totalShards = 4
+				totalKeys   = 4000
+			)
+			counts := make([]int, totalShards)
+			for i := 0; i < totalKeys; i++ {
+				shard := ShardForKey(fmt.Sprintf("test/machineset-%d", i), totalShards)
+				Expect(shard).To(BeNumerically(">=", 0))
+				Expect(shard).To(BeNumerically("<", totalShards))
+				counts[shard]++
+			}
+			for _, count := range counts {
+				Expect(count).To(BeNumerically("~", totalKeys/totalShards, totalKeys/totalShards/5))
+			}
+		})
+	})
+
+	Describe("##PatchMachineWithType", func() {
+		It("should remove a single annotation with a JSON patch", func() {
+			machine := &machinev1.Machine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "machine-0",
+					Namespace:   testNamespace,
+					Annotations: map[string]string{"anno1": "value1", "anno2": "value2"},
+				},
+			}
+			fakeClient := fakemachineclientset.NewSimpleClientset(machine)
+			machineControl := RealMachineControl{controlMachineClient: fakeClient.MachineV1alpha1(), Recorder: record.NewFakeRecorder(10)}
+
+			patch := []byte(`[{"op": "remove", "path": "/metadata/annotations/anno1"}]`)
+			Expect(machineControl.PatchMachineWithType(context.TODO(), testNamespace, "machine-0", types.JSONPatchType, patch)).To(Succeed())
+
+			patched, err := fakeClient.MachineV1alpha1().Machines(testNamespace).Get(context.TODO(), "machine-0", metav1.GetOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(patched.Annotations).To(Equal(map[string]string{"anno2": "value2"}))
+		})
+
+		It("should default to a JSON merge patch in PatchMachine", func() {
+			machine := &machinev1.Machine{
+				ObjectMeta: metav1.ObjectMeta{Name: "machine-0", Namespace: testNamespace},
+			}
+			fakeClient := fakemachineclientset.NewSimpleClientset(machine)
+			machineControl := RealMachineControl{controlMachineClient: fakeClient.MachineV1alpha1(), Recorder: record.NewFakeRecorder(10)}
+
+			Expect(machineControl.PatchMachine(context.TODO(), testNamespace, "machine-0", []byte(`{"metadata":{"labels":{"key":"value"}}}`))).To(Succeed())
+
+			Expect(fakeClient.Actions()).To(HaveLen(1))
+			Expect(fakeClient.Actions()[0].(k8stesting.PatchAction).GetPatchType()).To(Equal(types.MergePatchType))
+		})
+	})
+
+	Describe("##PatchMachineStatus", func() {
+		It("should patch the status subresource without touching the spec", func() {
+			machine := &machinev1.Machine{
+				ObjectMeta: metav1.ObjectMeta{Name: "machine-0", Namespace: testNamespace},
+				Spec:       machinev1.MachineSpec{ProviderID: "provider-id-0"},
+				Status: machinev1.MachineStatus{
+					CurrentStatus: machinev1.CurrentStatus{Phase: machinev1.MachineRunning},
+				},
+			}
+			fakeClient := fakemachineclientset.NewSimpleClientset(machine)
+			machineControl := RealMachineControl{controlMachineClient: fakeClient.MachineV1alpha1(), Recorder: record.NewFakeRecorder(10)}
+
+			patch := []byte(`{"status":{"currentStatus":{"phase":"Unknown"}}}`)
+			Expect(machineControl.PatchMachineStatus(context.TODO(), testNamespace, "machine-0", patch)).To(Succeed())
+
+			Expect(fakeClient.Actions()).To(HaveLen(1))
+			Expect(fakeClient.Actions()[0].GetSubresource()).To(Equal("status"))
+			patched, err := fakeClient.MachineV1alpha1().Machines(testNamespace).Get(context.TODO(), "machine-0", metav1.GetOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(patched.Status.CurrentStatus.Phase).To(Equal(machinev1.MachineUnknown))
+			Expect(patched.Spec).To(Equal(machine.Spec))
+		})
+	})
+
+	Describe("##ComputeHash64", func() {
+		newLabelledTemplate := func(name string) *machinev1.MachineTemplateSpec {
+			return &machinev1.MachineTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"name": name}},
+			}
+		}
+
+		It("should tell apart templates whose 32-bit hashes collide", func() {
+			template1 := newLabelledTemplate("ld363b8v")
+			template2 := newLabelledTemplate("aheifmys")
+
+			Expect(ComputeHash(template1, nil)).To(Equal(ComputeHash(template2, nil)))
+			Expect(ComputeHash64(template1, nil)).ToNot(Equal(ComputeHash64(template2, nil)))
+		})
+
+		It("should include the collision count", func() {
+			template := newLabelledTemplate("template")
+
+			Expect(ComputeHash64(template, nil)).To(Equal(ComputeHash64(template, nil)))
+			Expect(ComputeHash64(template, ptr.To[int32](1))).ToNot(Equal(ComputeHash64(template, nil)))
+		})
+	})
+
+	Describe("##ObserveOnce", func() {
+		It("should lower the expectations only on the first observation of an event", func() {
+			expectations := NewContExpectations(0, nil)
+			Expect(expectations.SetExpectations("test/observe-once-0", 2, 2)).To(Succeed())
+
+			Expect(ObserveOnce(expectations, "test/observe-once-0", "create-0", true)).To(BeTrue())
+			Expect(ObserveOnce(expectations, "test/observe-once-0", "create-0", true)).To(BeFalse())
+			Expect(ObserveOnce(expectations, "test/observe-once-0", "delete-0", false)).To(BeTrue())
+			Expect(ObserveOnce(expectations, "test/observe-once-0", "delete-0", false)).To(BeFalse())
+
+			exp, exists, err := expectations.GetExpectations("test/observe-once-0")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(exists).To(BeTrue())
+			add, del := exp.GetExpectations()
+			Expect(add).To(Equal(int64(1)))
+			Expect(del).To(Equal(int64(1)))
+		})
+
+		It("should track events per controller", func() {
+			expectations := NewContExpectations(0, nil)
+
+			Expect(ObserveOnce(expectations, "test/observe-once-1", "event-0", true)).To(BeTrue())
+			Expect(ObserveOnce(expectations, "test/observe-once-2", "event-0", true)).To(BeTrue())
+		})
+
+		It("should forget the oldest events beyond the cache size", func() {
+			expectations := NewContExpectations(0, nil)
+
+			Expect(ObserveOnce(expectations, "test/observe-once-3", "event-0", true)).To(BeTrue())
+			for i := 1; i <= observedEventsCacheSize; i++ {
+				ObserveOnce(expectations, "test/observe-once-3", fmt.Sprintf("event-%d", i), true)
+			}
+			Expect(ObserveOnce(expectations, "test/observe-once-3", "event-0", true)).To(BeTrue())
+		})
+
+		It("should track events per expectations store", func() {
+			expectations, other := NewContExpectations(0, nil), NewContExpectations(0, nil)
+
+			Expect(ObserveOnce(expectations, "test/observe-once-4", "event-0", true)).To(BeTrue())
+			for i := 1; i <= observedEventsCacheSize; i++ {
+				ObserveOnce(other, "test/observe-once-4", fmt.Sprintf("event-%d", i), true)
+			}
+			Expect(ObserveOnce(expectations, "test/observe-once-4", "event-0", true)).To(BeFalse())
+			Expect(ObserveOnce(other, "test/observe-once-4", "event-0", true)).To(BeTrue())
+		})
+	})
+
+	Describe("##ComputeHash", func() {
+		It("should not depend on the insertion order of map fields", func() {
+			keys := []string{"key-a", "key-b", "key-c", "key-d", "key-e", "key-f", "key-g", "key-h"}
+			labels1 := make(map[string]string)
+			for _, key := range keys {
+				labels1[key] = "value-" + key
+			}
+			labels2 := make(map[string]string)
+			for i := len(keys) - 1; i >= 0; i-- {
+				labels2[keys[i]] = "value-" + keys[i]
+			}
+			template1 := &machinev1.MachineTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels1, Annotations: map[string]string{"anno1": "value1", "anno2": "value2"}},
+			}
+			template2 := &machinev1.MachineTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels2, Annotations: map[string]string{"anno2": "value2", "anno1": "value1"}},
+			}
+
+			for i := 0; i < 10; i++ {
+				Expect(ComputeHash(template1, nil)).To(Equal(ComputeHash(template2, nil)))
+			}
+		})
+	})
+
+	Describe("##MachineSetsByPriority", func() {
+		now := time.Now()
+		newPrioritizedMachineSet := func(name, priority string, replicas int32, age time.Duration) *machinev1.MachineSet {
+			ms := &machinev1.MachineSet{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace, CreationTimestamp: metav1.NewTime(now.Add(-age))},
+				Spec:       machinev1.MachineSetSpec{Replicas: replicas},
+			}
+			if priority != "" {
+				ms.Annotations = map[string]string{machineutils.MachineSetPriority: priority}
+			}
+			return ms
+		}
+
+		It("should sort by priority, then size, then age", func() {
+			machineSets := []*machinev1.MachineSet{
+				newPrioritizedMachineSet("default-small", "", 1, time.Hour),
+				newPrioritizedMachineSet("high", "5", 3, time.Hour),
+				newPrioritizedMachineSet("default-large-new", "", 3, time.Hour),
+				newPrioritizedMachineSet("low", "1", 1, time.Hour),
+				newPrioritizedMachineSet("default-large-old", "3", 3, 2*time.Hour),
+				newPrioritizedMachineSet("invalid", "high", 2, time.Hour),
+			}
+			sort.Sort(MachineSetsByPriority(machineSets))
+
+			var names []string
+			for _, ms := range machineSets {
+				names = append(names, ms.Name)
+			}
+			Expect(names).To(Equal([]string{"low", "default-large-old", "default-large-new", "invalid", "default-small", "high"}))
+		})
+	})
+
+	Describe("##MachineNameGenerator", func() {
+		machineSet := &machinev1.MachineSet{ObjectMeta: metav1.ObjectMeta{Name: "machineset-0", Namespace: testNamespace}}
+		template := &machinev1.MachineTemplateSpec{}
+
+		It("should append a random 5 character suffix by default", func() {
+			machine, err := GetFakeMachineFromTemplate(template, machineSet, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(machine.Name).To(MatchRegexp(`^machineset-0--[0-9a-f]{5}$`))
+		})
+
+		It("should use the overridden generator", func() {
+			defaultGenerator := MachineNameGenerator
+			DeferCleanup(func() { MachineNameGenerator = defaultGenerator })
+			count := 0
+			MachineNameGenerator = func(prefix string) string {
+				count++
+				return fmt.Sprintf("%s%d", prefix, count)
+			}
+
+			first, err := GetFakeMachineFromTemplate(template, machineSet, nil)
+			Expect(err).ToNot(HaveOccurred())
+			second, err := GetFakeMachineFromTemplate(template, machineSet, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(first.Name).To(Equal("machineset-0-1"))
+			Expect(second.Name).To(Equal("machineset-0-2"))
+		})
+	})
+
+	Describe("##ControlleeExpectations Equal", func() {
+		now := time.Now()
+
+		DescribeTable("should compare the key and counters",
+			func(other *ControlleeExpectations, expected bool) {
+				exp := &ControlleeExpectations{add: 2, del: 1, key: "test/machineset-0", timestamp: now}
+				Expect(exp.Equal(other)).To(Equal(expected))
+			},
+			Entry("equal expectations", &ControlleeExpectations{add: 2, del: 1, key: "test/machineset-0", timestamp: now}, true),
+			Entry("different timestamp", &ControlleeExpectations{add: 2, del: 1, key: "test/machineset-0", timestamp: now.Add(time.Minute)}, true),
+			Entry("different add", &ControlleeExpectations{add: 3, del: 1, key: "test/machineset-0", timestamp: now}, false),
+			Entry("different del", &ControlleeExpectations{add: 2, del: 0, key: "test/machineset-0", timestamp: now}, false),
+			Entry("different key", &ControlleeExpectations{add: 2, del: 1, key: "test/machineset-1", timestamp: now}, false),
+			Entry("nil", nil, false),
+		)
+
+		It("should treat two nil expectations as equal", func() {
+			var exp *ControlleeExpectations
+			Expect(exp.Equal(nil)).To(BeTrue())
+		})
+	})
+
+	Describe("##DetectNameCollisions", func() {
+		var fakeClient *fakemachineclientset.Clientset
+
+		BeforeEach(func() {
+			fakeClient = fakemachineclientset.NewSimpleClientset(
+				&machinev1.Machine{ObjectMeta: metav1.ObjectMeta{Name: "machine-0", Namespace: testNamespace}},
+				&machinev1.Machine{ObjectMeta: metav1.ObjectMeta{Name: "machine-1", Namespace: testNamespace}},
+				&machinev1.Machine{ObjectMeta: metav1.ObjectMeta{Name: "machine-2", Namespace: "other"}},
+			)
+		})
+
+		It("should return the names of existing machines in the namespace with a single list call", func() {
+			collisions, err := DetectNameCollisions(context.TODO(), fakeClient.MachineV1alpha1(), testNamespace, []string{"machine-1", "machine-2", "machine-0", "machine-3"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(collisions).To(Equal([]string{"machine-1", "machine-0"}))
+			Expect(fakeClient.Actions()).To(HaveLen(1))
+			Expect(fakeClient.Actions()[0].GetVerb()).To(Equal("list"))
+		})
+
+		It("should not call the API server without names", func() {
+			collisions, err := DetectNameCollisions(context.TODO(), fakeClient.MachineV1alpha1(), testNamespace, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(collisions).To(BeEmpty())
+			Expect(fakeClient.Actions()).To(BeEmpty())
+		})
+
+		It("should return the error of the list call", func() {
+			fakeClient.PrependReactor("list", "machines", func(_ k8stesting.Action) (bool, runtime.Object, error) {
+				return true, nil, fmt.Errorf("list failed")
+			})
+
+			_, err := DetectNameCollisions(context.TODO(), fakeClient.MachineV1alpha1(), testNamespace, []string{"machine-0"})
+			Expect(err).To(MatchError(ContainSubstring("list failed")))
+		})
+	})
+
+	Describe("##Snapshot", func() {
+		It("should return a copy of the expectations of all controllers", func() {
+			fakeClock := testingclock.NewFakeClock(time.Now())
+			expectations := NewContExpectationsWithClock(fakeClock)
+			Expect(expectations.SetExpectations("test/machineset-2", 0, 2)).To(Succeed())
+			Expect(expectations.SetExpectations("test/machineset-0", 3, 0)).To(Succeed())
+			Expect(expectations.SetExpectations("test/machineset-1", 1, 1)).To(Succeed())
+			expectations.CreationObserved("test/machineset-0")
+
+			snapshot := expectations.Snapshot()
+			Expect(snapshot).To(HaveLen(3))
+			for i, expected := range []struct {
+				key      string
+				add, del int64
+			}{
+				{"test/machineset-0", 2, 0},
+				{"test/machineset-1", 1, 1},
+				{"test/machineset-2", 0, 2},
+			} {
+				Expect(snapshot[i].Key()).To(Equal(expected.key))
+				Expect(snapshot[i].Timestamp()).To(Equal(fakeClock.Now()))
+				add, del := snapshot[i].GetExpectations()
+				Expect(add).To(Equal(expected.add))
+				Expect(del).To(Equal(expected.del))
+			}
+		})
+
+		It("should not let callers mutate the live store", func() {
+			expectations := NewContExpectations(0, nil)
+			Expect(expectations.SetExpectations("test/machineset-0", 3, 0)).To(Succeed())
+
+			snapshot := expectations.Snapshot()
+			snapshot[0].Add(-3, 0)
+
+			exp, exists, err := expectations.GetExpectations("test/machineset-0")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(exists).To(BeTrue())
+			add, _ := exp.GetExpectations()
+			Expect(add).To(Equal(int64(3)))
+		})
+	})
+})
+
+type countingRateLimiter struct {
+	flowcontrol.RateLimiter
+	waits int
+}
+
+func (r *countingRateLimiter) Wait(ctx context.Context) error {
+	r.waits++
+	return r.RateLimiter.Wait(ctx)
+}
+
+type countingMachineControl struct {
+	MachineControlInterface
+	creates, deletes, patches int
+}
+
+func (m *countingMachineControl) CreateMachines(_ context.Context, _ string, _ *machinev1.MachineTemplateSpec, _ runtime.Object) error {
+	m.creates++
+	return nil
+}
+
+func (m *countingMachineControl) CreateMachinesWithControllerRef(_ context.Context, _ string, _ *machinev1.MachineTemplateSpec, _ runtime.Object, _ *metav1.OwnerReference) error {
+	m.creates++
+	return nil
+}
+
+func (m *countingMachineControl) CreateMachinesReturning(_ context.Context, _ string, _ *machinev1.MachineTemplateSpec, _ runtime.Object) (*machinev1.Machine, error) {
+	m.creates++
+	return &machinev1.Machine{}, nil
+}
+
+func (m *countingMachineControl) CreateMachinesWithControllerRefReturning(_ context.Context, _ string, _ *machinev1.MachineTemplateSpec, _ runtime.Object, _ *metav1.OwnerReference) (*machinev1.Machine, error) {
+	m.creates++
+	return &machinev1.Machine{}, nil
+}
+
+func (m *countingMachineControl) DeleteMachine(_ context.Context, _ string, _ string, _ runtime.Object) error {
+	m.deletes++
+	return nil
+}
+
+func (m *countingMachineControl) DeleteMachines(_ context.Context, _ string, machineIDs []string, _ runtime.Object) error {
+	m.deletes += len(machineIDs)
+	return nil
+}
+
+func (m *countingMachineControl) PatchMachine(_ context.Context, _ string, _ string, _ []byte) error {
+	m.patches++
+	return nil
+}
+
+func (m *countingMachineControl) PatchMachineWithType(_ context.Context, _ string, _ string, _ types.PatchType, _ []byte) error {
+	m.patches++
+	return nil
+}
+
+func (m *countingMachineControl) PatchMachineStatus(_ context.Context, _ string, _ string, _ []byte) error {
+	m.patches++
+	return nil
+}
+
+type capturingRecorder struct {
+	record.EventRecorder
+	objects []runtime.Object
+}
+
+func (r *capturingRecorder) Event(object runtime.Object, _, _, _ string) {
+	r.objects = append(r.objects, object)
+}
+
+func (r *capturingRecorder) Eventf(object runtime.Object, _, _, _ string, _ ...interface{}) {
+	r.objects = append(r.objects, object)
+}
@@ -26,6 +26,9 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"sort"
+	"strings"
+	"time"
 
 	"k8s.io/klog/v2"
 
@@ -33,6 +36,7 @@ import (
 	machineapi "github.com/gardener/machine-controller-manager/pkg/client/clientset/versioned/typed/machine/v1alpha1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 // updateMachineSetStatus attempts to update the Status.Replicas of the given MachineSet, with a single GET/PUT retry.
@@ -46,10 +50,10 @@ func updateMachineSetStatus(ctx context.Context, machineClient machineapi.Machin
 		is.Status.FullyLabeledReplicas == newStatus.FullyLabeledReplicas &&
 		is.Status.ReadyReplicas == newStatus.ReadyReplicas &&
 		is.Status.AvailableReplicas == newStatus.AvailableReplicas &&
-		is.Generation == is.Status.ObservedGeneration &&
 		reflect.DeepEqual(is.Status.Conditions, newStatus.Conditions) &&
 		reflect.DeepEqual(is.Status.FailedMachines, newStatus.FailedMachines) {
-		return is, nil
+		// Only the observed generation might be behind, which doesn't warrant a full status update.
+		return BumpObservedGeneration(ctx, machineClient, is)
 	}
 
 	// Save the generation number we acted on, otherwise we might wrongfully indicate
@@ -89,6 +93,23 @@ func updateMachineSetStatus(ctx context.Context, machineClient machineapi.Machin
 	return nil, updateErr
 }
 
+// NeedsObservedGenerationUpdate returns true if the observed generation in the status of the MachineSet
+// is behind its generation.
+func NeedsObservedGenerationUpdate(is *v1alpha1.MachineSet) bool {
+	return is.Status.ObservedGeneration < is.Generation
+}
+
+// BumpObservedGeneration patches the observed generation in the status of the MachineSet to its generation,
+// if it is behind. The MachineSet is returned unchanged otherwise.
+func BumpObservedGeneration(ctx context.Context, machineClient machineapi.MachineV1alpha1Interface, is *v1alpha1.MachineSet) (*v1alpha1.MachineSet, error) {
+	if !NeedsObservedGenerationUpdate(is) {
+		return is, nil
+	}
+	klog.V(4).Infof("Updating observed generation for MachineSet: %s/%s, sequence No: %v->%v", is.Namespace, is.Name, is.Status.ObservedGeneration, is.Generation)
+	patch := []byte(fmt.Sprintf(`{"status":{"observedGeneration":%d}}`, is.Generation))
+	return machineClient.MachineSets(is.Namespace).Patch(ctx, is.Name, types.MergePatchType, patch, metav1.PatchOptions{}, "status")
+}
+
 func calculateMachineSetStatus(is *v1alpha1.MachineSet, filteredMachines []*v1alpha1.Machine, manageReplicasErr error) v1alpha1.MachineSetStatus {
 	newStatus := is.Status
 	// Count the number of machines that have labels matching the labels of the machine
@@ -221,3 +242,127 @@ func isMachineReady(machine *v1alpha1.Machine) bool {
 	// TODO add more conditions
 	return machine.Status.CurrentStatus.Phase == v1alpha1.MachineRunning
 }
+
+const (
+	// multipleMachineFailuresReason is the summarized reason for machines which failed for different reasons
+	multipleMachineFailuresReason = "MultipleMachineFailures"
+	// unknownMachineFailureReason is the reason for failed machines without an error code
+	unknownMachineFailureReason = "Unknown"
+	// maxMachineErrorSummaryLength is the maximum length of the summarized message of machine failures
+	maxMachineErrorSummaryLength = 1024
+	// replicaFailureWindow is the duration for which a failed create or delete operation of a machine
+	// is considered recent enough to report a ReplicaFailure condition on its machine set
+	replicaFailureWindow = 10 * time.Minute
+)
+
+// SummarizeMachineErrors summarizes the distinct failure reasons of the failed machines into a reason and
+// a message for a condition of their machine set. The reason is the error code of the last operation if all
+// machines failed alike, and the message counts the machines per reason with a sample description.
+// It returns empty strings if none of the machines failed.
+func SummarizeMachineErrors(machines []*v1alpha1.Machine) (reason, message string) {
+	var (
+		reasons      []string
+		counts       = make(map[string]int)
+		descriptions = make(map[string]string)
+		failed       int
+	)
+	for _, machine := range machines {
+		if machine.Status.LastOperation.State != v1alpha1.MachineStateFailed && machine.Status.CurrentStatus.Phase != v1alpha1.MachineFailed {
+			continue
+		}
+		failed++
+		machineReason := machine.Status.LastOperation.ErrorCode
+		if machineReason == "" {
+			machineReason = unknownMachineFailureReason
+		}
+		if counts[machineReason] == 0 {
+			reasons = append(reasons, machineReason)
+			descriptions[machineReason] = machine.Status.LastOperation.Description
+		}
+		counts[machineReason]++
+	}
+	if failed == 0 {
+		return "", ""
+	}
+
+	sort.SliceStable(reasons, func(i, j int) bool {
+		if counts[reasons[i]] != counts[reasons[j]] {
+			return counts[reasons[i]] > counts[reasons[j]]
+		}
+		return reasons[i] < reasons[j]
+	})
+
+	reason = reasons[0]
+	if len(reasons) > 1 {
+		reason = multipleMachineFailuresReason
+	}
+	summaries := make([]string, 0, len(reasons))
+	for _, r := range reasons {
+		summaries = append(summaries, fmt.Sprintf("%s (%d): %s", r, counts[r], descriptions[r]))
+	}
+	message = fmt.Sprintf("%d machine(s) failed with %d distinct reason(s): %s", failed, len(reasons), strings.Join(summaries, "; "))
+	if len(message) > maxMachineErrorSummaryLength {
+		message = message[:maxMachineErrorSummaryLength-3] + "..."
+	}
+	return reason, message
+}
+
+// ComputeMachineSetConditions derives the MachinesReady and ReplicaFailure conditions of the machine set from the
+// phases of its machines and their create/delete operations which failed within replicaFailureWindow before now.
+// Failed creations take precedence over failed deletions. Conditions of other types are kept as they are, and the
+// transition time of a condition is only moved to now if its status changed.
+func ComputeMachineSetConditions(ms *v1alpha1.MachineSet, machines []*v1alpha1.Machine, now time.Time) []v1alpha1.MachineSetCondition {
+	var (
+		readyReplicas                int32
+		failedCreates, failedDeletes []*v1alpha1.Machine
+	)
+	for _, machine := range machines {
+		if isMachineAvailable(machine) && isMachineReady(machine) {
+			readyReplicas++
+		}
+		lastOperation := machine.Status.LastOperation
+		if lastOperation.State != v1alpha1.MachineStateFailed || now.Sub(lastOperation.LastUpdateTime.Time) > replicaFailureWindow {
+			continue
+		}
+		switch lastOperation.Type {
+		case v1alpha1.MachineOperationCreate:
+			failedCreates = append(failedCreates, machine)
+		case v1alpha1.MachineOperationDelete:
+			failedDeletes = append(failedDeletes, machine)
+		}
+	}
+
+	var conditions []v1alpha1.MachineSetCondition
+	for _, c := range ms.Status.Conditions {
+		if c.Type != v1alpha1.MachineSetMachinesReady && c.Type != v1alpha1.MachineSetReplicaFailure {
+			conditions = append(conditions, c)
+		}
+	}
+
+	readyMessage := fmt.Sprintf("%d/%d machines are ready", readyReplicas, ms.Spec.Replicas)
+	if readyReplicas >= ms.Spec.Replicas {
+		conditions = append(conditions, newMachineSetConditionAt(ms, v1alpha1.MachineSetMachinesReady, v1alpha1.ConditionTrue, "MachinesReady", readyMessage, now))
+	} else {
+		conditions = append(conditions, newMachineSetConditionAt(ms, v1alpha1.MachineSetMachinesReady, v1alpha1.ConditionFalse, "MachinesNotReady", readyMessage, now))
+	}
+
+	if len(failedCreates) > 0 {
+		_, message := SummarizeMachineErrors(failedCreates)
+		conditions = append(conditions, newMachineSetConditionAt(ms, v1alpha1.MachineSetReplicaFailure, v1alpha1.ConditionTrue, "FailedCreate", message, now))
+	} else if len(failedDeletes) > 0 {
+		_, message := SummarizeMachineErrors(failedDeletes)
+		conditions = append(conditions, newMachineSetConditionAt(ms, v1alpha1.MachineSetReplicaFailure, v1alpha1.ConditionTrue, "FailedDelete", message, now))
+	}
+	return conditions
+}
+
+// newMachineSetConditionAt creates a new MachineSet condition, keeping the transition time of the current
+// condition of the machine set if its status is unchanged.
+func newMachineSetConditionAt(ms *v1alpha1.MachineSet, condType v1alpha1.MachineSetConditionType, status v1alpha1.ConditionStatus, reason, msg string, now time.Time) v1alpha1.MachineSetCondition {
+	cond := NewMachineSetCondition(condType, status, reason, msg)
+	cond.LastTransitionTime = metav1.NewTime(now)
+	if currentCond := GetCondition(&ms.Status, condType); currentCond != nil && currentCond.Status == status {
+		cond.LastTransitionTime = currentCond.LastTransitionTime
+	}
+	return cond
+}
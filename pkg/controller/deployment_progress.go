@@ -38,7 +38,7 @@ import (
 // for example a resync of the deployment after it was scaled up. In those cases,
 // we shouldn't try to estimate any progress.
 func (dc *controller) syncRolloutStatus(ctx context.Context, allISs []*v1alpha1.MachineSet, newIS *v1alpha1.MachineSet, d *v1alpha1.MachineDeployment) error {
-	newStatus := calculateDeploymentStatus(allISs, newIS, d)
+	newStatus := calculateDeploymentStatus(allISs, d)
 
 	// If there is only one machine set that is active then that means we are not running
 	// a new rollout and this is a resync where we don't need to estimate any progress.
@@ -28,12 +28,14 @@ import (
 	"reflect"
 	"sort"
 	"strconv"
+	"time"
 
 	"github.com/gardener/machine-controller-manager/pkg/apis/machine/v1alpha1"
 	labelsutil "github.com/gardener/machine-controller-manager/pkg/util/labels"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/rand"
@@ -48,20 +50,29 @@ func (dc *controller) syncStatusOnly(ctx context.Context, d *v1alpha1.MachineDep
 	}
 
 	allISs := append(oldISs, newIS)
-	return dc.syncMachineDeploymentStatus(ctx, allISs, newIS, d)
+	return dc.syncMachineDeploymentStatus(ctx, allISs, d)
 }
 
 // sync is responsible for reconciling deployments on scaling events or when they
-// are paused.
+// are paused. Paused deployments are only scaled on direct edits of their replicas,
+// unless scaling while paused is requested via the ScaleWhilePausedAnnotation.
 func (dc *controller) sync(ctx context.Context, d *v1alpha1.MachineDeployment, isList []*v1alpha1.MachineSet, machineMap map[types.UID]*v1alpha1.MachineList) error {
 	newIS, oldISs, err := dc.getAllMachineSetsAndSyncRevision(ctx, d, isList, machineMap, false)
 	if err != nil {
 		return err
 	}
-	if err := dc.scale(ctx, d, newIS, oldISs); err != nil {
-		// If we get an error while trying to scale, the deployment will be requeued
-		// so we can abort this resync
-		return err
+	shouldScale := ShouldReconcileScaling(d)
+	if !shouldScale {
+		if shouldScale, err = dc.isScalingEvent(ctx, d, isList, machineMap); err != nil {
+			return err
+		}
+	}
+	if shouldScale {
+		if err := dc.scale(ctx, d, newIS, oldISs); err != nil {
+			// If we get an error while trying to scale, the deployment will be requeued
+			// so we can abort this resync
+			return err
+		}
 	}
 
 	// Clean up the deployment when it's paused and no rollback is in flight.
@@ -72,7 +83,7 @@ func (dc *controller) sync(ctx context.Context, d *v1alpha1.MachineDeployment, i
 	}
 
 	allISs := append(oldISs, newIS)
-	return dc.syncMachineDeploymentStatus(ctx, allISs, newIS, d)
+	return dc.syncMachineDeploymentStatus(ctx, allISs, d)
 }
 
 // checkPausedConditions checks if the given deployment is paused or not and adds an appropriate condition.
@@ -384,6 +395,7 @@ func (dc *controller) getNewMachineSet(ctx context.Context, d *v1alpha1.MachineD
 	}
 	if !alreadyExists && newReplicasCount > 0 {
 		dc.recorder.Eventf(d, v1.EventTypeNormal, "ScalingMachineSet", "Scaled up machine set %s to %d", createdIS.Name, newReplicasCount)
+		dc.recordScale(NewScaleRecord(createdIS, 0, newReplicasCount, "created new machine set", time.Now()))
 	}
 
 	needsUpdate := SetMachineDeploymentRevision(d, newRevision)
@@ -552,6 +564,7 @@ func (dc *controller) scaleMachineSet(ctx context.Context, is *v1alpha1.MachineS
 	annotationsNeedUpdate := SetReplicasAnnotations(isCopy, (deployment.Spec.Replicas), (deployment.Spec.Replicas)+MaxSurge(*deployment))
 
 	scaled := false
+	oldScale := is.Spec.Replicas
 	var err error
 	if sizeNeedsUpdate || annotationsNeedUpdate {
 		isCopy.Spec.Replicas = newScale
@@ -559,6 +572,7 @@ func (dc *controller) scaleMachineSet(ctx context.Context, is *v1alpha1.MachineS
 		if err == nil && sizeNeedsUpdate {
 			scaled = true
 			dc.recorder.Eventf(deployment, v1.EventTypeNormal, "ScalingMachineSet", "Scaled %s machine set %s to %d", scalingOperation, is.Name, newScale)
+			dc.recordScale(NewScaleRecord(is, oldScale, newScale, fmt.Sprintf("scaled %s for machine deployment %s", scalingOperation, deployment.Name), time.Now()))
 		}
 	}
 	return scaled, is, err
@@ -583,6 +597,11 @@ func (dc *controller) cleanupMachineDeployment(ctx context.Context, oldISs []*v1
 		return nil
 	}
 
+	machines, err := dc.machineLister.Machines(deployment.Namespace).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+
 	sort.Sort(MachineSetsByCreationTimestamp(cleanableISes))
 	klog.V(4).Infof("Looking to cleanup old machine sets for deployment %q", deployment.Name)
 
@@ -592,6 +611,11 @@ func (dc *controller) cleanupMachineDeployment(ctx context.Context, oldISs []*v1
 		if is.Status.Replicas != 0 || (is.Spec.Replicas) != 0 || is.Generation > is.Status.ObservedGeneration || is.DeletionTimestamp != nil {
 			continue
 		}
+		// Avoid orphaning machines which are still controlled by the machine set, e.g. terminating ones
+		if safe, remaining := IsMachineSetSafeToDelete(is, machines); !safe {
+			klog.V(3).Infof("Skipping cleanup of machine set %q for deployment %q as it still has %d machines", is.Name, deployment.Name, remaining)
+			continue
+		}
 		klog.V(3).Infof("Trying to cleanup machine set %q for deployment %q", is.Name, deployment.Name)
 		if err := dc.controlMachineClient.MachineSets(is.Namespace).Delete(ctx, is.Name, metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
 			// Return error instead of aggregating and continuing DELETEs on the theory
@@ -604,8 +628,8 @@ func (dc *controller) cleanupMachineDeployment(ctx context.Context, oldISs []*v1
 }
 
 // syncDeploymentStatus checks if the status is up-to-date and sync it if necessary
-func (dc *controller) syncMachineDeploymentStatus(ctx context.Context, allISs []*v1alpha1.MachineSet, newIS *v1alpha1.MachineSet, d *v1alpha1.MachineDeployment) error {
-	newStatus := calculateDeploymentStatus(allISs, newIS, d)
+func (dc *controller) syncMachineDeploymentStatus(ctx context.Context, allISs []*v1alpha1.MachineSet, d *v1alpha1.MachineDeployment) error {
+	newStatus := calculateDeploymentStatus(allISs, d)
 
 	if reflect.DeepEqual(d.Status, newStatus) {
 		return nil
@@ -618,26 +642,11 @@ func (dc *controller) syncMachineDeploymentStatus(ctx context.Context, allISs []
 }
 
 // calculateStatus calculates the latest status for the provided deployment by looking into the provided machine sets.
-func calculateDeploymentStatus(allISs []*v1alpha1.MachineSet, newIS *v1alpha1.MachineSet, deployment *v1alpha1.MachineDeployment) v1alpha1.MachineDeploymentStatus {
-	availableReplicas := GetAvailableReplicaCountForMachineSets(allISs)
-	totalReplicas := GetReplicaCountForMachineSets(allISs)
-	unavailableReplicas := totalReplicas - availableReplicas
-	// If unavailableReplicas is negative, then that means the Deployment has more available replicas running than
-	// desired, e.g. whenever it scales down. In such a case we should simply default unavailableReplicas to zero.
-	if unavailableReplicas < 0 {
-		unavailableReplicas = 0
-	}
-
-	status := v1alpha1.MachineDeploymentStatus{
-		// TODO: Ensure that if we start retrying status updates, we won't pick up a new Generation value.
-		ObservedGeneration:  deployment.Generation,
-		Replicas:            GetActualReplicaCountForMachineSets(allISs),
-		UpdatedReplicas:     GetActualReplicaCountForMachineSets([]*v1alpha1.MachineSet{newIS}),
-		ReadyReplicas:       GetReadyReplicaCountForMachineSets(allISs),
-		AvailableReplicas:   availableReplicas,
-		UnavailableReplicas: unavailableReplicas,
-		CollisionCount:      deployment.Status.CollisionCount,
-	}
+func calculateDeploymentStatus(allISs []*v1alpha1.MachineSet, deployment *v1alpha1.MachineDeployment) v1alpha1.MachineDeploymentStatus {
+	status := ComputeDeploymentStatusReplicas(deployment, allISs)
+	// TODO: Ensure that if we start retrying status updates, we won't pick up a new Generation value.
+	status.ObservedGeneration = deployment.Generation
+	status.CollisionCount = deployment.Status.CollisionCount
 	status.FailedMachines = []*v1alpha1.MachineSummary{}
 
 	for _, is := range allISs {
@@ -653,7 +662,7 @@ func calculateDeploymentStatus(allISs []*v1alpha1.MachineSet, newIS *v1alpha1.Ma
 	// Copy conditions one by one so we won't mutate the original object.
 	status.Conditions = append(status.Conditions, deployment.Status.Conditions...)
 
-	if availableReplicas >= (deployment.Spec.Replicas)-MaxUnavailable(*deployment) {
+	if status.AvailableReplicas >= (deployment.Spec.Replicas)-MaxUnavailable(*deployment) {
 		minAvailability := NewMachineDeploymentCondition(v1alpha1.MachineDeploymentAvailable, v1alpha1.ConditionTrue, MinimumReplicasAvailable, "Deployment has minimum availability.")
 		SetMachineDeploymentCondition(&status, *minAvailability)
 	} else {
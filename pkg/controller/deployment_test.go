@@ -19,6 +19,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
 )
 
 const ClusterAutoscalerScaleDownDisabledAnnotationKey = "cluster-autoscaler.kubernetes.io/scale-down-disabled"
@@ -1789,6 +1790,71 @@ var _ = Describe("machineDeployment", func() {
 					return nil
 				},
 			),
+			Entry("paused case: machineSet should still be scaled after the replicas of the machineDeployment are edited",
+				func(testMachineDeployment *machinev1.MachineDeployment, testMachineSet *machinev1.MachineSet) {
+					testMachineDeployment.Spec.Paused = true
+					testMachineDeployment.Spec.Replicas = 7
+					testMachineSet.Annotations[DesiredReplicasAnnotation] = "5"
+				},
+				func(_ *machinev1.MachineDeployment, testMachineSets []machinev1.MachineSet, _ []machinev1.Machine, _ *corev1.Node) error {
+					if len(testMachineSets) != 1 || testMachineSets[0].Spec.Replicas != 7 {
+						return errors.New("machineSet should have been scaled to the edited replicas")
+					}
+					return nil
+				},
+			),
+			Entry("paused case: machineSet should not be reconciled to the replicas without an edit of them",
+				func(testMachineDeployment *machinev1.MachineDeployment, testMachineSet *machinev1.MachineSet) {
+					testMachineDeployment.Spec.Paused = true
+					testMachineSet.Annotations[DesiredReplicasAnnotation] = "5"
+					testMachineSet.Spec.Replicas = 3
+					testMachineSet.Status.Replicas = 3
+					testMachineSet.Status.FullyLabeledReplicas = 3
+					testMachineSet.Status.ReadyReplicas = 3
+					testMachineSet.Status.AvailableReplicas = 3
+				},
+				func(_ *machinev1.MachineDeployment, testMachineSets []machinev1.MachineSet, _ []machinev1.Machine, _ *corev1.Node) error {
+					if len(testMachineSets) != 1 || testMachineSets[0].Spec.Replicas != 3 {
+						return errors.New("machineSet replicas should not have changed")
+					}
+					return nil
+				},
+			),
+			Entry("paused case: machineSet should be reconciled to the replicas with the scale-while-paused annotation",
+				func(testMachineDeployment *machinev1.MachineDeployment, testMachineSet *machinev1.MachineSet) {
+					testMachineDeployment.Spec.Paused = true
+					testMachineDeployment.Annotations[ScaleWhilePausedAnnotation] = "true"
+					testMachineSet.Annotations[DesiredReplicasAnnotation] = "5"
+					testMachineSet.Spec.Replicas = 3
+					testMachineSet.Status.Replicas = 3
+					testMachineSet.Status.FullyLabeledReplicas = 3
+					testMachineSet.Status.ReadyReplicas = 3
+					testMachineSet.Status.AvailableReplicas = 3
+				},
+				func(_ *machinev1.MachineDeployment, testMachineSets []machinev1.MachineSet, _ []machinev1.Machine, _ *corev1.Node) error {
+					if len(testMachineSets) != 1 || testMachineSets[0].Spec.Replicas != 5 {
+						return errors.New("machineSet should have been scaled to the replicas of the machineDeployment")
+					}
+					return nil
+				},
+			),
+			Entry("paused case: old machineSets beyond the revision history limit should still be cleaned up",
+				func(testMachineDeployment *machinev1.MachineDeployment, testMachineSet *machinev1.MachineSet) {
+					// The machineSet becomes an old one which is scaled down completely.
+					testMachineDeployment.Spec.Template.Spec.Class.Name = "MachineClass-test-new"
+					testMachineDeployment.Spec.Paused = true
+					testMachineDeployment.Spec.RevisionHistoryLimit = ptr.To[int32](0)
+					testMachineSet.Spec.Replicas = 0
+					testMachineSet.Status = machinev1.MachineSetStatus{ObservedGeneration: testMachineSet.Generation}
+					testMachine.OwnerReferences = nil
+				},
+				func(_ *machinev1.MachineDeployment, testMachineSets []machinev1.MachineSet, _ []machinev1.Machine, _ *corev1.Node) error {
+					if len(testMachineSets) != 0 {
+						return errors.New("the old machineSet should have been cleaned up")
+					}
+					return nil
+				},
+			),
 			Entry("should set MachinePriority=1 for the machines named in TriggerDeletionByMCM annotation in the MachineDeployment",
 				func(testMachineDeployment *machinev1.MachineDeployment, _ *machinev1.MachineSet) {
 					testMachineDeployment.Annotations[machineutils.TriggerDeletionByMCM] = annotations.CreateMachinesTriggeredForDeletionAnnotValue([]string{testMachine.Name})
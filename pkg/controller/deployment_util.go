@@ -111,6 +111,9 @@ const (
 	// PreferNoScheduleKey is used to identify machineSet nodes on which PreferNoSchedule taint is added on
 	// older machineSets during a rolling update
 	PreferNoScheduleKey = "deployment.machine.sapcloud.io/prefer-no-schedule"
+	// ScaleWhilePausedAnnotation is set to "true" on a paused deployment to keep reconciling the replicas of its
+	// machine sets. Without it, a paused deployment only scales its machine sets on edits of its replicas.
+	ScaleWhilePausedAnnotation = "deployment.machine.sapcloud.io/scale-while-paused"
 
 	// RollbackRevisionNotFound is not found rollback event reason
 	RollbackRevisionNotFound = "DeploymentRollbackRevisionNotFound"
@@ -648,6 +651,41 @@ func MaxSurge(deployment v1alpha1.MachineDeployment) int32 {
 	return maxSurge
 }
 
+// MaxSafeDeletions returns how many of the totalReady machines may be deleted while keeping at least minQuorum
+// machines ready. A negative minQuorum is treated as 0.
+func MaxSafeDeletions(totalReady int32, minQuorum int32) int32 {
+	if minQuorum < 0 {
+		minQuorum = 0
+	}
+	if totalReady <= minQuorum {
+		return 0
+	}
+	return totalReady - minQuorum
+}
+
+// ValidateMachineSetReplicas returns an error if the replicas of the machine set exceed the desired replicas
+// of the deployment plus its maximum surge, or are negative. It is meant as a sanity check of computed
+// replicas before they are applied to the machine set.
+func ValidateMachineSetReplicas(ms *v1alpha1.MachineSet, deployment *v1alpha1.MachineDeployment) error {
+	if ms.Spec.Replicas < 0 {
+		return fmt.Errorf("machine set %s/%s has negative replicas %d", ms.Namespace, ms.Name, ms.Spec.Replicas)
+	}
+
+	maxSurge := int32(0)
+	if IsRollingUpdate(deployment) {
+		surge, _, err := ResolveFenceposts(deployment.Spec.Strategy.RollingUpdate.MaxSurge, deployment.Spec.Strategy.RollingUpdate.MaxUnavailable, deployment.Spec.Replicas)
+		if err != nil {
+			return fmt.Errorf("unable to resolve surge of machine deployment %s/%s: %v", deployment.Namespace, deployment.Name, err)
+		}
+		maxSurge = surge
+	}
+	if maxReplicas := deployment.Spec.Replicas + maxSurge; ms.Spec.Replicas > maxReplicas {
+		return fmt.Errorf("machine set %s/%s has %d replicas, exceeding the %d desired replicas plus %d surge of machine deployment %s/%s",
+			ms.Namespace, ms.Name, ms.Spec.Replicas, deployment.Spec.Replicas, maxSurge, deployment.Namespace, deployment.Name)
+	}
+	return nil
+}
+
 // GetProportion will estimate the proportion for the provided machine set using 1. the current size
 // of the parent deployment, 2. the replica count that needs be added on the machine sets of the
 // deployment, and 3. the total replicas added in the machine sets of the deployment so far.
@@ -860,6 +898,28 @@ func EqualIgnoreHash(template1, template2 *v1alpha1.MachineTemplateSpec) bool {
 	return apiequality.Semantic.DeepEqual(t1Copy, t2Copy)
 }
 
+// IsInPlaceUpdateEligible returns true if the given machine templates differ only in fields which can be updated
+// in-place on the existing machines and nodes, in which case an in-place update is preferred over recreation.
+// The in-place safe fields are:
+//   - the labels and annotations of the machine template
+//   - the labels, annotations and taints of the node template
+//   - the machine configuration (timeouts, node conditions etc.)
+//   - the kind of the machine class
+//
+// Changes to any other field, e.g. the name of the machine class, require the machines to be recreated.
+func IsInPlaceUpdateEligible(oldTemplate, newTemplate *v1alpha1.MachineTemplateSpec) bool {
+	oldCopy := oldTemplate.DeepCopy()
+	newCopy := newTemplate.DeepCopy()
+	for _, t := range []*v1alpha1.MachineTemplateSpec{oldCopy, newCopy} {
+		t.Labels, t.Annotations = nil, nil
+		t.Spec.NodeTemplateSpec.Labels, t.Spec.NodeTemplateSpec.Annotations = nil, nil
+		t.Spec.NodeTemplateSpec.Spec.Taints = nil
+		t.Spec.MachineConfiguration = nil
+		t.Spec.Class.Kind = ""
+	}
+	return apiequality.Semantic.DeepEqual(oldCopy, newCopy)
+}
+
 // FindNewMachineSet returns the new RS this given deployment targets (the one with the same machine template).
 func FindNewMachineSet(deployment *v1alpha1.MachineDeployment, isList []*v1alpha1.MachineSet) *v1alpha1.MachineSet {
 	sort.Sort(MachineSetsByCreationTimestamp(isList))
@@ -895,6 +955,153 @@ func FindOldMachineSets(deployment *v1alpha1.MachineDeployment, isList []*v1alph
 	return requiredISs, allISs
 }
 
+// FindMachineSetByTemplateHash returns the machine set labelled with the given machine template hash, or nil if there is none.
+func FindMachineSetByTemplateHash(isList []*v1alpha1.MachineSet, templateHash string) *v1alpha1.MachineSet {
+	for _, is := range isList {
+		if is != nil && is.Labels[v1alpha1.DefaultMachineDeploymentUniqueLabelKey] == templateHash {
+			return is
+		}
+	}
+	return nil
+}
+
+// SameDeploymentDifferentRevision returns true if both machine sets are controlled by the same machine deployment
+// but are labelled with different machine template hashes, i.e. they are different revisions of the deployment.
+func SameDeploymentDifferentRevision(a, b *v1alpha1.MachineSet) bool {
+	aRef, bRef := metav1.GetControllerOf(a), metav1.GetControllerOf(b)
+	if aRef == nil || bRef == nil || aRef.Kind != "MachineDeployment" || aRef.UID != bRef.UID {
+		return false
+	}
+	return a.Labels[v1alpha1.DefaultMachineDeploymentUniqueLabelKey] != b.Labels[v1alpha1.DefaultMachineDeploymentUniqueLabelKey]
+}
+
+// ShouldReconcileScaling returns whether the machine sets of the deployment should be reconciled to its replicas,
+// apart from direct edits of its replicas which are always honored. This is the case unless the deployment is paused
+// without the ScaleWhilePausedAnnotation set to "true".
+func ShouldReconcileScaling(deployment *v1alpha1.MachineDeployment) bool {
+	if !deployment.Spec.Paused {
+		return true
+	}
+	return deployment.Annotations[ScaleWhilePausedAnnotation] == "true"
+}
+
+// MachineSetTemplateDrift partitions the machines by whether they were created from the current template of the
+// machine set, i.e. whether their machine template hash label matches the one of the machine set template. Machines
+// without the label are considered out of sync. The machines of a machine set without such a label, e.g. one not
+// controlled by a deployment, never carry it either, so the drift is unknown and all of them are considered in sync.
+func MachineSetTemplateDrift(ms *v1alpha1.MachineSet, machines []*v1alpha1.Machine) (inSync, outOfSync []*v1alpha1.Machine) {
+	currentHash, ok := ms.Spec.Template.Labels[v1alpha1.DefaultMachineDeploymentUniqueLabelKey]
+	if !ok {
+		return machines, nil
+	}
+	for _, machine := range machines {
+		if machine.Labels[v1alpha1.DefaultMachineDeploymentUniqueLabelKey] == currentHash {
+			inSync = append(inSync, machine)
+		} else {
+			outOfSync = append(outOfSync, machine)
+		}
+	}
+	return inSync, outOfSync
+}
+
+// ComputeDeploymentStatusReplicas returns the replica counts of the deployment status rolled up across the machine sets
+// controlled by the deployment. The updated replicas are the replicas of the machine set matching the hash of the
+// current deployment template. Only the replica fields of the returned status are set.
+func ComputeDeploymentStatusReplicas(deployment *v1alpha1.MachineDeployment, machineSets []*v1alpha1.MachineSet) v1alpha1.MachineDeploymentStatus {
+	ownedISs := filterMachineSetsControlledBy(deployment, machineSets)
+
+	availableReplicas := GetAvailableReplicaCountForMachineSets(ownedISs)
+	// If unavailableReplicas is negative, the deployment has more available replicas than desired, e.g. while scaling down.
+	unavailableReplicas := GetReplicaCountForMachineSets(ownedISs) - availableReplicas
+	if unavailableReplicas < 0 {
+		unavailableReplicas = 0
+	}
+
+	updatedIS := findUpdatedMachineSet(deployment, ownedISs)
+
+	return v1alpha1.MachineDeploymentStatus{
+		Replicas:            GetActualReplicaCountForMachineSets(ownedISs),
+		UpdatedReplicas:     GetActualReplicaCountForMachineSets([]*v1alpha1.MachineSet{updatedIS}),
+		ReadyReplicas:       GetReadyReplicaCountForMachineSets(ownedISs),
+		AvailableReplicas:   availableReplicas,
+		UnavailableReplicas: unavailableReplicas,
+	}
+}
+
+// RolloutProgressPercent returns the progress of the rollout of the deployment in percent, i.e. the available
+// replicas of the machine set matching the hash of the current deployment template over the desired replicas,
+// clamped to 0-100. A deployment without desired replicas is considered completely rolled out.
+func RolloutProgressPercent(deployment *v1alpha1.MachineDeployment, machineSets []*v1alpha1.MachineSet) int {
+	if deployment.Spec.Replicas <= 0 {
+		return 100
+	}
+	updatedIS := findUpdatedMachineSet(deployment, filterMachineSetsControlledBy(deployment, machineSets))
+	updatedAvailable := GetAvailableReplicaCountForMachineSets([]*v1alpha1.MachineSet{updatedIS})
+
+	percent := int(int64(updatedAvailable) * 100 / int64(deployment.Spec.Replicas))
+	if percent < 0 {
+		return 0
+	} else if percent > 100 {
+		return 100
+	}
+	return percent
+}
+
+// SelectMachinesForConsolidation returns the machines to delete across the given machine sets so that the total number of
+// machines shrinks to targetTotal. Machine sets of the oldest revisions are retired first, and the machines within a machine
+// set are picked in ActiveMachines order. machinesByMS maps the name of each machine set to its machines.
+// Machines protected from deletion are never picked.
+func SelectMachinesForConsolidation(machineSets []*v1alpha1.MachineSet, machinesByMS map[string][]*v1alpha1.Machine, targetTotal int32) []*v1alpha1.Machine {
+	total := 0
+	for _, ms := range machineSets {
+		total += len(machinesByMS[ms.Name])
+	}
+	excess := total - int(targetTotal)
+	if excess <= 0 {
+		return nil
+	}
+
+	sortedMachineSets := make([]*v1alpha1.MachineSet, len(machineSets))
+	copy(sortedMachineSets, machineSets)
+	sort.Sort(MachineSetsByRevision(sortedMachineSets))
+
+	var machinesToDelete []*v1alpha1.Machine
+	for _, ms := range sortedMachineSets {
+		if excess == 0 {
+			break
+		}
+		candidates := make([]*v1alpha1.Machine, 0, len(machinesByMS[ms.Name]))
+		for _, machine := range machinesByMS[ms.Name] {
+			if !IsDeletionProtected(machine) {
+				candidates = append(candidates, machine)
+			}
+		}
+		sort.Sort(ActiveMachines(candidates))
+
+		count := integer.IntMin(excess, len(candidates))
+		machinesToDelete = append(machinesToDelete, candidates[:count]...)
+		excess -= count
+	}
+	return machinesToDelete
+}
+
+// filterMachineSetsControlledBy returns the machine sets controlled by the deployment.
+func filterMachineSetsControlledBy(deployment *v1alpha1.MachineDeployment, machineSets []*v1alpha1.MachineSet) []*v1alpha1.MachineSet {
+	var ownedISs []*v1alpha1.MachineSet
+	for _, is := range machineSets {
+		if is != nil && metav1.IsControlledBy(is, deployment) {
+			ownedISs = append(ownedISs, is)
+		}
+	}
+	return ownedISs
+}
+
+// findUpdatedMachineSet returns the machine set matching the hash of the current deployment template, or nil.
+func findUpdatedMachineSet(deployment *v1alpha1.MachineDeployment, machineSets []*v1alpha1.MachineSet) *v1alpha1.MachineSet {
+	templateHash := fmt.Sprintf("%d", ComputeHash(&deployment.Spec.Template, deployment.Status.CollisionCount))
+	return FindMachineSetByTemplateHash(machineSets, templateHash)
+}
+
 // WaitForMachineSetUpdated polls the machine set until it is updated.
 func WaitForMachineSetUpdated(c v1alpha1listers.MachineSetLister, desiredGeneration int64, namespace, name string) error {
 	return wait.PollImmediate(1*time.Second, 1*time.Minute, func() (bool, error) {
@@ -945,6 +1152,54 @@ func LabelMachinesWithHash(ctx context.Context, machineList *v1alpha1.MachineLis
 	return nil
 }
 
+// FindMachinesMissingTemplateHash returns the machines which lack the machine template hash label,
+// e.g. as they were created before the label was introduced.
+func FindMachinesMissingTemplateHash(machines []*v1alpha1.Machine) []*v1alpha1.Machine {
+	var missing []*v1alpha1.Machine
+	for _, machine := range machines {
+		if machine.Labels[v1alpha1.DefaultMachineDeploymentUniqueLabelKey] == "" {
+			missing = append(missing, machine)
+		}
+	}
+	return missing
+}
+
+// RepairMissingTemplateHashes labels the machines lacking the machine template hash label with the hash of
+// the machine set controlling them, so that they are accounted for in rollouts again. Machines which already
+// carry a hash label, even a stale one, as well as machines without a labelled controlling machine set are left untouched.
+func RepairMissingTemplateHashes(ctx context.Context, c v1alpha1client.MachineV1alpha1Interface, machineLister v1alpha1listers.MachineLister, machines []*v1alpha1.Machine, machineSets []*v1alpha1.MachineSet) error {
+	for _, machine := range FindMachinesMissingTemplateHash(machines) {
+		controllerRef := metav1.GetControllerOf(machine)
+		if controllerRef == nil {
+			continue
+		}
+		var hash string
+		for _, is := range machineSets {
+			if is.UID == controllerRef.UID {
+				hash = is.Labels[v1alpha1.DefaultMachineDeploymentUniqueLabelKey]
+				break
+			}
+		}
+		if hash == "" {
+			continue
+		}
+		_, err := UpdateMachineWithRetries(ctx, c.Machines(machine.Namespace), machineLister, machine.Namespace, machine.Name,
+			func(machineToUpdate *v1alpha1.Machine) error {
+				// Precondition: the machine doesn't carry a hash label yet.
+				if machineToUpdate.Labels[v1alpha1.DefaultMachineDeploymentUniqueLabelKey] != "" {
+					return errors.ErrPreconditionViolated
+				}
+				machineToUpdate.Labels = labelsutil.AddLabel(machineToUpdate.Labels, v1alpha1.DefaultMachineDeploymentUniqueLabelKey, hash)
+				return nil
+			})
+		if err != nil {
+			return fmt.Errorf("error in repairing template hash label %s of machine %q: %v", hash, machine.Name, err)
+		}
+		klog.V(4).Infof("Repaired missing template hash label of machine %s/%s with hash %s.", machine.Namespace, machine.Name, hash)
+	}
+	return nil
+}
+
 // SetFromMachineSetTemplate sets the desired MachineTemplateSpec from a machine set template to the given deployment.
 func SetFromMachineSetTemplate(deployment *v1alpha1.MachineDeployment, template v1alpha1.MachineTemplateSpec) *v1alpha1.MachineDeployment {
 	deployment.Spec.Template.ObjectMeta = template.ObjectMeta
@@ -1110,6 +1365,86 @@ func NewISNewReplicas(deployment *v1alpha1.MachineDeployment, allISs []*v1alpha1
 	}
 }
 
+// DesiredReplicasForMachineSet returns the number of replicas the given machine set should be scaled to
+// during the current step of the deployment's rollout. The new machine set (the one matching the
+// deployment template) is scaled up within the bounds of maxSurge, while old machine sets are scaled
+// down, oldest first, without dropping below the minimum availability allowed by maxUnavailable.
+// For the Recreate strategy, old machine sets are scaled to zero and the new machine set is scaled
+// to the desired replicas only once all old machines are gone.
+func DesiredReplicasForMachineSet(deployment *v1alpha1.MachineDeployment, ms *v1alpha1.MachineSet, allMachineSets []*v1alpha1.MachineSet) (int32, error) {
+	newMS := FindNewMachineSet(deployment, allMachineSets)
+	isNew := newMS != nil && newMS.UID == ms.UID && newMS.Name == ms.Name
+
+	switch deployment.Spec.Strategy.Type {
+	case v1alpha1.RecreateMachineDeploymentStrategyType:
+		if !isNew {
+			return 0, nil
+		}
+		for _, is := range allMachineSets {
+			if is.UID == newMS.UID && is.Name == newMS.Name {
+				continue
+			}
+			if is.Status.Replicas > 0 {
+				// Wait for the old machines to be deleted before scaling up.
+				return ms.Spec.Replicas, nil
+			}
+		}
+		return deployment.Spec.Replicas, nil
+	case v1alpha1.RollingUpdateMachineDeploymentStrategyType:
+		if deployment.Spec.Strategy.RollingUpdate == nil {
+			return 0, fmt.Errorf("machine deployment %s has no rolling update configuration", deployment.Name)
+		}
+		maxSurge, maxUnavailable, err := ResolveFenceposts(deployment.Spec.Strategy.RollingUpdate.MaxSurge, deployment.Spec.Strategy.RollingUpdate.MaxUnavailable, deployment.Spec.Replicas)
+		if err != nil {
+			return 0, err
+		}
+		totalReplicas := GetReplicaCountForMachineSets(allMachineSets)
+
+		if isNew {
+			maxTotalReplicas := deployment.Spec.Replicas + maxSurge
+			if ms.Spec.Replicas >= deployment.Spec.Replicas {
+				// Do not exceed the number of desired replicas.
+				return deployment.Spec.Replicas, nil
+			}
+			if totalReplicas >= maxTotalReplicas {
+				// Cannot scale up.
+				return ms.Spec.Replicas, nil
+			}
+			scaleUpCount := integer.Int32Min(maxTotalReplicas-totalReplicas, deployment.Spec.Replicas-ms.Spec.Replicas)
+			return ms.Spec.Replicas + scaleUpCount, nil
+		}
+
+		minAvailable := deployment.Spec.Replicas - maxUnavailable
+		if minAvailable < 0 {
+			minAvailable = 0
+		}
+		var newMSUnavailable int32
+		if newMS != nil && newMS.Spec.Replicas > newMS.Status.AvailableReplicas {
+			newMSUnavailable = newMS.Spec.Replicas - newMS.Status.AvailableReplicas
+		}
+		maxScaledDown := totalReplicas - minAvailable - newMSUnavailable
+		if maxScaledDown <= 0 {
+			return ms.Spec.Replicas, nil
+		}
+
+		_, oldMachineSets := FindOldMachineSets(deployment, allMachineSets)
+		sort.Sort(MachineSetsByCreationTimestamp(oldMachineSets))
+		for _, is := range oldMachineSets {
+			scaleDownCount := integer.Int32Min(is.Spec.Replicas, maxScaledDown)
+			if is.UID == ms.UID && is.Name == ms.Name {
+				return is.Spec.Replicas - scaleDownCount, nil
+			}
+			maxScaledDown -= scaleDownCount
+			if maxScaledDown <= 0 {
+				break
+			}
+		}
+		return ms.Spec.Replicas, nil
+	default:
+		return 0, fmt.Errorf("machine deployment type %v isn't supported", deployment.Spec.Strategy.Type)
+	}
+}
+
 // IsSaturated checks if the new machine set is saturated by comparing its size with its deployment size.
 // Both the deployment and the machine set have to believe this machine set can own all of the desired
 // replicas in the deployment and the annotation helps in achieving that. All machines of the MachineSet
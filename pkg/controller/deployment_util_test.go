@@ -5,12 +5,20 @@
 package controller
 
 import (
+	"context"
+	"fmt"
+	"time"
+
 	machinev1 "github.com/gardener/machine-controller-manager/pkg/apis/machine/v1alpha1"
+	labelsutil "github.com/gardener/machine-controller-manager/pkg/util/labels"
+	"github.com/gardener/machine-controller-manager/pkg/util/provider/machineutils"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
 	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
@@ -80,6 +88,24 @@ var _ = Describe("deployment_util", func() {
 		}
 	})
 
+	newStatusMachineSet := func(name, templateHash string, owned bool, specReplicas int32, status machinev1.MachineSetStatus) *machinev1.MachineSet {
+		ms := &machinev1.MachineSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: testNamespace,
+				Labels: map[string]string{
+					machinev1.DefaultMachineDeploymentUniqueLabelKey: templateHash,
+				},
+			},
+			Spec:   machinev1.MachineSetSpec{Replicas: specReplicas},
+			Status: status,
+		}
+		if owned {
+			ms.OwnerReferences = []metav1.OwnerReference{*metav1.NewControllerRef(machineDeployment, machinev1.SchemeGroupVersion.WithKind("MachineDeployment"))}
+		}
+		return ms
+	}
+
 	Describe("#SetNewMachineSetNodeTemplate", func() {
 		It("when nodeTemplate is updated in MachineSet", func() {
 			stop := make(chan struct{})
@@ -324,4 +350,589 @@ var _ = Describe("deployment_util", func() {
 		})
 
 	})
+
+	Describe("#DesiredReplicasForMachineSet", func() {
+		var (
+			oldTemplate *machinev1.MachineTemplateSpec
+		)
+
+		newTestMachineSet := func(name string, template *machinev1.MachineTemplateSpec, age time.Duration, replicas, available int32) *machinev1.MachineSet {
+			return &machinev1.MachineSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              name,
+					Namespace:         testNamespace,
+					UID:               types.UID(name),
+					CreationTimestamp: metav1.NewTime(time.Now().Add(-age)),
+				},
+				Spec: machinev1.MachineSetSpec{
+					Replicas: replicas,
+					Template: *template.DeepCopy(),
+				},
+				Status: machinev1.MachineSetStatus{
+					Replicas:          replicas,
+					AvailableReplicas: available,
+				},
+			}
+		}
+
+		BeforeEach(func() {
+			machineDeployment.Spec.Replicas = 3
+			oldTemplate = machineDeployment.Spec.Template.DeepCopy()
+			oldTemplate.Spec.Class.Name = "old-machine-class"
+		})
+
+		Context("RollingUpdate strategy", func() {
+			It("should scale up the new machine set within maxSurge and scale down the old one within maxUnavailable", func() {
+				oldMS := newTestMachineSet("old", oldTemplate, time.Hour, 3, 3)
+				newMS := newTestMachineSet("new", &machineDeployment.Spec.Template, time.Minute, 0, 0)
+				all := []*machinev1.MachineSet{oldMS, newMS}
+
+				replicas, err := DesiredReplicasForMachineSet(machineDeployment, newMS, all)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(replicas).To(Equal(int32(1)))
+
+				replicas, err = DesiredReplicasForMachineSet(machineDeployment, oldMS, all)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(replicas).To(Equal(int32(2)))
+			})
+
+			It("should not scale up beyond maxSurge and account for unavailable new machines while scaling down", func() {
+				oldMS := newTestMachineSet("old", oldTemplate, time.Hour, 3, 3)
+				newMS := newTestMachineSet("new", &machineDeployment.Spec.Template, time.Minute, 1, 0)
+				all := []*machinev1.MachineSet{oldMS, newMS}
+
+				replicas, err := DesiredReplicasForMachineSet(machineDeployment, newMS, all)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(replicas).To(Equal(int32(1)))
+
+				replicas, err = DesiredReplicasForMachineSet(machineDeployment, oldMS, all)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(replicas).To(Equal(int32(2)))
+			})
+
+			It("should keep the old machine set when no machine may become unavailable", func() {
+				oldMS := newTestMachineSet("old", oldTemplate, time.Hour, 3, 3)
+				newMS := newTestMachineSet("new", &machineDeployment.Spec.Template, time.Minute, 1, 0)
+				all := []*machinev1.MachineSet{oldMS, newMS}
+				machineDeployment.Spec.Strategy.RollingUpdate.MaxUnavailable = &intstr.IntOrString{IntVal: 0}
+
+				replicas, err := DesiredReplicasForMachineSet(machineDeployment, oldMS, all)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(replicas).To(Equal(int32(3)))
+			})
+
+			It("should allow one unavailable machine when both maxSurge and maxUnavailable are zero", func() {
+				oldMS := newTestMachineSet("old", oldTemplate, time.Hour, 3, 3)
+				newMS := newTestMachineSet("new", &machineDeployment.Spec.Template, time.Minute, 0, 0)
+				all := []*machinev1.MachineSet{oldMS, newMS}
+				machineDeployment.Spec.Strategy.RollingUpdate.MaxSurge = &intstr.IntOrString{IntVal: 0}
+				machineDeployment.Spec.Strategy.RollingUpdate.MaxUnavailable = &intstr.IntOrString{IntVal: 0}
+
+				replicas, err := DesiredReplicasForMachineSet(machineDeployment, newMS, all)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(replicas).To(Equal(int32(0)))
+
+				replicas, err = DesiredReplicasForMachineSet(machineDeployment, oldMS, all)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(replicas).To(Equal(int32(2)))
+			})
+
+			It("should scale down the oldest machine set first", func() {
+				oldestMS := newTestMachineSet("oldest", oldTemplate, 2*time.Hour, 2, 2)
+				olderMS := newTestMachineSet("older", oldTemplate, time.Hour, 1, 1)
+				newMS := newTestMachineSet("new", &machineDeployment.Spec.Template, time.Minute, 0, 0)
+				all := []*machinev1.MachineSet{newMS, olderMS, oldestMS}
+				machineDeployment.Spec.Strategy.RollingUpdate.MaxSurge = &intstr.IntOrString{IntVal: 0}
+
+				replicas, err := DesiredReplicasForMachineSet(machineDeployment, oldestMS, all)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(replicas).To(Equal(int32(1)))
+
+				replicas, err = DesiredReplicasForMachineSet(machineDeployment, olderMS, all)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(replicas).To(Equal(int32(1)))
+			})
+
+			It("should resolve percentages against the deployment replicas", func() {
+				machineDeployment.Spec.Replicas = 10
+				oldMS := newTestMachineSet("old", oldTemplate, time.Hour, 10, 10)
+				newMS := newTestMachineSet("new", &machineDeployment.Spec.Template, time.Minute, 0, 0)
+				all := []*machinev1.MachineSet{oldMS, newMS}
+				maxSurge := intstr.FromString("25%")
+				maxUnavailable := intstr.FromString("25%")
+				machineDeployment.Spec.Strategy.RollingUpdate.MaxSurge = &maxSurge
+				machineDeployment.Spec.Strategy.RollingUpdate.MaxUnavailable = &maxUnavailable
+
+				replicas, err := DesiredReplicasForMachineSet(machineDeployment, newMS, all)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(replicas).To(Equal(int32(3)))
+
+				replicas, err = DesiredReplicasForMachineSet(machineDeployment, oldMS, all)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(replicas).To(Equal(int32(8)))
+			})
+
+			It("should not exceed the deployment replicas for a saturated new machine set", func() {
+				newMS := newTestMachineSet("new", &machineDeployment.Spec.Template, time.Minute, 4, 4)
+
+				replicas, err := DesiredReplicasForMachineSet(machineDeployment, newMS, []*machinev1.MachineSet{newMS})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(replicas).To(Equal(int32(3)))
+			})
+
+			It("should return an error if the rolling update configuration is missing", func() {
+				newMS := newTestMachineSet("new", &machineDeployment.Spec.Template, time.Minute, 0, 0)
+				machineDeployment.Spec.Strategy.RollingUpdate = nil
+
+				_, err := DesiredReplicasForMachineSet(machineDeployment, newMS, []*machinev1.MachineSet{newMS})
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		Context("Recreate strategy", func() {
+			BeforeEach(func() {
+				machineDeployment.Spec.Strategy = machinev1.MachineDeploymentStrategy{
+					Type: machinev1.RecreateMachineDeploymentStrategyType,
+				}
+			})
+
+			It("should scale down old machine sets and hold the new one while old machines exist", func() {
+				oldMS := newTestMachineSet("old", oldTemplate, time.Hour, 3, 3)
+				newMS := newTestMachineSet("new", &machineDeployment.Spec.Template, time.Minute, 0, 0)
+				all := []*machinev1.MachineSet{oldMS, newMS}
+
+				replicas, err := DesiredReplicasForMachineSet(machineDeployment, oldMS, all)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(replicas).To(Equal(int32(0)))
+
+				replicas, err = DesiredReplicasForMachineSet(machineDeployment, newMS, all)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(replicas).To(Equal(int32(0)))
+			})
+
+			It("should scale up the new machine set once all old machines are gone", func() {
+				oldMS := newTestMachineSet("old", oldTemplate, time.Hour, 0, 0)
+				newMS := newTestMachineSet("new", &machineDeployment.Spec.Template, time.Minute, 0, 0)
+				all := []*machinev1.MachineSet{oldMS, newMS}
+
+				replicas, err := DesiredReplicasForMachineSet(machineDeployment, newMS, all)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(replicas).To(Equal(int32(3)))
+			})
+		})
+
+		It("should return an error for an unsupported strategy type", func() {
+			newMS := newTestMachineSet("new", &machineDeployment.Spec.Template, time.Minute, 0, 0)
+			machineDeployment.Spec.Strategy.Type = "Unknown"
+
+			_, err := DesiredReplicasForMachineSet(machineDeployment, newMS, []*machinev1.MachineSet{newMS})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("#ComputeDeploymentStatusReplicas", func() {
+		BeforeEach(func() {
+			machineDeployment.UID = "1234"
+			machineDeployment.Spec.Replicas = 4
+		})
+
+		It("should roll up the replicas of the owned machine sets", func() {
+			currentHash := fmt.Sprintf("%d", ComputeHash(&machineDeployment.Spec.Template, machineDeployment.Status.CollisionCount))
+			machineSets := []*machinev1.MachineSet{
+				newStatusMachineSet("old", "1111", true, 2, machinev1.MachineSetStatus{Replicas: 3, ReadyReplicas: 3, AvailableReplicas: 2}),
+				newStatusMachineSet("new", currentHash, true, 2, machinev1.MachineSetStatus{Replicas: 2, ReadyReplicas: 1, AvailableReplicas: 1}),
+				newStatusMachineSet("foreign", currentHash, false, 5, machinev1.MachineSetStatus{Replicas: 5, ReadyReplicas: 5, AvailableReplicas: 5}),
+			}
+
+			status := ComputeDeploymentStatusReplicas(machineDeployment, machineSets)
+			Expect(status).To(Equal(machinev1.MachineDeploymentStatus{
+				Replicas:            5,
+				UpdatedReplicas:     2,
+				ReadyReplicas:       4,
+				AvailableReplicas:   3,
+				UnavailableReplicas: 1,
+			}))
+		})
+
+		It("should report no updated replicas if no machine set matches the current template", func() {
+			machineSets := []*machinev1.MachineSet{
+				newStatusMachineSet("old", "1111", true, 1, machinev1.MachineSetStatus{Replicas: 3, ReadyReplicas: 3, AvailableReplicas: 3}),
+			}
+
+			status := ComputeDeploymentStatusReplicas(machineDeployment, machineSets)
+			Expect(status.Replicas).To(Equal(int32(3)))
+			Expect(status.UpdatedReplicas).To(Equal(int32(0)))
+			Expect(status.UnavailableReplicas).To(Equal(int32(0)))
+		})
+	})
+
+	Describe("#RolloutProgressPercent", func() {
+		BeforeEach(func() {
+			machineDeployment.UID = "1234"
+			machineDeployment.Spec.Replicas = 4
+		})
+
+		It("should return the available replicas of the updated machine set over the desired replicas", func() {
+			currentHash := fmt.Sprintf("%d", ComputeHash(&machineDeployment.Spec.Template, machineDeployment.Status.CollisionCount))
+			machineSets := []*machinev1.MachineSet{
+				newStatusMachineSet("old", "1111", true, 2, machinev1.MachineSetStatus{AvailableReplicas: 2}),
+				newStatusMachineSet("new", currentHash, true, 2, machinev1.MachineSetStatus{AvailableReplicas: 1}),
+				newStatusMachineSet("foreign", currentHash, false, 5, machinev1.MachineSetStatus{AvailableReplicas: 5}),
+			}
+
+			Expect(RolloutProgressPercent(machineDeployment, machineSets)).To(Equal(25))
+		})
+
+		It("should return 0 if no machine set matches the current template", func() {
+			machineSets := []*machinev1.MachineSet{
+				newStatusMachineSet("old", "1111", true, 4, machinev1.MachineSetStatus{AvailableReplicas: 4}),
+			}
+
+			Expect(RolloutProgressPercent(machineDeployment, machineSets)).To(Equal(0))
+		})
+
+		It("should clamp the progress to 100", func() {
+			currentHash := fmt.Sprintf("%d", ComputeHash(&machineDeployment.Spec.Template, machineDeployment.Status.CollisionCount))
+			machineSets := []*machinev1.MachineSet{
+				newStatusMachineSet("new", currentHash, true, 6, machinev1.MachineSetStatus{AvailableReplicas: 6}),
+			}
+
+			Expect(RolloutProgressPercent(machineDeployment, machineSets)).To(Equal(100))
+		})
+
+		It("should return 100 if no replicas are desired", func() {
+			machineDeployment.Spec.Replicas = 0
+
+			Expect(RolloutProgressPercent(machineDeployment, nil)).To(Equal(100))
+		})
+	})
+
+	Describe("#FindMachineSetByTemplateHash", func() {
+		It("should return the machine set with the given template hash", func() {
+			ms1 := &machinev1.MachineSet{ObjectMeta: metav1.ObjectMeta{Name: "ms-1", Labels: map[string]string{machinev1.DefaultMachineDeploymentUniqueLabelKey: "1"}}}
+			ms2 := &machinev1.MachineSet{ObjectMeta: metav1.ObjectMeta{Name: "ms-2", Labels: map[string]string{machinev1.DefaultMachineDeploymentUniqueLabelKey: "2"}}}
+
+			Expect(FindMachineSetByTemplateHash([]*machinev1.MachineSet{ms1, ms2}, "2")).To(Equal(ms2))
+			Expect(FindMachineSetByTemplateHash([]*machinev1.MachineSet{ms1, ms2}, "3")).To(BeNil())
+		})
+	})
+
+	Describe("#SameDeploymentDifferentRevision", func() {
+		otherDeployment := &machinev1.MachineDeployment{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: testNamespace, UID: "other-uid"}}
+		newRevision := func(owner *machinev1.MachineDeployment, hash string) *machinev1.MachineSet {
+			ms := &machinev1.MachineSet{ObjectMeta: metav1.ObjectMeta{
+				Name:      "ms-" + hash,
+				Namespace: testNamespace,
+				Labels:    map[string]string{machinev1.DefaultMachineDeploymentUniqueLabelKey: hash},
+			}}
+			if owner != nil {
+				ms.OwnerReferences = []metav1.OwnerReference{*metav1.NewControllerRef(owner, machinev1.SchemeGroupVersion.WithKind("MachineDeployment"))}
+			}
+			return ms
+		}
+
+		It("should return true for different revisions of the same deployment", func() {
+			Expect(SameDeploymentDifferentRevision(newRevision(machineDeployment, "1"), newRevision(machineDeployment, "2"))).To(BeTrue())
+		})
+
+		It("should return false for the same revision", func() {
+			Expect(SameDeploymentDifferentRevision(newRevision(machineDeployment, "1"), newRevision(machineDeployment, "1"))).To(BeFalse())
+		})
+
+		It("should return false for machine sets of different deployments", func() {
+			Expect(SameDeploymentDifferentRevision(newRevision(machineDeployment, "1"), newRevision(otherDeployment, "2"))).To(BeFalse())
+		})
+
+		It("should return false for machine sets without a controller", func() {
+			Expect(SameDeploymentDifferentRevision(newRevision(nil, "1"), newRevision(machineDeployment, "2"))).To(BeFalse())
+			Expect(SameDeploymentDifferentRevision(newRevision(nil, "1"), newRevision(nil, "2"))).To(BeFalse())
+		})
+	})
+
+	Describe("#ShouldReconcileScaling", func() {
+		newPausedDeployment := func(paused bool, annotations map[string]string) *machinev1.MachineDeployment {
+			return &machinev1.MachineDeployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "machinedeployment-0", Namespace: testNamespace, Annotations: annotations},
+				Spec:       machinev1.MachineDeploymentSpec{Paused: paused},
+			}
+		}
+
+		DescribeTable("##table",
+			func(deployment *machinev1.MachineDeployment, expected bool) {
+				Expect(ShouldReconcileScaling(deployment)).To(Equal(expected))
+			},
+			Entry("should scale a deployment which is not paused", newPausedDeployment(false, nil), true),
+			Entry("should not scale a paused deployment without the scale annotation", newPausedDeployment(true, nil), false),
+			Entry("should scale a paused deployment with the scale annotation", newPausedDeployment(true, map[string]string{ScaleWhilePausedAnnotation: "true"}), true),
+			Entry("should not scale a paused deployment with a disabled scale annotation", newPausedDeployment(true, map[string]string{ScaleWhilePausedAnnotation: "false"}), false),
+		)
+	})
+
+	Describe("#MachineSetTemplateDrift", func() {
+		newHashedMachine := func(name, hash string) *machinev1.Machine {
+			machine := &machinev1.Machine{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace}}
+			if hash != "" {
+				machine.Labels = map[string]string{machinev1.DefaultMachineDeploymentUniqueLabelKey: hash}
+			}
+			return machine
+		}
+
+		It("should partition a mixed fleet by the template hash of the machine set", func() {
+			// The template hashes are computed as by the deployment controller when creating the machine sets.
+			oldTemplate := machineDeployment.Spec.Template.DeepCopy()
+			oldHash := fmt.Sprintf("%d", ComputeHash(oldTemplate, nil))
+			machineDeployment.Spec.Template.Spec.Class.Name = "test-machine-class-new"
+			currentHash := fmt.Sprintf("%d", ComputeHash(&machineDeployment.Spec.Template, nil))
+
+			template := *machineDeployment.Spec.Template.DeepCopy()
+			template.Labels = labelsutil.CloneAndAddLabel(template.Labels, machinev1.DefaultMachineDeploymentUniqueLabelKey, currentHash)
+			ms := &machinev1.MachineSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "ms-2", Namespace: testNamespace},
+				Spec:       machinev1.MachineSetSpec{Template: template},
+			}
+			// Machines are labelled with the labels of the template they were created from.
+			current := &machinev1.Machine{ObjectMeta: metav1.ObjectMeta{Name: "machine-0", Namespace: testNamespace, Labels: template.Labels}}
+			old := newHashedMachine("machine-1", oldHash)
+			unlabelled := newHashedMachine("machine-2", "")
+
+			inSync, outOfSync := MachineSetTemplateDrift(ms, []*machinev1.Machine{current, old, unlabelled})
+			Expect(inSync).To(ConsistOf(current))
+			Expect(outOfSync).To(ConsistOf(old, unlabelled))
+		})
+
+		It("should consider all machines of a machine set without the label in sync", func() {
+			ms := &machinev1.MachineSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "ms", Namespace: testNamespace},
+				Spec: machinev1.MachineSetSpec{
+					Template: machinev1.MachineTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"key": "value"}},
+					},
+				},
+			}
+			// Machines are labelled with the labels of the template they were created from.
+			machine0 := &machinev1.Machine{ObjectMeta: metav1.ObjectMeta{Name: "machine-0", Namespace: testNamespace, Labels: ms.Spec.Template.Labels}}
+			machine1 := &machinev1.Machine{ObjectMeta: metav1.ObjectMeta{Name: "machine-1", Namespace: testNamespace, Labels: ms.Spec.Template.Labels}}
+
+			inSync, outOfSync := MachineSetTemplateDrift(ms, []*machinev1.Machine{machine0, machine1})
+			Expect(inSync).To(ConsistOf(machine0, machine1))
+			Expect(outOfSync).To(BeEmpty())
+		})
+	})
+
+	Describe("#IsInPlaceUpdateEligible", func() {
+		DescribeTable("should classify the template change",
+			func(mutate func(t *machinev1.MachineTemplateSpec), expected bool) {
+				oldTemplate := machineDeployment.Spec.Template.DeepCopy()
+				newTemplate := oldTemplate.DeepCopy()
+				mutate(newTemplate)
+				Expect(IsInPlaceUpdateEligible(oldTemplate, newTemplate)).To(Equal(expected))
+			},
+			Entry("no change", func(_ *machinev1.MachineTemplateSpec) {}, true),
+			Entry("label only change", func(t *machinev1.MachineTemplateSpec) {
+				t.Labels["new-label"] = "new-value"
+			}, true),
+			Entry("annotation change", func(t *machinev1.MachineTemplateSpec) {
+				t.Annotations = map[string]string{"anno": "anno"}
+			}, true),
+			Entry("node template labels, annotations and taints change", func(t *machinev1.MachineTemplateSpec) {
+				t.Spec.NodeTemplateSpec.Labels["key2"] = "value2"
+				t.Spec.NodeTemplateSpec.Annotations["anno2"] = "anno2"
+				t.Spec.NodeTemplateSpec.Spec.Taints = nil
+			}, true),
+			Entry("machine configuration change", func(t *machinev1.MachineTemplateSpec) {
+				t.Spec.MachineConfiguration = &machinev1.MachineConfiguration{MachineDrainTimeout: &metav1.Duration{Duration: time.Minute}}
+			}, true),
+			Entry("class kind change", func(t *machinev1.MachineTemplateSpec) {
+				t.Spec.Class.Kind = "MachineClass"
+			}, true),
+			Entry("class name change", func(t *machinev1.MachineTemplateSpec) {
+				t.Spec.Class.Name = "new-machine-class"
+			}, false),
+			Entry("provider ID change", func(t *machinev1.MachineTemplateSpec) {
+				t.Spec.ProviderID = "new-provider-id"
+			}, false),
+			Entry("node spec change other than taints", func(t *machinev1.MachineTemplateSpec) {
+				t.Spec.NodeTemplateSpec.Spec.PodCIDR = "10.0.0.0/24"
+			}, false),
+			Entry("label and class name change", func(t *machinev1.MachineTemplateSpec) {
+				t.Labels["new-label"] = "new-value"
+				t.Spec.Class.Name = "new-machine-class"
+			}, false),
+		)
+	})
+
+	Describe("#RepairMissingTemplateHashes", func() {
+		var (
+			stop       chan struct{}
+			machineSet *machinev1.MachineSet
+		)
+
+		newHashedMachine := func(name, hash string, owner *machinev1.MachineSet) *machinev1.Machine {
+			machine := &machinev1.Machine{ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: testNamespace,
+				Labels:    map[string]string{"key": "value"},
+			}}
+			if hash != "" {
+				machine.Labels[machinev1.DefaultMachineDeploymentUniqueLabelKey] = hash
+			}
+			if owner != nil {
+				machine.OwnerReferences = []metav1.OwnerReference{*metav1.NewControllerRef(owner, machinev1.SchemeGroupVersion.WithKind("MachineSet"))}
+			}
+			return machine
+		}
+
+		BeforeEach(func() {
+			stop = make(chan struct{})
+			machineSet = &machinev1.MachineSet{ObjectMeta: metav1.ObjectMeta{
+				Name:      "machineset-0",
+				Namespace: testNamespace,
+				UID:       "machineset-0-uid",
+				Labels:    map[string]string{machinev1.DefaultMachineDeploymentUniqueLabelKey: "1234"},
+			}}
+		})
+
+		AfterEach(func() {
+			close(stop)
+		})
+
+		It("should find the machines without a template hash", func() {
+			missing := newHashedMachine("machine-0", "", machineSet)
+			hashed := newHashedMachine("machine-1", "1234", machineSet)
+			Expect(FindMachinesMissingTemplateHash([]*machinev1.Machine{missing, hashed})).To(ConsistOf(missing))
+		})
+
+		It("should stamp the hash of the owning machine set only on machines missing it", func() {
+			missing := newHashedMachine("machine-0", "", machineSet)
+			stale := newHashedMachine("machine-1", "stale", machineSet)
+			orphan := newHashedMachine("machine-2", "", nil)
+			objects := []runtime.Object{missing, stale, orphan, machineSet}
+
+			c, trackers := createController(stop, testNamespace, objects, nil, nil)
+			defer trackers.Stop()
+			waitForCacheSync(stop, c)
+
+			machines := []*machinev1.Machine{missing, stale, orphan}
+			Expect(RepairMissingTemplateHashes(context.TODO(), c.controlMachineClient, c.machineLister, machines, []*machinev1.MachineSet{machineSet})).To(Succeed())
+
+			expectedHashes := map[string]string{"machine-0": "1234", "machine-1": "stale", "machine-2": ""}
+			for name, hash := range expectedHashes {
+				actual, err := c.controlMachineClient.Machines(testNamespace).Get(context.TODO(), name, metav1.GetOptions{})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(actual.Labels[machinev1.DefaultMachineDeploymentUniqueLabelKey]).To(Equal(hash))
+			}
+		})
+	})
+
+	Describe("#MaxSafeDeletions", func() {
+		DescribeTable("##table",
+			func(totalReady, minQuorum, expected int32) {
+				Expect(MaxSafeDeletions(totalReady, minQuorum)).To(Equal(expected))
+			},
+			Entry("should allow deleting the machines beyond the quorum", int32(5), int32(3), int32(2)),
+			Entry("should allow no deletions at the quorum", int32(3), int32(3), int32(0)),
+			Entry("should allow no deletions below the quorum", int32(2), int32(3), int32(0)),
+			Entry("should allow deleting all machines without quorum", int32(3), int32(0), int32(3)),
+			Entry("should treat a negative quorum as no quorum", int32(3), int32(-1), int32(3)),
+			Entry("should allow no deletions without ready machines", int32(0), int32(0), int32(0)),
+		)
+	})
+
+	Describe("#ValidateMachineSetReplicas", func() {
+		newReplicasMachineSet := func(replicas int32) *machinev1.MachineSet {
+			return &machinev1.MachineSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "ms-0", Namespace: testNamespace},
+				Spec:       machinev1.MachineSetSpec{Replicas: replicas},
+			}
+		}
+
+		BeforeEach(func() {
+			machineDeployment.Spec.Replicas = 4
+		})
+
+		It("should accept replicas within the desired replicas plus surge", func() {
+			Expect(ValidateMachineSetReplicas(newReplicasMachineSet(0), machineDeployment)).To(Succeed())
+			Expect(ValidateMachineSetReplicas(newReplicasMachineSet(5), machineDeployment)).To(Succeed())
+		})
+
+		It("should reject replicas exceeding the desired replicas plus surge", func() {
+			err := ValidateMachineSetReplicas(newReplicasMachineSet(6), machineDeployment)
+			Expect(err).To(MatchError(ContainSubstring("exceeding the 4 desired replicas plus 1 surge")))
+		})
+
+		It("should resolve a percentage surge against the desired replicas", func() {
+			maxSurge := intstr.FromString("50%")
+			machineDeployment.Spec.Strategy.RollingUpdate.MaxSurge = &maxSurge
+
+			Expect(ValidateMachineSetReplicas(newReplicasMachineSet(6), machineDeployment)).To(Succeed())
+			Expect(ValidateMachineSetReplicas(newReplicasMachineSet(7), machineDeployment)).NotTo(Succeed())
+		})
+
+		It("should not allow any surge for the recreate strategy", func() {
+			machineDeployment.Spec.Strategy = machinev1.MachineDeploymentStrategy{Type: machinev1.RecreateMachineDeploymentStrategyType}
+
+			Expect(ValidateMachineSetReplicas(newReplicasMachineSet(4), machineDeployment)).To(Succeed())
+			Expect(ValidateMachineSetReplicas(newReplicasMachineSet(5), machineDeployment)).NotTo(Succeed())
+		})
+
+		It("should reject negative replicas", func() {
+			Expect(ValidateMachineSetReplicas(newReplicasMachineSet(-1), machineDeployment)).To(MatchError(ContainSubstring("negative replicas")))
+		})
+	})
+
+	Describe("#SelectMachinesForConsolidation", func() {
+		newRevisionMachineSet := func(name, revision string) *machinev1.MachineSet {
+			return &machinev1.MachineSet{ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   testNamespace,
+				Annotations: map[string]string{RevisionAnnotation: revision},
+			}}
+		}
+		newPhaseMachine := func(name string, phase machinev1.MachinePhase) *machinev1.Machine {
+			return &machinev1.Machine{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace},
+				Status:     machinev1.MachineStatus{CurrentStatus: machinev1.CurrentStatus{Phase: phase}},
+			}
+		}
+		names := func(machines []*machinev1.Machine) []string {
+			var result []string
+			for _, machine := range machines {
+				result = append(result, machine.Name)
+			}
+			return result
+		}
+
+		var (
+			machineSets  []*machinev1.MachineSet
+			machinesByMS map[string][]*machinev1.Machine
+		)
+
+		BeforeEach(func() {
+			machineSets = []*machinev1.MachineSet{
+				newRevisionMachineSet("ms-3", "3"),
+				newRevisionMachineSet("ms-1", "1"),
+				newRevisionMachineSet("ms-2", "2"),
+			}
+			machinesByMS = map[string][]*machinev1.Machine{
+				"ms-1": {newPhaseMachine("ms-1-running", machinev1.MachineRunning), newPhaseMachine("ms-1-pending", machinev1.MachinePending)},
+				"ms-2": {newPhaseMachine("ms-2-running", machinev1.MachineRunning), newPhaseMachine("ms-2-unknown", machinev1.MachineUnknown)},
+				"ms-3": {newPhaseMachine("ms-3-running", machinev1.MachineRunning)},
+			}
+		})
+
+		It("should retire the oldest revisions first, in ActiveMachines order within a machine set", func() {
+			Expect(names(SelectMachinesForConsolidation(machineSets, machinesByMS, 2))).To(Equal([]string{"ms-1-pending", "ms-1-running", "ms-2-unknown"}))
+		})
+
+		It("should not pick protected machines", func() {
+			machinesByMS["ms-1"][1].Annotations = map[string]string{machineutils.MachineNoDelete: "true"}
+
+			Expect(names(SelectMachinesForConsolidation(machineSets, machinesByMS, 3))).To(Equal([]string{"ms-1-running", "ms-2-unknown"}))
+		})
+
+		It("should not pick any machine if the target total is already met", func() {
+			Expect(SelectMachinesForConsolidation(machineSets, machinesByMS, 5)).To(BeEmpty())
+			Expect(SelectMachinesForConsolidation(machineSets, machinesByMS, 6)).To(BeEmpty())
+		})
+	})
 })
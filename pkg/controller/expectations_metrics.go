@@ -0,0 +1,94 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/klog/v2"
+)
+
+// ExpectationsMetrics is a prometheus collector for the expectations of the controllers in a ContExpectations store.
+// It exposes the outstanding creations and deletions per controller key, and how often the expectations of a
+// controller were found expired before they were fulfilled.
+type ExpectationsMetrics struct {
+	outstanding *prometheus.GaugeVec
+	expired     *prometheus.CounterVec
+}
+
+var _ prometheus.Collector = &ExpectationsMetrics{}
+
+// NewExpectationsMetrics returns a new ExpectationsMetrics collector.
+func NewExpectationsMetrics() *ExpectationsMetrics {
+	return &ExpectationsMetrics{
+		outstanding: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "mcm",
+			Subsystem: "machine_set",
+			Name:      "outstanding_expectations",
+			Help:      "Number of creations or deletions a machineset controller is still waiting to observe.",
+		}, []string{"controller", "type"}),
+		expired: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "mcm",
+			Subsystem: "machine_set",
+			Name:      "expired_expectations_total",
+			Help:      "Count of checks of machineset controller expectations which had expired before being fulfilled.",
+		}, []string{"controller"}),
+	}
+}
+
+// Describe is method required to implement the prometheus.Collect interface.
+func (m *ExpectationsMetrics) Describe(ch chan<- *prometheus.Desc) {
+	m.outstanding.Describe(ch)
+	m.expired.Describe(ch)
+}
+
+// Collect is method required to implement the prometheus.Collect interface.
+func (m *ExpectationsMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.outstanding.Collect(ch)
+	m.expired.Collect(ch)
+}
+
+// register registers the collector with the given registerer and returns the collector to update. If expectations
+// metrics are already registered, e.g. by another store, the registered collector is returned to be shared.
+func (m *ExpectationsMetrics) register(registerer prometheus.Registerer) *ExpectationsMetrics {
+	err := registerer.Register(m)
+	if err == nil {
+		return m
+	}
+	if are, ok := err.(prometheus.AlreadyRegisteredError); ok {
+		if existing, ok := are.ExistingCollector.(*ExpectationsMetrics); ok {
+			return existing
+		}
+	}
+	klog.Errorf("Failed to register expectations metrics: %v", err)
+	return m
+}
+
+// observe sets the outstanding gauges of the controller to the current counters of its expectations.
+// Counters which have been lowered below zero are reported as zero.
+func (m *ExpectationsMetrics) observe(exp *ControlleeExpectations) {
+	if m == nil {
+		return
+	}
+	add, del := exp.GetExpectations()
+	m.outstanding.WithLabelValues(exp.key, "add").Set(float64(max(add, 0)))
+	m.outstanding.WithLabelValues(exp.key, "del").Set(float64(max(del, 0)))
+}
+
+// forget drops the metrics of the controller, whose expectations have been deleted.
+func (m *ExpectationsMetrics) forget(controllerKey string) {
+	if m == nil {
+		return
+	}
+	m.outstanding.DeletePartialMatch(prometheus.Labels{"controller": controllerKey})
+	m.expired.DeleteLabelValues(controllerKey)
+}
+
+// expiredObserved counts expired expectations of the controller.
+func (m *ExpectationsMetrics) expiredObserved(controllerKey string) {
+	if m == nil {
+		return
+	}
+	m.expired.WithLabelValues(controllerKey).Inc()
+}
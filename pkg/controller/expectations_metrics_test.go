@@ -0,0 +1,104 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	testingclock "k8s.io/utils/clock/testing"
+)
+
+var _ = Describe("expectations_metrics", func() {
+	const controllerKey = "test/machineset-0"
+
+	var (
+		registry     *prometheus.Registry
+		fakeClock    *testingclock.FakeClock
+		expectations *ContExpectations
+	)
+
+	BeforeEach(func() {
+		registry = prometheus.NewRegistry()
+		fakeClock = testingclock.NewFakeClock(time.Now())
+		expectations = NewContExpectations(0, registry)
+		expectations.clock = fakeClock
+	})
+
+	Describe("#NewContExpectations", func() {
+		It("should register the collector with the passed registerer", func() {
+			Expect(expectations.SetExpectations(controllerKey, 1, 0)).To(Succeed())
+
+			Expect(testutil.CollectAndCount(registry, "mcm_machine_set_outstanding_expectations")).To(Equal(2))
+		})
+
+		It("should not expose metrics without a registerer", func() {
+			Expect(NewContExpectations(0, nil).metrics).To(BeNil())
+		})
+
+		It("should export the expectations of all stores registered with the same registerer", func() {
+			other := NewContExpectations(0, registry)
+			Expect(expectations.SetExpectations(controllerKey, 1, 0)).To(Succeed())
+			Expect(other.SetExpectations("test/machineset-1", 0, 2)).To(Succeed())
+
+			Expect(testutil.CollectAndCount(registry, "mcm_machine_set_outstanding_expectations")).To(Equal(4))
+			Expect(testutil.ToFloat64(expectations.metrics.outstanding.WithLabelValues("test/machineset-1", "del"))).To(Equal(2.0))
+		})
+	})
+
+	Describe("#SatisfiedExpectations", func() {
+		It("should count expectations which expired before being fulfilled once", func() {
+			Expect(expectations.SetExpectations(controllerKey, 2, 0)).To(Succeed())
+			Expect(expectations.SatisfiedExpectations(controllerKey)).To(BeFalse())
+			Expect(testutil.ToFloat64(expectations.metrics.expired.WithLabelValues(controllerKey))).To(BeZero())
+
+			fakeClock.Step(ExpectationsTimeout + time.Second)
+			Expect(expectations.SatisfiedExpectations(controllerKey)).To(BeTrue())
+
+			Expect(testutil.ToFloat64(expectations.metrics.expired.WithLabelValues(controllerKey))).To(Equal(1.0))
+		})
+
+		It("should not count fulfilled expectations", func() {
+			Expect(expectations.SetExpectations(controllerKey, 1, 0)).To(Succeed())
+			expectations.CreationObserved(controllerKey)
+
+			fakeClock.Step(ExpectationsTimeout + time.Second)
+			Expect(expectations.SatisfiedExpectations(controllerKey)).To(BeTrue())
+
+			Expect(testutil.ToFloat64(expectations.metrics.expired.WithLabelValues(controllerKey))).To(BeZero())
+		})
+	})
+
+	Describe("#outstanding expectations", func() {
+		outstanding := func(kind string) float64 {
+			return testutil.ToFloat64(expectations.metrics.outstanding.WithLabelValues(controllerKey, kind))
+		}
+
+		It("should track the outstanding creations and deletions of the controller", func() {
+			Expect(expectations.SetExpectations(controllerKey, 3, 1)).To(Succeed())
+			Expect(outstanding("add")).To(Equal(3.0))
+			Expect(outstanding("del")).To(Equal(1.0))
+
+			expectations.CreationObserved(controllerKey)
+			expectations.DeletionObserved(controllerKey)
+			expectations.DeletionObserved(controllerKey)
+			Expect(outstanding("add")).To(Equal(2.0))
+			Expect(outstanding("del")).To(BeZero())
+
+			expectations.RaiseExpectations(controllerKey, 1, 0)
+			Expect(outstanding("add")).To(Equal(3.0))
+		})
+
+		It("should drop the metrics of deleted expectations", func() {
+			Expect(expectations.SetExpectations(controllerKey, 3, 1)).To(Succeed())
+			expectations.DeleteExpectations(controllerKey)
+
+			Expect(testutil.CollectAndCount(registry, "mcm_machine_set_outstanding_expectations")).To(BeZero())
+		})
+	})
+})
@@ -428,7 +428,11 @@ func (c *controller) manageReplicas(ctx context.Context, allMachines []*v1alpha1
 		klog.V(2).Infof("Too many replicas for %v %s/%s, need %d, deleting %d", machineSet.Kind, machineSet.Namespace, machineSet.Name, (machineSet.Spec.Replicas), diff)
 
 		logMachinesWithPriority1(activeMachines)
-		machinesToDelete := getMachinesToDelete(activeMachines, diff)
+		machinesToDelete, err := getMachinesToDelete(activeMachines, diff)
+		if err != nil {
+			klog.Errorf("failed to scale down machineset %s: %v", machineSet.Name, err)
+			return err
+		}
 		logMachinesToDelete(machinesToDelete)
 
 		// Snapshot the UIDs (ns/name) of the machines we're expecting to see
@@ -474,6 +478,7 @@ func (c *controller) reconcileClusterMachineSet(key string) error {
 	if apierrors.IsNotFound(err) {
 		klog.V(4).Infof("%v has been deleted", key)
 		c.expectations.DeleteExpectations(key)
+		DeleteMachinePhaseGauges(c.namespace, name)
 		return nil
 	}
 	if err != nil {
@@ -566,6 +571,8 @@ func (c *controller) reconcileClusterMachineSet(key string) error {
 		}
 	}
 
+	UpdateMachinePhaseGauges(machineSet.Namespace, machineSet.Name, filteredMachines)
+
 	machineSet = machineSet.DeepCopy()
 	newStatus := calculateMachineSetStatus(machineSet, filteredMachines, manageReplicasErr)
 
@@ -615,9 +622,8 @@ func (c *controller) claimMachines(ctx context.Context, machineSet *v1alpha1.Mac
 //
 // It returns the number of successful calls to the function.
 func slowStartBatch(count int, initialBatchSize int, fn func() error) (int, error) {
-	remaining := count
 	successes := 0
-	for batchSize := integer.IntMin(remaining, initialBatchSize); batchSize > 0; batchSize = integer.IntMin(2*batchSize, remaining) {
+	for _, batchSize := range slowStartBatchSizes(count, initialBatchSize) {
 		errCh := make(chan error, batchSize)
 		defer close(errCh)
 
@@ -637,21 +643,47 @@ func slowStartBatch(count int, initialBatchSize int, fn func() error) (int, erro
 		if len(errCh) > 0 {
 			return successes, <-errCh
 		}
-		remaining -= batchSize
 	}
 	return successes, nil
 }
 
-func getMachinesToDelete(filteredMachines []*v1alpha1.Machine, diff int) []*v1alpha1.Machine {
+// slowStartBatchSizes returns the sizes of the batches slowStartBatch splits count calls into,
+// starting with initialBatchSize and doubling with each batch.
+func slowStartBatchSizes(count int, initialBatchSize int) []int {
+	var batchSizes []int
+	remaining := count
+	for batchSize := integer.IntMin(remaining, initialBatchSize); batchSize > 0; batchSize = integer.IntMin(2*batchSize, remaining) {
+		batchSizes = append(batchSizes, batchSize)
+		remaining -= batchSize
+	}
+	return batchSizes
+}
+
+func getMachinesToDelete(filteredMachines []*v1alpha1.Machine, diff int) ([]*v1alpha1.Machine, error) {
+	// Deletion protected machines still count towards the replicas, but are never picked for deletion.
+	candidates := make([]*v1alpha1.Machine, 0, len(filteredMachines))
+	for _, machine := range filteredMachines {
+		if IsDeletionProtected(machine) {
+			klog.V(3).Infof("Machine %q is protected from deletion by the %s annotation, skipping it", machine.Name, machineutils.MachineNoDelete)
+			continue
+		}
+		candidates = append(candidates, machine)
+	}
+	if diff > 0 && len(candidates) == 0 {
+		return nil, fmt.Errorf("cannot scale down: all machines protected by the %s annotation", machineutils.MachineNoDelete)
+	}
+	if diff > len(candidates) {
+		diff = len(candidates)
+	}
+
 	// No need to sort machines if we are about to delete all of them.
-	// diff will always be <= len(filteredMachines), so not need to handle > case.
-	if diff < len(filteredMachines) {
+	if diff < len(candidates) {
 		// Sort the machines in the order such that not-ready < ready, unscheduled
 		// < scheduled, and pending < running. This ensures that we delete machines
 		// in the earlier stages whenever possible.
-		sort.Sort(ActiveMachines(filteredMachines))
+		sort.Sort(ActiveMachines(candidates))
 	}
-	return filteredMachines[:diff]
+	return candidates[:diff], nil
 }
 
 func getMachineKeys(machines []*v1alpha1.Machine) []string {
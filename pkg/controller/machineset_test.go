@@ -7,15 +7,19 @@ package controller
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/gardener/machine-controller-manager/pkg/util/provider/machineutils"
+	"strings"
 	"sync"
 	"time"
 
 	"k8s.io/utils/pointer"
 
 	machinev1 "github.com/gardener/machine-controller-manager/pkg/apis/machine/v1alpha1"
+	"github.com/gardener/machine-controller-manager/pkg/metrics"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 
 	k8sError "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -592,6 +596,12 @@ var _ = Describe("machineset", func() {
 								"test-label": "test-label",
 							},
 						},
+						Spec: machinev1.MachineSpec{
+							Class: machinev1.ClassSpec{
+								Name: "MachineClass-test",
+								Kind: "MachineClass",
+							},
+						},
 					},
 					Selector: &metav1.LabelSelector{
 						MatchLabels: map[string]string{
@@ -962,6 +972,27 @@ var _ = Describe("machineset", func() {
 			Expect(Err).Should(BeNil())
 		})
 
+		// Testcase: It should drop the machine phase gauges of a deleted machineset.
+		It("It should drop the machine phase gauges if machineset doesnt exist.", func() {
+			stop := make(chan struct{})
+			defer close(stop)
+			defer metrics.MachinesByPhase.Reset()
+
+			objects := []runtime.Object{}
+			c, trackers := createController(stop, testNamespace, objects, nil, nil)
+			defer trackers.Stop()
+			waitForCacheSync(stop, c)
+
+			UpdateMachinePhaseGauges(testNamespace, testMachineSet.Name, nil)
+			Expect(testutil.CollectAndCount(metrics.MachinesByPhase)).NotTo(BeZero())
+
+			Key := testNamespace + "/" + testMachineSet.Name
+			Err := c.reconcileClusterMachineSet(Key)
+
+			Expect(Err).Should(BeNil())
+			Expect(testutil.CollectAndCount(metrics.MachinesByPhase)).To(BeZero())
+		})
+
 		// Testcase: It should return nil if the machineset validation fails.
 		It("It should return nil if machineset validation fails", func() {
 			stop := make(chan struct{})
@@ -1161,6 +1192,19 @@ var _ = Describe("machineset", func() {
 		})
 	})
 
+	Describe("#slowStartBatchSizes", func() {
+		DescribeTable("should start with the initial batch size and double it with each batch",
+			func(count, initialBatchSize int, expected []int) {
+				Expect(slowStartBatchSizes(count, initialBatchSize)).To(Equal(expected))
+			},
+			Entry("a single call", 1, SlowStartInitialBatchSize, []int{1}),
+			Entry("a last batch smaller than double the previous one", 5, SlowStartInitialBatchSize, []int{1, 2, 2}),
+			Entry("many calls", 100, SlowStartInitialBatchSize, []int{1, 2, 4, 8, 16, 32, 37}),
+			Entry("a larger initial batch size", 100, 10, []int{10, 20, 40, 30}),
+			Entry("no calls", 0, SlowStartInitialBatchSize, []int(nil)),
+		)
+	})
+
 	Describe("#getMachinesToDelete", func() {
 		var (
 			testActiveMachine1 *machinev1.Machine
@@ -1209,11 +1253,43 @@ var _ = Describe("machineset", func() {
 			defer close(stop)
 			diff = 1
 			filteredMachines := []*machinev1.Machine{testActiveMachine1, testFailedMachine1}
-			machinesToDelete := getMachinesToDelete(filteredMachines, diff)
+			machinesToDelete, err := getMachinesToDelete(filteredMachines, diff)
 
+			Expect(err).ToNot(HaveOccurred())
 			Expect(len(machinesToDelete)).To(Equal(len(filteredMachines) - diff))
 			Expect(machinesToDelete[0].Name).To(Equal(testFailedMachine1.Name))
 		})
+
+		It("should skip deletion protected machines", func() {
+			testFailedMachine1.Annotations = map[string]string{machineutils.MachineNoDelete: "true"}
+			filteredMachines := []*machinev1.Machine{testActiveMachine1, testFailedMachine1}
+			machinesToDelete, err := getMachinesToDelete(filteredMachines, 1)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(machinesToDelete).To(HaveLen(1))
+			Expect(machinesToDelete[0].Name).To(Equal(testActiveMachine1.Name))
+		})
+
+		It("should only return the unprotected machines if fewer than diff are available", func() {
+			testFailedMachine1.Annotations = map[string]string{machineutils.MachineNoDelete: "true"}
+			filteredMachines := []*machinev1.Machine{testActiveMachine1, testFailedMachine1}
+			machinesToDelete, err := getMachinesToDelete(filteredMachines, 2)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(machinesToDelete).To(HaveLen(1))
+			Expect(machinesToDelete[0].Name).To(Equal(testActiveMachine1.Name))
+		})
+
+		It("should return an error if all machines are deletion protected", func() {
+			testActiveMachine1.Annotations = map[string]string{machineutils.MachineNoDelete: "true"}
+			testFailedMachine1.Annotations = map[string]string{machineutils.MachineNoDelete: "true"}
+			filteredMachines := []*machinev1.Machine{testActiveMachine1, testFailedMachine1}
+			machinesToDelete, err := getMachinesToDelete(filteredMachines, 1)
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("all machines protected"))
+			Expect(machinesToDelete).To(BeEmpty())
+		})
 	})
 
 	Describe("#getMachineKeys", func() {
@@ -1588,4 +1664,345 @@ var _ = Describe("machineset", func() {
 			Expect(testMachineSet.Finalizers).To(Equal(finalizers))
 		})
 	})
+
+	Describe("#IsDeletionProtected", func() {
+		newAnnotatedMachine := func(annotations map[string]string) *machinev1.Machine {
+			return &machinev1.Machine{
+				ObjectMeta: metav1.ObjectMeta{Name: "machine-0", Namespace: testNamespace, Annotations: annotations},
+			}
+		}
+
+		DescribeTable("##table",
+			func(machine *machinev1.Machine, expected bool) {
+				Expect(IsDeletionProtected(machine)).To(Equal(expected))
+			},
+			Entry("should protect a machine with the annotation set to true", newAnnotatedMachine(map[string]string{machineutils.MachineNoDelete: "true"}), true),
+			Entry("should not protect a machine with the annotation set to false", newAnnotatedMachine(map[string]string{machineutils.MachineNoDelete: "false"}), false),
+			Entry("should not protect a machine with an invalid value", newAnnotatedMachine(map[string]string{machineutils.MachineNoDelete: "yes please"}), false),
+			Entry("should not protect a machine with an empty value", newAnnotatedMachine(map[string]string{machineutils.MachineNoDelete: ""}), false),
+			Entry("should not protect a machine without the annotation", newAnnotatedMachine(nil), false),
+		)
+	})
+
+	Describe("#DetectConcurrentScale", func() {
+		newVersionedMachineSet := func(resourceVersion string, generation int64, replicas int32) *machinev1.MachineSet {
+			return &machinev1.MachineSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "machineset-0",
+					Namespace:       testNamespace,
+					ResourceVersion: resourceVersion,
+					Generation:      generation,
+				},
+				Spec: machinev1.MachineSetSpec{Replicas: replicas},
+			}
+		}
+
+		DescribeTable("##table",
+			func(observed, current *machinev1.MachineSet, expected bool) {
+				Expect(DetectConcurrentScale(observed, current)).To(Equal(expected))
+			},
+			Entry("should return false for an unchanged machine set", newVersionedMachineSet("1", 1, 3), newVersionedMachineSet("1", 1, 3), false),
+			Entry("should return false for a status only update", newVersionedMachineSet("1", 1, 3), newVersionedMachineSet("2", 1, 3), false),
+			Entry("should return false for a spec update keeping the replicas", newVersionedMachineSet("1", 1, 3), newVersionedMachineSet("2", 2, 3), false),
+			Entry("should return true if the replicas were changed", newVersionedMachineSet("1", 1, 3), newVersionedMachineSet("2", 2, 5), true),
+			Entry("should return true if the replicas were changed without a generation bump", newVersionedMachineSet("1", 1, 3), newVersionedMachineSet("2", 1, 1), true),
+		)
+	})
+
+	Describe("#NeedsObservedGenerationUpdate", func() {
+		newGenerationMachineSet := func(generation, observedGeneration int64) *machinev1.MachineSet {
+			return &machinev1.MachineSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "machineset-0", Namespace: testNamespace, Generation: generation},
+				Status:     machinev1.MachineSetStatus{ObservedGeneration: observedGeneration},
+			}
+		}
+
+		DescribeTable("##table",
+			func(generation, observedGeneration int64, expected bool) {
+				Expect(NeedsObservedGenerationUpdate(newGenerationMachineSet(generation, observedGeneration))).To(Equal(expected))
+			},
+			Entry("should return true if the observed generation is behind", int64(2), int64(1), true),
+			Entry("should return false if the observed generation is up to date", int64(2), int64(2), false),
+		)
+
+		It("should only patch the observed generation if it is behind", func() {
+			stop := make(chan struct{})
+			defer close(stop)
+
+			testMachineSet := newGenerationMachineSet(3, 1)
+			testMachineSet.Status.Replicas = 2
+			objects := []runtime.Object{testMachineSet}
+			c, trackers := createController(stop, testNamespace, objects, nil, nil)
+			defer trackers.Stop()
+
+			updated, err := BumpObservedGeneration(context.TODO(), c.controlMachineClient, testMachineSet)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(updated.Status.ObservedGeneration).To(Equal(int64(3)))
+			Expect(updated.Status.Replicas).To(Equal(int32(2)))
+
+			upToDate := newGenerationMachineSet(3, 3)
+			Expect(BumpObservedGeneration(context.TODO(), c.controlMachineClient, upToDate)).To(BeIdenticalTo(upToDate))
+		})
+
+		It("should coalesce status updates which only bump the observed generation", func() {
+			stop := make(chan struct{})
+			defer close(stop)
+
+			testMachineSet := newGenerationMachineSet(2, 1)
+			objects := []runtime.Object{testMachineSet}
+			c, trackers := createController(stop, testNamespace, objects, nil, nil)
+			defer trackers.Stop()
+
+			updated, err := updateMachineSetStatus(context.TODO(), c.controlMachineClient, testMachineSet, testMachineSet.Status)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(updated.Status.ObservedGeneration).To(Equal(int64(2)))
+		})
+	})
+
+	Describe("#NodesToCordonForDeletion", func() {
+		newMachineWithNode := func(name, nodeName string) *machinev1.Machine {
+			machine := &machinev1.Machine{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace}}
+			if nodeName != "" {
+				machine.Labels = map[string]string{machinev1.NodeLabelKey: nodeName}
+			}
+			return machine
+		}
+
+		It("should return the node names of the machines", func() {
+			machines := []*machinev1.Machine{
+				newMachineWithNode("machine-0", "node-0"),
+				newMachineWithNode("machine-1", "node-1"),
+			}
+			Expect(NodesToCordonForDeletion(machines)).To(Equal([]string{"node-0", "node-1"}))
+		})
+
+		It("should skip machines without a node", func() {
+			machines := []*machinev1.Machine{
+				newMachineWithNode("machine-0", ""),
+				newMachineWithNode("machine-1", "node-1"),
+			}
+			Expect(NodesToCordonForDeletion(machines)).To(Equal([]string{"node-1"}))
+		})
+
+		It("should return nothing for no machines", func() {
+			Expect(NodesToCordonForDeletion(nil)).To(BeEmpty())
+		})
+	})
+
+	Describe("#GroupMachinesByTemplateHash", func() {
+		newMachineWithHash := func(name, hash string) *machinev1.Machine {
+			machine := &machinev1.Machine{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace}}
+			if hash != "" {
+				machine.Labels = map[string]string{machinev1.DefaultMachineDeploymentUniqueLabelKey: hash}
+			}
+			return machine
+		}
+
+		It("should group the machines by their template hash", func() {
+			machine0 := newMachineWithHash("machine-0", "hash-new")
+			machine1 := newMachineWithHash("machine-1", "hash-old")
+			machine2 := newMachineWithHash("machine-2", "hash-new")
+			machine3 := newMachineWithHash("machine-3", "")
+
+			groups := GroupMachinesByTemplateHash([]*machinev1.Machine{machine0, machine1, machine2, machine3})
+			Expect(groups).To(HaveLen(3))
+			Expect(groups["hash-new"]).To(Equal([]*machinev1.Machine{machine0, machine2}))
+			Expect(groups["hash-old"]).To(Equal([]*machinev1.Machine{machine1}))
+			Expect(groups[""]).To(Equal([]*machinev1.Machine{machine3}))
+		})
+
+		It("should return no groups for no machines", func() {
+			Expect(GroupMachinesByTemplateHash(nil)).To(BeEmpty())
+		})
+	})
+
+	Describe("#IsMachineSetSafeToDelete", func() {
+		machineSet := &machinev1.MachineSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "machineset-0", Namespace: testNamespace, UID: "1234567"},
+		}
+		otherMachineSet := &machinev1.MachineSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "machineset-1", Namespace: testNamespace, UID: "7654321"},
+		}
+		newOwnedMachine := func(name string, owner *machinev1.MachineSet, phase machinev1.MachinePhase) *machinev1.Machine {
+			return &machinev1.Machine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            name,
+					Namespace:       testNamespace,
+					OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(owner, machinev1.SchemeGroupVersion.WithKind("MachineSet"))},
+				},
+				Status: machinev1.MachineStatus{CurrentStatus: machinev1.CurrentStatus{Phase: phase}},
+			}
+		}
+
+		It("should be safe to delete a machine set without machines", func() {
+			machines := []*machinev1.Machine{newOwnedMachine("machine-0", otherMachineSet, machinev1.MachineRunning)}
+			safe, remaining := IsMachineSetSafeToDelete(machineSet, machines)
+			Expect(safe).To(BeTrue())
+			Expect(remaining).To(Equal(0))
+		})
+
+		It("should count the remaining machines including terminating ones", func() {
+			machines := []*machinev1.Machine{
+				newOwnedMachine("machine-0", machineSet, machinev1.MachineRunning),
+				newOwnedMachine("machine-1", machineSet, machinev1.MachineTerminating),
+				newOwnedMachine("machine-2", otherMachineSet, machinev1.MachineRunning),
+			}
+			safe, remaining := IsMachineSetSafeToDelete(machineSet, machines)
+			Expect(safe).To(BeFalse())
+			Expect(remaining).To(Equal(2))
+		})
+	})
+
+	Describe("#SummarizeMachineErrors", func() {
+		newFailedMachine := func(name, errorCode, description string) *machinev1.Machine {
+			return &machinev1.Machine{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace},
+				Status: machinev1.MachineStatus{
+					CurrentStatus: machinev1.CurrentStatus{Phase: machinev1.MachineFailed},
+					LastOperation: machinev1.LastOperation{
+						State:       machinev1.MachineStateFailed,
+						ErrorCode:   errorCode,
+						Description: description,
+					},
+				},
+			}
+		}
+		runningMachine := &machinev1.Machine{
+			ObjectMeta: metav1.ObjectMeta{Name: "machine-running", Namespace: testNamespace},
+			Status: machinev1.MachineStatus{
+				CurrentStatus: machinev1.CurrentStatus{Phase: machinev1.MachineRunning},
+				LastOperation: machinev1.LastOperation{State: machinev1.MachineStateSuccessful},
+			},
+		}
+
+		It("should return empty strings without failed machines", func() {
+			reason, message := SummarizeMachineErrors([]*machinev1.Machine{runningMachine})
+			Expect(reason).To(BeEmpty())
+			Expect(message).To(BeEmpty())
+		})
+
+		It("should use the error code as reason if all machines failed alike", func() {
+			reason, message := SummarizeMachineErrors([]*machinev1.Machine{
+				newFailedMachine("machine-0", "ResourceExhausted", "quota exceeded"),
+				newFailedMachine("machine-1", "ResourceExhausted", "quota exceeded"),
+				runningMachine,
+			})
+			Expect(reason).To(Equal("ResourceExhausted"))
+			Expect(message).To(Equal("2 machine(s) failed with 1 distinct reason(s): ResourceExhausted (2): quota exceeded"))
+		})
+
+		It("should count the distinct reasons of machines which failed differently", func() {
+			reason, message := SummarizeMachineErrors([]*machinev1.Machine{
+				newFailedMachine("machine-0", "Internal", "provider error"),
+				newFailedMachine("machine-1", "ResourceExhausted", "quota exceeded"),
+				newFailedMachine("machine-2", "ResourceExhausted", "quota exceeded"),
+				newFailedMachine("machine-3", "", "unknown error"),
+			})
+			Expect(reason).To(Equal("MultipleMachineFailures"))
+			Expect(message).To(Equal("4 machine(s) failed with 3 distinct reason(s): ResourceExhausted (2): quota exceeded; Internal (1): provider error; Unknown (1): unknown error"))
+		})
+
+		It("should cap the message length", func() {
+			var machines []*machinev1.Machine
+			for i := 0; i < 100; i++ {
+				machines = append(machines, newFailedMachine(fmt.Sprintf("machine-%d", i), fmt.Sprintf("Reason%d", i), strings.Repeat("x", 50)))
+			}
+			reason, message := SummarizeMachineErrors(machines)
+			Expect(reason).To(Equal("MultipleMachineFailures"))
+			Expect(message).To(HaveLen(1024))
+			Expect(message).To(HavePrefix("100 machine(s) failed with 100 distinct reason(s): "))
+			Expect(message).To(HaveSuffix("..."))
+		})
+	})
+
+	Describe("#ComputeMachineSetConditions", func() {
+		now := time.Now()
+		newOperationMachine := func(name string, phase machinev1.MachinePhase, opType machinev1.MachineOperationType, state machinev1.MachineState, age time.Duration) *machinev1.Machine {
+			return &machinev1.Machine{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace},
+				Status: machinev1.MachineStatus{
+					CurrentStatus: machinev1.CurrentStatus{Phase: phase},
+					LastOperation: machinev1.LastOperation{
+						Type:           opType,
+						State:          state,
+						ErrorCode:      "ResourceExhausted",
+						Description:    "quota exceeded",
+						LastUpdateTime: metav1.NewTime(now.Add(-age)),
+					},
+				},
+			}
+		}
+		var machineSet *machinev1.MachineSet
+
+		BeforeEach(func() {
+			machineSet = &machinev1.MachineSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "machineset-0", Namespace: testNamespace},
+				Spec:       machinev1.MachineSetSpec{Replicas: 2},
+			}
+		})
+
+		It("should set ReplicaFailure on recent create failures", func() {
+			machines := []*machinev1.Machine{
+				newOperationMachine("machine-0", machinev1.MachineRunning, machinev1.MachineOperationCreate, machinev1.MachineStateSuccessful, time.Hour),
+				newOperationMachine("machine-1", machinev1.MachinePending, machinev1.MachineOperationCreate, machinev1.MachineStateFailed, time.Minute),
+			}
+
+			conditions := ComputeMachineSetConditions(machineSet, machines, now)
+			Expect(conditions).To(HaveLen(2))
+			status := machinev1.MachineSetStatus{Conditions: conditions}
+			failureCond := GetCondition(&status, machinev1.MachineSetReplicaFailure)
+			Expect(failureCond).NotTo(BeNil())
+			Expect(failureCond.Status).To(Equal(machinev1.ConditionTrue))
+			Expect(failureCond.Reason).To(Equal("FailedCreate"))
+			Expect(failureCond.Message).To(ContainSubstring("ResourceExhausted (1): quota exceeded"))
+			Expect(failureCond.LastTransitionTime.Time).To(Equal(now))
+			readyCond := GetCondition(&status, machinev1.MachineSetMachinesReady)
+			Expect(readyCond.Status).To(Equal(machinev1.ConditionFalse))
+			Expect(readyCond.Message).To(Equal("1/2 machines are ready"))
+		})
+
+		It("should prefer create failures over delete failures", func() {
+			machines := []*machinev1.Machine{
+				newOperationMachine("machine-0", machinev1.MachineTerminating, machinev1.MachineOperationDelete, machinev1.MachineStateFailed, time.Minute),
+				newOperationMachine("machine-1", machinev1.MachinePending, machinev1.MachineOperationCreate, machinev1.MachineStateFailed, time.Minute),
+			}
+
+			status := machinev1.MachineSetStatus{Conditions: ComputeMachineSetConditions(machineSet, machines, now)}
+			Expect(GetCondition(&status, machinev1.MachineSetReplicaFailure).Reason).To(Equal("FailedCreate"))
+
+			status = machinev1.MachineSetStatus{Conditions: ComputeMachineSetConditions(machineSet, machines[:1], now)}
+			Expect(GetCondition(&status, machinev1.MachineSetReplicaFailure).Reason).To(Equal("FailedDelete"))
+		})
+
+		It("should not set ReplicaFailure for create failures outside of the failure window", func() {
+			machineSet.Status.Conditions = []machinev1.MachineSetCondition{
+				NewMachineSetCondition(machinev1.MachineSetReplicaFailure, machinev1.ConditionTrue, "FailedCreate", "quota exceeded"),
+			}
+			machines := []*machinev1.Machine{
+				newOperationMachine("machine-0", machinev1.MachinePending, machinev1.MachineOperationCreate, machinev1.MachineStateFailed, time.Hour),
+			}
+
+			status := machinev1.MachineSetStatus{Conditions: ComputeMachineSetConditions(machineSet, machines, now)}
+			Expect(GetCondition(&status, machinev1.MachineSetReplicaFailure)).To(BeNil())
+		})
+
+		It("should keep other conditions and the transition time of unchanged conditions", func() {
+			transitionTime := metav1.NewTime(now.Add(-time.Hour))
+			machineSet.Status.Conditions = []machinev1.MachineSetCondition{
+				{Type: machinev1.MachineSetFrozen, Status: machinev1.ConditionTrue, Reason: "Frozen"},
+				{Type: machinev1.MachineSetMachinesReady, Status: machinev1.ConditionTrue, LastTransitionTime: transitionTime},
+			}
+			machines := []*machinev1.Machine{
+				newOperationMachine("machine-0", machinev1.MachineRunning, machinev1.MachineOperationCreate, machinev1.MachineStateSuccessful, time.Hour),
+				newOperationMachine("machine-1", machinev1.MachineRunning, machinev1.MachineOperationCreate, machinev1.MachineStateSuccessful, time.Hour),
+			}
+
+			status := machinev1.MachineSetStatus{Conditions: ComputeMachineSetConditions(machineSet, machines, now)}
+			Expect(status.Conditions).To(HaveLen(2))
+			Expect(GetCondition(&status, machinev1.MachineSetFrozen)).NotTo(BeNil())
+			readyCond := GetCondition(&status, machinev1.MachineSetMachinesReady)
+			Expect(readyCond.Reason).To(Equal("MachinesReady"))
+			Expect(readyCond.LastTransitionTime).To(Equal(transitionTime))
+		})
+	})
 })
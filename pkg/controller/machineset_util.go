@@ -26,6 +26,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/gardener/machine-controller-manager/pkg/util/provider/machineutils"
+	"strconv"
 
 	"k8s.io/klog/v2"
 
@@ -217,3 +218,64 @@ func logMachinesToDelete(machines []*v1alpha1.Machine) {
 		klog.V(3).Infof("Machine %q needs to be deleted", m.Name)
 	}
 }
+
+// IsDeletionProtected returns true if the machine carries the no-delete annotation set to true,
+// in which case it must not be selected for deletion on scale down. Invalid values don't protect the machine.
+func IsDeletionProtected(machine *v1alpha1.Machine) bool {
+	value, ok := machine.Annotations[machineutils.MachineNoDelete]
+	if !ok {
+		return false
+	}
+	protected, err := strconv.ParseBool(value)
+	if err != nil {
+		klog.Warningf("Invalid value %q for annotation %q on machine %q, the machine is not protected against deletion", value, machineutils.MachineNoDelete, machine.Name)
+		return false
+	}
+	return protected
+}
+
+// DetectConcurrentScale returns true if the replicas of the machine set were changed by an external actor,
+// e.g. the autoscaler, between the observed and the current state of the machine set. The controller
+// should then requeue and re-read the machine set instead of clobbering the external change.
+func DetectConcurrentScale(observed, current *v1alpha1.MachineSet) bool {
+	if observed.ResourceVersion == current.ResourceVersion && observed.Generation == current.Generation {
+		return false
+	}
+	return observed.Spec.Replicas != current.Spec.Replicas
+}
+
+// NodesToCordonForDeletion returns the names of the nodes backing the machines selected for deletion,
+// so that they can be cordoned before the machines are deleted. Machines without a node are skipped.
+func NodesToCordonForDeletion(machines []*v1alpha1.Machine) []string {
+	var nodeNames []string
+	for _, machine := range machines {
+		if nodeName := machine.Labels[v1alpha1.NodeLabelKey]; nodeName != "" {
+			nodeNames = append(nodeNames, nodeName)
+		}
+	}
+	return nodeNames
+}
+
+// GroupMachinesByTemplateHash groups the machines by their machine template hash label, e.g. to count
+// the updated and outdated machines of a machine set during a rollout. Unlabeled machines are grouped
+// under the empty hash.
+func GroupMachinesByTemplateHash(machines []*v1alpha1.Machine) map[string][]*v1alpha1.Machine {
+	groups := make(map[string][]*v1alpha1.Machine)
+	for _, machine := range machines {
+		hash := machine.Labels[v1alpha1.DefaultMachineDeploymentUniqueLabelKey]
+		groups[hash] = append(groups[hash], machine)
+	}
+	return groups
+}
+
+// IsMachineSetSafeToDelete returns whether the machine set can be deleted without orphaning any of its machines,
+// along with the count of machines still controlled by it. Terminating machines count as remaining.
+func IsMachineSetSafeToDelete(ms *v1alpha1.MachineSet, machines []*v1alpha1.Machine) (bool, int) {
+	remaining := 0
+	for _, machine := range machines {
+		if metav1.IsControlledBy(machine, ms) {
+			remaining++
+		}
+	}
+	return remaining == 0, remaining
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
 )
 
 type staleMachinesRemovedCounter struct {
@@ -35,6 +36,58 @@ func (cntr *staleMachinesRemovedCounter) readAndReset() int32 {
 	return value
 }
 
+// machinePhases are the machine phases reported by the machines by phase gauges
+var machinePhases = []v1alpha1.MachinePhase{
+	v1alpha1.MachinePending,
+	v1alpha1.MachineAvailable,
+	v1alpha1.MachineRunning,
+	v1alpha1.MachineTerminating,
+	v1alpha1.MachineUnknown,
+	v1alpha1.MachineFailed,
+	v1alpha1.MachineCrashLoopBackOff,
+	v1alpha1.MachineInPlaceUpdating,
+	v1alpha1.MachineInPlaceUpdateSuccessful,
+	v1alpha1.MachineInPlaceUpdateFailed,
+}
+
+// RecordMachinePhaseMetrics registers the machines by phase gauges with the given registerer.
+func RecordMachinePhaseMetrics(registerer prometheus.Registerer) {
+	if err := registerer.Register(metrics.MachinesByPhase); err != nil {
+		if _, ok := err.(prometheus.AlreadyRegisteredError); !ok {
+			klog.Errorf("Failed to register machines by phase metrics: %v", err)
+		}
+	}
+}
+
+// UpdateMachinePhaseGauges sets the machines by phase gauges of the given machineset to the number of machines in each phase.
+// Gauges of phases without machines are reset to zero.
+func UpdateMachinePhaseGauges(namespace, machineSetName string, machines []*v1alpha1.Machine) {
+	counts := make(map[v1alpha1.MachinePhase]int, len(machinePhases))
+	for _, phase := range machinePhases {
+		counts[phase] = 0
+	}
+	for _, machine := range machines {
+		if phase := machine.Status.CurrentStatus.Phase; phase != "" {
+			counts[phase]++
+		}
+	}
+
+	// drop gauges of phases which are not known anymore
+	DeleteMachinePhaseGauges(namespace, machineSetName)
+	for phase, count := range counts {
+		metrics.MachinesByPhase.With(prometheus.Labels{
+			"namespace":  namespace,
+			"machineset": machineSetName,
+			"phase":      string(phase),
+		}).Set(float64(count))
+	}
+}
+
+// DeleteMachinePhaseGauges drops the machines by phase gauges of the given machineset, e.g. once it has been deleted.
+func DeleteMachinePhaseGauges(namespace, machineSetName string) {
+	metrics.MachinesByPhase.DeletePartialMatch(prometheus.Labels{"namespace": namespace, "machineset": machineSetName})
+}
+
 // Describe is method required to implement the prometheus.Collect interface.
 func (c *controller) Describe(ch chan<- *prometheus.Desc) {
 	ch <- metrics.MachineSetCountDesc
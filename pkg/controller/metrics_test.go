@@ -0,0 +1,88 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	machinev1 "github.com/gardener/machine-controller-manager/pkg/apis/machine/v1alpha1"
+	"github.com/gardener/machine-controller-manager/pkg/metrics"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+var _ = Describe("metrics", func() {
+	Describe("#UpdateMachinePhaseGauges", func() {
+		newMachineInPhase := func(phase machinev1.MachinePhase) *machinev1.Machine {
+			return &machinev1.Machine{
+				Status: machinev1.MachineStatus{
+					CurrentStatus: machinev1.CurrentStatus{Phase: phase},
+				},
+			}
+		}
+		gaugeValue := func(namespace, machineSetName string, phase machinev1.MachinePhase) float64 {
+			return testutil.ToFloat64(metrics.MachinesByPhase.With(prometheus.Labels{"namespace": namespace, "machineset": machineSetName, "phase": string(phase)}))
+		}
+
+		AfterEach(func() {
+			metrics.MachinesByPhase.Reset()
+		})
+
+		It("should register the gauges only once", func() {
+			registry := prometheus.NewRegistry()
+			RecordMachinePhaseMetrics(registry)
+			RecordMachinePhaseMetrics(registry)
+		})
+
+		It("should count the machines in each phase and reset phases that dropped to zero", func() {
+			UpdateMachinePhaseGauges(testNamespace, "machineset-0", []*machinev1.Machine{
+				newMachineInPhase(machinev1.MachineRunning),
+				newMachineInPhase(machinev1.MachineRunning),
+				newMachineInPhase(machinev1.MachineFailed),
+			})
+			Expect(gaugeValue(testNamespace, "machineset-0", machinev1.MachineRunning)).To(Equal(2.0))
+			Expect(gaugeValue(testNamespace, "machineset-0", machinev1.MachineFailed)).To(Equal(1.0))
+			Expect(gaugeValue(testNamespace, "machineset-0", machinev1.MachinePending)).To(Equal(0.0))
+
+			UpdateMachinePhaseGauges(testNamespace, "machineset-0", []*machinev1.Machine{
+				newMachineInPhase(machinev1.MachineRunning),
+			})
+			Expect(gaugeValue(testNamespace, "machineset-0", machinev1.MachineRunning)).To(Equal(1.0))
+			Expect(gaugeValue(testNamespace, "machineset-0", machinev1.MachineFailed)).To(Equal(0.0))
+		})
+
+		It("should not touch the gauges of other machinesets", func() {
+			UpdateMachinePhaseGauges(testNamespace, "machineset-0", []*machinev1.Machine{newMachineInPhase(machinev1.MachineRunning)})
+			UpdateMachinePhaseGauges(testNamespace, "machineset-1", nil)
+
+			Expect(gaugeValue(testNamespace, "machineset-0", machinev1.MachineRunning)).To(Equal(1.0))
+			Expect(gaugeValue(testNamespace, "machineset-1", machinev1.MachineRunning)).To(Equal(0.0))
+		})
+
+		It("should not mix up machinesets with the same name in different namespaces", func() {
+			UpdateMachinePhaseGauges(testNamespace, "machineset-0", []*machinev1.Machine{newMachineInPhase(machinev1.MachineRunning)})
+			UpdateMachinePhaseGauges("other", "machineset-0", nil)
+
+			Expect(gaugeValue(testNamespace, "machineset-0", machinev1.MachineRunning)).To(Equal(1.0))
+			Expect(gaugeValue("other", "machineset-0", machinev1.MachineRunning)).To(Equal(0.0))
+		})
+	})
+
+	Describe("#DeleteMachinePhaseGauges", func() {
+		AfterEach(func() {
+			metrics.MachinesByPhase.Reset()
+		})
+
+		It("should drop the gauges of the deleted machineset only", func() {
+			UpdateMachinePhaseGauges(testNamespace, "machineset-0", nil)
+			UpdateMachinePhaseGauges(testNamespace, "machineset-1", nil)
+			UpdateMachinePhaseGauges("other", "machineset-0", nil)
+
+			DeleteMachinePhaseGauges(testNamespace, "machineset-0")
+
+			Expect(testutil.CollectAndCount(metrics.MachinesByPhase)).To(Equal(2 * len(machinePhases)))
+		})
+	})
+})
@@ -0,0 +1,107 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+)
+
+// DefaultRateLimitedLoggerTTL is the default period within which a RateLimitedLogger suppresses repeated log lines.
+const DefaultRateLimitedLoggerTTL = 30 * time.Second
+
+// RateLimitedLogger logs at a fixed verbosity and suppresses a log line which was already logged within the TTL.
+// When a suppressed line is logged again after the TTL or its entry is pruned, the number of suppressed repetitions
+// is appended to it, e.g. "(repeated 42 times)".
+type RateLimitedLogger struct {
+	ttl   time.Duration
+	clock clock.Clock
+
+	// enabled returns whether log lines are logged at all, to avoid formatting them otherwise
+	enabled func() bool
+	// log writes a single log line
+	log func(msg string)
+
+	lock      sync.Mutex
+	entries   map[string]*rateLimitedLogEntry
+	lastPrune time.Time
+}
+
+// rateLimitedLogEntry tracks the repetitions of a single log line.
+type rateLimitedLogEntry struct {
+	lastLogged time.Time
+	suppressed int
+}
+
+// NewRateLimitedLogger returns a RateLimitedLogger logging at the given verbosity. A non-positive ttl
+// defaults to DefaultRateLimitedLoggerTTL.
+func NewRateLimitedLogger(level klog.Level, ttl time.Duration) *RateLimitedLogger {
+	return NewRateLimitedLoggerWithClock(level, ttl, clock.RealClock{})
+}
+
+// NewRateLimitedLoggerWithClock returns a RateLimitedLogger logging at the given verbosity using the given clock.
+func NewRateLimitedLoggerWithClock(level klog.Level, ttl time.Duration, c clock.Clock) *RateLimitedLogger {
+	if ttl <= 0 {
+		ttl = DefaultRateLimitedLoggerTTL
+	}
+	return &RateLimitedLogger{
+		ttl:     ttl,
+		clock:   c,
+		enabled: func() bool { return klog.V(level).Enabled() },
+		log:     func(msg string) { klog.V(level).InfoDepth(2, msg) },
+		entries: make(map[string]*rateLimitedLogEntry),
+	}
+}
+
+// Infof logs the formatted message unless the same message was logged within the TTL.
+func (l *RateLimitedLogger) Infof(format string, args ...interface{}) {
+	if !l.enabled() {
+		return
+	}
+	msg := fmt.Sprintf(format, args...)
+	now := l.clock.Now()
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	defer l.pruneLocked(now)
+	entry, ok := l.entries[msg]
+	if ok && now.Sub(entry.lastLogged) < l.ttl {
+		entry.suppressed++
+		return
+	}
+	if ok && entry.suppressed > 0 {
+		l.log(repeatedLogLine(msg, entry.suppressed))
+	} else {
+		l.log(msg)
+	}
+	l.entries[msg] = &rateLimitedLogEntry{lastLogged: now}
+}
+
+// pruneLocked drops the entries not logged within the TTL at most once per TTL, emitting the summary of entries
+// with suppressed repetitions. The lock must be held by the caller.
+func (l *RateLimitedLogger) pruneLocked(now time.Time) {
+	if now.Sub(l.lastPrune) < l.ttl {
+		return
+	}
+	l.lastPrune = now
+	for msg, entry := range l.entries {
+		if now.Sub(entry.lastLogged) < l.ttl {
+			continue
+		}
+		if entry.suppressed > 0 {
+			l.log(repeatedLogLine(msg, entry.suppressed))
+		}
+		delete(l.entries, msg)
+	}
+}
+
+func repeatedLogLine(msg string, repetitions int) string {
+	return fmt.Sprintf("%s (repeated %d times)", msg, repetitions)
+}
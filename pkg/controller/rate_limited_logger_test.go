@@ -0,0 +1,74 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	testingclock "k8s.io/utils/clock/testing"
+)
+
+var _ = Describe("rate_limited_logger", func() {
+	var (
+		fakeClock *testingclock.FakeClock
+		logger    *RateLimitedLogger
+		lines     []string
+	)
+
+	BeforeEach(func() {
+		fakeClock = testingclock.NewFakeClock(time.Now())
+		logger = NewRateLimitedLoggerWithClock(4, 10*time.Second, fakeClock)
+		logger.enabled = func() bool { return true }
+		lines = nil
+		logger.log = func(msg string) { lines = append(lines, msg) }
+	})
+
+	Describe("#Infof", func() {
+		It("should suppress repeated log lines within the TTL", func() {
+			logger.Infof("waiting on %s", "machineset-0")
+			logger.Infof("waiting on %s", "machineset-0")
+			logger.Infof("waiting on %s", "machineset-1")
+
+			Expect(lines).To(Equal([]string{"waiting on machineset-0", "waiting on machineset-1"}))
+		})
+
+		It("should log the number of suppressed repetitions after the TTL", func() {
+			for i := 0; i < 3; i++ {
+				logger.Infof("waiting on machineset-0")
+			}
+			fakeClock.Step(10 * time.Second)
+			logger.Infof("waiting on machineset-0")
+			logger.Infof("waiting on machineset-0")
+
+			Expect(lines).To(Equal([]string{"waiting on machineset-0", "waiting on machineset-0 (repeated 2 times)"}))
+		})
+
+		It("should emit the summary of pruned log lines", func() {
+			logger.Infof("waiting on machineset-0")
+			logger.Infof("waiting on machineset-0")
+			fakeClock.Step(10 * time.Second)
+			logger.Infof("waiting on machineset-1")
+
+			Expect(lines).To(Equal([]string{"waiting on machineset-0", "waiting on machineset-1", "waiting on machineset-0 (repeated 1 times)"}))
+			Expect(logger.entries).To(HaveLen(1))
+		})
+
+		It("should not log anything if the verbosity is disabled", func() {
+			logger.enabled = func() bool { return false }
+			logger.Infof("waiting on machineset-0")
+
+			Expect(lines).To(BeEmpty())
+			Expect(logger.entries).To(BeEmpty())
+		})
+	})
+
+	Describe("#NewRateLimitedLogger", func() {
+		It("should default the TTL", func() {
+			Expect(NewRateLimitedLogger(4, 0).ttl).To(Equal(DefaultRateLimitedLoggerTTL))
+		})
+	})
+})
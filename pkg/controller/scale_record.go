@@ -0,0 +1,61 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"time"
+
+	"github.com/gardener/machine-controller-manager/pkg/apis/machine/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ScaleRecord is a structured record of a single replica change of a machineSet, kept for auditing.
+type ScaleRecord struct {
+	// Namespace of the scaled machineSet
+	Namespace string
+	// MachineSet is the name of the scaled machineSet
+	MachineSet string
+	// MachineDeployment is the name of the machineDeployment controlling the machineSet, empty if there is none
+	MachineDeployment string
+	// From is the replica count before the change
+	From int32
+	// To is the replica count after the change
+	To int32
+	// Reason describes why the machineSet was scaled
+	Reason string
+	// Time at which the machineSet was scaled
+	Time time.Time
+}
+
+// ScaleRecorder is implemented by audit backends which persist the scale history of machineSets.
+type ScaleRecorder interface {
+	// RecordScale records a single replica change of a machineSet.
+	RecordScale(ScaleRecord)
+}
+
+// NewScaleRecord returns the ScaleRecord for scaling the given machineSet from `from` to `to` replicas. The owning
+// machineDeployment is taken from the controller reference of the machineSet, if any.
+func NewScaleRecord(ms *v1alpha1.MachineSet, from, to int32, reason string, at time.Time) ScaleRecord {
+	record := ScaleRecord{
+		Namespace:  ms.Namespace,
+		MachineSet: ms.Name,
+		From:       from,
+		To:         to,
+		Reason:     reason,
+		Time:       at,
+	}
+	if controllerRef := metav1.GetControllerOf(ms); controllerRef != nil && controllerRef.Kind == controllerKind.Kind {
+		record.MachineDeployment = controllerRef.Name
+	}
+	return record
+}
+
+// recordScale passes the record to the configured ScaleRecorder, if any.
+func (dc *controller) recordScale(record ScaleRecord) {
+	if dc.scaleRecorder == nil {
+		return
+	}
+	dc.scaleRecorder.RecordScale(record)
+}
@@ -0,0 +1,145 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	machinev1 "github.com/gardener/machine-controller-manager/pkg/apis/machine/v1alpha1"
+	fakemachineclientset "github.com/gardener/machine-controller-manager/pkg/client/clientset/versioned/fake"
+	machineinformers "github.com/gardener/machine-controller-manager/pkg/client/informers/externalversions"
+	"github.com/gardener/machine-controller-manager/pkg/options"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	coreinformers "k8s.io/client-go/informers"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+type fakeScaleRecorder struct {
+	records []ScaleRecord
+}
+
+func (f *fakeScaleRecorder) RecordScale(record ScaleRecord) {
+	f.records = append(f.records, record)
+}
+
+var _ = Describe("scale_record", func() {
+	machineDeployment := &machinev1.MachineDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "machinedeployment-0", Namespace: testNamespace, UID: "1234567"},
+		Spec:       machinev1.MachineDeploymentSpec{Replicas: 3},
+	}
+	newOwnedMachineSet := func(replicas int32, owner *machinev1.MachineDeployment) *machinev1.MachineSet {
+		ms := &machinev1.MachineSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "machineset-0", Namespace: testNamespace},
+			Spec:       machinev1.MachineSetSpec{Replicas: replicas},
+		}
+		if owner != nil {
+			ms.OwnerReferences = []metav1.OwnerReference{*metav1.NewControllerRef(owner, controllerKind)}
+		}
+		return ms
+	}
+
+	Describe("#NewScaleRecord", func() {
+		now := time.Now()
+
+		It("should include the owning machine deployment", func() {
+			record := NewScaleRecord(newOwnedMachineSet(1, machineDeployment), 1, 3, "scaled up", now)
+
+			Expect(record).To(Equal(ScaleRecord{
+				Namespace:         testNamespace,
+				MachineSet:        "machineset-0",
+				MachineDeployment: "machinedeployment-0",
+				From:              1,
+				To:                3,
+				Reason:            "scaled up",
+				Time:              now,
+			}))
+		})
+
+		It("should leave the machine deployment empty for an unowned machine set", func() {
+			record := NewScaleRecord(newOwnedMachineSet(3, nil), 3, 0, "scaled down", now)
+
+			Expect(record.MachineDeployment).To(BeEmpty())
+			Expect(record.From).To(Equal(int32(3)))
+			Expect(record.To).To(Equal(int32(0)))
+		})
+	})
+
+	Describe("#scaleMachineSet", func() {
+		It("should record every replica change", func() {
+			stop := make(chan struct{})
+			defer close(stop)
+
+			ms := newOwnedMachineSet(1, machineDeployment)
+			c, trackers := createController(stop, testNamespace, []runtime.Object{machineDeployment, ms}, nil, nil)
+			defer trackers.Stop()
+			waitForCacheSync(stop, c)
+			c.recorder = record.NewFakeRecorder(10)
+			recorder := &fakeScaleRecorder{}
+			c.scaleRecorder = recorder
+
+			scaled, _, err := c.scaleMachineSet(context.TODO(), ms, 3, machineDeployment, "up")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(scaled).To(BeTrue())
+			Expect(recorder.records).To(HaveLen(1))
+			Expect(recorder.records[0].MachineDeployment).To(Equal("machinedeployment-0"))
+			Expect(recorder.records[0].From).To(Equal(int32(1)))
+			Expect(recorder.records[0].To).To(Equal(int32(3)))
+			Expect(recorder.records[0].Reason).To(Equal("scaled up for machine deployment machinedeployment-0"))
+		})
+
+		It("should record replica changes with the scale recorder passed to NewController", func() {
+			ms := newOwnedMachineSet(1, machineDeployment)
+			fakeMachineClient := fakemachineclientset.NewSimpleClientset(machineDeployment, ms)
+			fakeCoreClient := k8sfake.NewSimpleClientset()
+			machineInformerFactory := machineinformers.NewSharedInformerFactory(fakeMachineClient, 0)
+			coreInformerFactory := coreinformers.NewSharedInformerFactory(fakeCoreClient, 0)
+			recorder := &fakeScaleRecorder{}
+
+			newController, err := NewController(
+				testNamespace,
+				fakeMachineClient.MachineV1alpha1(),
+				fakeCoreClient,
+				fakeCoreClient,
+				coreInformerFactory.Core().V1().Nodes(),
+				machineInformerFactory.Machine().V1alpha1().Machines(),
+				machineInformerFactory.Machine().V1alpha1().MachineSets(),
+				machineInformerFactory.Machine().V1alpha1().MachineDeployments(),
+				record.NewFakeRecorder(10),
+				options.SafetyOptions{},
+				false,
+				recorder,
+			)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, _, err = newController.(*controller).scaleMachineSet(context.TODO(), ms, 2, machineDeployment, "up")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recorder.records).To(HaveLen(1))
+			Expect(recorder.records[0].MachineSet).To(Equal("machineset-0"))
+			Expect(recorder.records[0].To).To(Equal(int32(2)))
+		})
+
+		It("should not record anything if the replicas are unchanged", func() {
+			stop := make(chan struct{})
+			defer close(stop)
+
+			ms := newOwnedMachineSet(3, machineDeployment)
+			c, trackers := createController(stop, testNamespace, []runtime.Object{machineDeployment, ms}, nil, nil)
+			defer trackers.Stop()
+			waitForCacheSync(stop, c)
+			c.recorder = record.NewFakeRecorder(10)
+			recorder := &fakeScaleRecorder{}
+			c.scaleRecorder = recorder
+
+			_, _, err := c.scaleMachineSet(context.TODO(), ms, 3, machineDeployment, "no-op")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recorder.records).To(BeEmpty())
+		})
+	})
+})
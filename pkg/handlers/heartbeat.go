@@ -0,0 +1,41 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package handlers
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Heartbeat records the time of the last successful reconcile loop of a controller,
+// so that a wedged controller can be detected by a health handler.
+// It is safe for a single writer calling Beat and multiple concurrent readers.
+type Heartbeat struct {
+	lastBeat atomic.Int64
+}
+
+// NewHeartbeat returns a Heartbeat whose first beat is recorded at creation time.
+func NewHeartbeat() *Heartbeat {
+	h := &Heartbeat{}
+	h.Beat()
+	return h
+}
+
+// Beat records a heartbeat at the current time.
+func (h *Heartbeat) Beat() {
+	h.lastBeat.Store(time.Now().UnixNano())
+}
+
+// StaleSince returns true if no heartbeat was recorded within maxGap before now,
+// along with the time elapsed since the last heartbeat.
+// A Heartbeat that never had a beat recorded is always stale, and the elapsed time is zero.
+func (h *Heartbeat) StaleSince(now time.Time, maxGap time.Duration) (bool, time.Duration) {
+	last := h.lastBeat.Load()
+	if last == 0 {
+		return true, 0
+	}
+	gap := now.Sub(time.Unix(0, last))
+	return gap > maxGap, gap
+}
@@ -0,0 +1,76 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package handlers
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHeartbeatStaleSince(t *testing.T) {
+	type testCase struct {
+		name          string
+		offset        time.Duration
+		maxGap        time.Duration
+		expectedStale bool
+	}
+
+	tests := []testCase{
+		{"fresh heartbeat is not stale", 10 * time.Second, time.Minute, false},
+		{"heartbeat exactly at the max gap is not stale", time.Minute, time.Minute, false},
+		{"heartbeat older than the max gap is stale", 2 * time.Minute, time.Minute, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h := &Heartbeat{}
+			h.Beat()
+			last := time.Unix(0, h.lastBeat.Load())
+
+			stale, gap := h.StaleSince(last.Add(test.offset), test.maxGap)
+
+			if stale != test.expectedStale {
+				t.Errorf("Unexpected staleness, got: %t, want: %t.", stale, test.expectedStale)
+			}
+			if gap != test.offset {
+				t.Errorf("Unexpected gap, got: %s, want: %s.", gap, test.offset)
+			}
+		})
+	}
+}
+
+func TestHeartbeatWithoutBeatIsStale(t *testing.T) {
+	h := &Heartbeat{}
+
+	stale, gap := h.StaleSince(time.Now(), time.Hour)
+
+	if !stale || gap != 0 {
+		t.Errorf("Heartbeat without beat should be stale with zero gap, got: %t, %s.", stale, gap)
+	}
+}
+
+func TestHeartbeatConcurrentAccess(_ *testing.T) {
+	h := NewHeartbeat()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			h.Beat()
+		}
+	}()
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				h.StaleSince(time.Now(), time.Minute)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -25,6 +25,13 @@ var (
 		Name:      "stale_machines_total",
 		Help:      "Total count of stale machines flagged for termination that turned stale due to long unhealthiness",
 	})
+
+	// MachinesByPhase Number of machines in each phase per machineset. It is registered on demand.
+	MachinesByPhase = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "machines_by_phase",
+		Help:      "Number of machines in each phase per machineset.",
+	}, []string{"namespace", "machineset", "phase"})
 )
 
 // variables for subsystem: machine_set
@@ -106,6 +113,14 @@ var (
 		Name:      "status_replicas",
 		Help:      "Information of the mcm managed Machinesets' status for replicas.",
 	}, []string{"name", "namespace"})
+
+	// MachineSetCorruptExpectations Count of corrupt machineset expectations which have been detected and reset.
+	MachineSetCorruptExpectations = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: machinesetSubsystem,
+		Name:      "corrupt_expectations_total",
+		Help:      "Count of corrupt machineset expectations which have been detected and reset.",
+	})
 )
 
 // variables for subsystem: machine_deployment
@@ -278,6 +293,7 @@ func registerMachineSetSubsystemMetrics() {
 	prometheus.MustRegister(MachineSetStatusReplicas)
 	prometheus.MustRegister(MachineSetStatusCondition)
 	prometheus.MustRegister(MachineSetStatusFailedMachines)
+	prometheus.MustRegister(MachineSetCorruptExpectations)
 }
 
 func registerMachineDeploymentSubsystemMetrics() {
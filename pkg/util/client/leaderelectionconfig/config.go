@@ -22,6 +22,8 @@ Modifications Copyright SAP SE or an SAP affiliate company and Gardener contribu
 package leaderelectionconfig
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/gardener/machine-controller-manager/pkg/options"
@@ -50,6 +52,22 @@ func DefaultLeaderElectionConfiguration() options.LeaderElectionConfiguration {
 	}
 }
 
+// supportedResourceLocks are the resource lock types supported by the leader election client.
+// The endpoints, configmaps, endpointsleases and configmapsleases locks have been removed from client-go.
+var supportedResourceLocks = []string{
+	rl.LeasesResourceLock,
+}
+
+// ValidateResourceLock returns an error if the given resource lock type is not supported for leader election.
+func ValidateResourceLock(lock string) error {
+	for _, supported := range supportedResourceLocks {
+		if lock == supported {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported leader election resource lock %q, supported values are: %s", lock, strings.Join(supportedResourceLocks, ", "))
+}
+
 // BindFlags binds the common LeaderElectionCLIConfig flags to a flagset
 func BindFlags(l *options.LeaderElectionConfiguration, fs *pflag.FlagSet) {
 	fs.BoolVar(&l.LeaderElect, "leader-elect", l.LeaderElect, ""+
@@ -71,6 +89,5 @@ func BindFlags(l *options.LeaderElectionConfiguration, fs *pflag.FlagSet) {
 		"of a leadership. This is only applicable if leader election is enabled.")
 	fs.StringVar(&l.ResourceLock, "leader-elect-resource-lock", l.ResourceLock, ""+
 		"The type of resource object that is used for locking during "+
-		"leader election. Supported options are 'endpoints', 'configmaps', "+
-		"'leases', 'endpointsleases' and 'configmapsleases'.")
+		"leader election. Supported options are 'leases'.")
 }
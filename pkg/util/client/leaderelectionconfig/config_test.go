@@ -0,0 +1,35 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package leaderelectionconfig
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateResourceLock(t *testing.T) {
+	tests := []struct {
+		name    string
+		lock    string
+		wantErr bool
+	}{
+		{name: "leases", lock: "leases", wantErr: false},
+		{name: "removed endpoints lock", lock: "endpoints", wantErr: true},
+		{name: "removed configmapsleases lock", lock: "configmapsleases", wantErr: true},
+		{name: "empty", lock: "", wantErr: true},
+		{name: "unknown", lock: "foo", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateResourceLock(tt.lock)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateResourceLock(%q) error = %v, wantErr %v", tt.lock, err, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), "leases") {
+				t.Errorf("expected the supported values in the error message, got %q", err.Error())
+			}
+		})
+	}
+}
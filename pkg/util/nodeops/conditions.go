@@ -10,6 +10,7 @@ import (
 	"fmt"
 
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clientset "k8s.io/client-go/kubernetes"
 	clientretry "k8s.io/client-go/util/retry"
@@ -68,7 +69,7 @@ func GetNodeCondition(ctx context.Context, c clientset.Interface, nodeName strin
 // AddOrUpdateConditionsOnNode adds a condition to the node's status
 func AddOrUpdateConditionsOnNode(ctx context.Context, c clientset.Interface, nodeName string, condition v1.NodeCondition) error {
 	firstTry := true
-	return clientretry.RetryOnConflict(Backoff, func() error {
+	return clientretry.RetryOnConflict(currentBackoff(), func() error {
 		var err error
 		var oldNode *v1.Node
 		// First we try getting node from the API server cache, as it's cheaper. If it fails
@@ -99,6 +100,10 @@ func UpdateNodeConditions(ctx context.Context, c clientset.Interface, nodeName s
 
 	_, err := c.CoreV1().Nodes().UpdateStatus(ctx, newNodeClone, metav1.UpdateOptions{})
 	if err != nil {
+		if apierrors.IsConflict(err) {
+			// conflicts are returned as is, to be retried with Backoff
+			return err
+		}
 		return fmt.Errorf("failed to create update conditions for node %q: %v", nodeName, err)
 	}
 
@@ -9,10 +9,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sync"
 	"time"
 
 	taintutils "github.com/gardener/machine-controller-manager/pkg/util/taints"
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/strategicpatch"
@@ -28,6 +30,23 @@ var Backoff = wait.Backoff{
 	Jitter:   1.0,
 }
 
+// backoffLock guards Backoff against concurrent updates via SetBackoff.
+var backoffLock sync.RWMutex
+
+// SetBackoff sets the Backoff used to retry conflicting updates of node taints and conditions.
+func SetBackoff(backoff wait.Backoff) {
+	backoffLock.Lock()
+	defer backoffLock.Unlock()
+	Backoff = backoff
+}
+
+// currentBackoff returns the current Backoff.
+func currentBackoff() wait.Backoff {
+	backoffLock.RLock()
+	defer backoffLock.RUnlock()
+	return Backoff
+}
+
 // AddOrUpdateTaintOnNode add taints to the node. If taint was added into node, it'll issue API calls
 // to update nodes; otherwise, no API calls. Return error if any.
 func AddOrUpdateTaintOnNode(ctx context.Context, c clientset.Interface, nodeName string, taints ...*v1.Taint) error {
@@ -35,7 +54,7 @@ func AddOrUpdateTaintOnNode(ctx context.Context, c clientset.Interface, nodeName
 		return nil
 	}
 	firstTry := true
-	return clientretry.RetryOnConflict(Backoff, func() error {
+	return clientretry.RetryOnConflict(currentBackoff(), func() error {
 		var err error
 		var oldNode *v1.Node
 		// First we try getting node from the API server cache, as it's cheaper. If it fails
@@ -92,7 +111,7 @@ func RemoveTaintOffNode(ctx context.Context, c clientset.Interface, nodeName str
 	}
 
 	firstTry := true
-	return clientretry.RetryOnConflict(Backoff, func() error {
+	return clientretry.RetryOnConflict(currentBackoff(), func() error {
 		var err error
 		var oldNode *v1.Node
 		// First we try getting node from the API server cache, as it's cheaper. If it fails
@@ -160,6 +179,10 @@ func UpdateNodeTaints(ctx context.Context, c clientset.Interface, nodeName strin
 
 	_, err := c.CoreV1().Nodes().Update(ctx, newNodeClone, metav1.UpdateOptions{})
 	if err != nil {
+		if apierrors.IsConflict(err) {
+			// conflicts are returned as is, to be retried with Backoff
+			return err
+		}
 		return fmt.Errorf("failed to create update taints for node %q: %v", nodeName, err)
 	}
 
@@ -22,6 +22,8 @@ Modifications Copyright SAP SE or an SAP affiliate company and Gardener contribu
 package options
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	drain "github.com/gardener/machine-controller-manager/pkg/util/provider/drain"
@@ -29,6 +31,7 @@ import (
 	"github.com/spf13/pflag"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	bootstraptokenutil "k8s.io/cluster-bootstrap/token/util"
 	"k8s.io/component-base/logs"
 
 	"github.com/gardener/machine-controller-manager/pkg/util/client/leaderelectionconfig"
@@ -74,6 +77,8 @@ func NewMCServer() *MCServer {
 				MachineSafetyOrphanVMsPeriod:             metav1.Duration{Duration: 15 * time.Minute},
 				MachineSafetyAPIServerStatusCheckPeriod:  metav1.Duration{Duration: 1 * time.Minute},
 				MachineSafetyAPIServerStatusCheckTimeout: metav1.Duration{Duration: 30 * time.Second},
+				MaxConcurrentReplacements:                0,
+				ReplacementCooldown:                      metav1.Duration{Duration: 0},
 			},
 		},
 	}
@@ -109,6 +114,8 @@ func (s *MCServer) AddFlags(fs *pflag.FlagSet) {
 
 	fs.DurationVar(&s.SafetyOptions.MachineSafetyOrphanVMsPeriod.Duration, "machine-safety-orphan-vms-period", s.SafetyOptions.MachineSafetyOrphanVMsPeriod.Duration, "Time period (in durartion) used to poll for orphan VMs by safety controller.")
 	fs.DurationVar(&s.SafetyOptions.MachineSafetyAPIServerStatusCheckPeriod.Duration, "machine-safety-apiserver-statuscheck-period", s.SafetyOptions.MachineSafetyAPIServerStatusCheckPeriod.Duration, "Time period (in duration) used to poll for APIServer's health by safety controller")
+	fs.Int32Var(&s.SafetyOptions.MaxConcurrentReplacements, "machine-max-concurrent-replacements", s.SafetyOptions.MaxConcurrentReplacements, "Maximum number of failed machines which are replaced concurrently. Defaults to 0, which means unlimited.")
+	fs.DurationVar(&s.SafetyOptions.ReplacementCooldown.Duration, "machine-replacement-cooldown", s.SafetyOptions.ReplacementCooldown.Duration, "Cooldown (in duration) after replacing a failed machine of a machine-set, within which no further machine of it is replaced. Defaults to 0, which means no cooldown.")
 	fs.StringVar(&s.NodeConditions, "node-conditions", s.NodeConditions, "List of comma-separated/case-sensitive node-conditions which when set to True will change machine to a failed state after MachineHealthTimeout duration. It may further be replaced with a new machine if the machine is backed by a machine-set object.")
 	fs.StringVar(&s.BootstrapTokenAuthExtraGroups, "bootstrap-token-auth-extra-groups", s.BootstrapTokenAuthExtraGroups, "Comma-separated list of groups to set bootstrap token's \"auth-extra-groups\" field to")
 
@@ -122,6 +129,23 @@ func (s *MCServer) AddFlags(fs *pflag.FlagSet) {
 // Validate is used to validate the options and config before launching the controller manager
 func (s *MCServer) Validate() error {
 	var errs []error
-	// TODO add validation
+	if err := leaderelectionconfig.ValidateResourceLock(s.LeaderElection.ResourceLock); err != nil {
+		errs = append(errs, err)
+	}
+	for _, group := range strings.Split(s.BootstrapTokenAuthExtraGroups, ",") {
+		if group = strings.TrimSpace(group); group == "" {
+			continue
+		}
+		if err := bootstraptokenutil.ValidateBootstrapGroupName(group); err != nil {
+			errs = append(errs, fmt.Errorf("invalid bootstrap-token-auth-extra-groups: %w", err))
+		}
+	}
+	if s.SafetyOptions.MaxConcurrentReplacements < 0 {
+		errs = append(errs, fmt.Errorf("machine-max-concurrent-replacements must not be negative, got %d", s.SafetyOptions.MaxConcurrentReplacements))
+	}
+	if s.SafetyOptions.ReplacementCooldown.Duration < 0 {
+		errs = append(errs, fmt.Errorf("machine-replacement-cooldown must not be negative, got %v", s.SafetyOptions.ReplacementCooldown.Duration))
+	}
+	// TODO add further validation
 	return utilerrors.NewAggregate(errs)
 }
@@ -0,0 +1,24 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package options_test
+
+import (
+	"flag"
+	"io"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/klog/v2"
+)
+
+func TestOptions(t *testing.T) {
+	klog.SetOutput(io.Discard)
+	flags := &flag.FlagSet{}
+	klog.InitFlags(flags)
+	_ = flags.Set("logtostderr", "false")
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Options Suite")
+}
@@ -0,0 +1,70 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package options_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/gardener/machine-controller-manager/pkg/util/provider/app/options"
+)
+
+var _ = Describe("options", func() {
+	Describe("#Validate", func() {
+		DescribeTable("should validate the bootstrap token auth extra groups",
+			func(groups string, expectedErr string) {
+				s := options.NewMCServer()
+				s.BootstrapTokenAuthExtraGroups = groups
+
+				err := s.Validate()
+				if expectedErr == "" {
+					Expect(err).ToNot(HaveOccurred())
+				} else {
+					Expect(err).To(MatchError(ContainSubstring(expectedErr)))
+				}
+			},
+			Entry("no groups", "", ""),
+			Entry("single bootstrap group", "system:bootstrappers:worker", ""),
+			Entry("multiple groups with whitespace and empty entries", "system:bootstrappers:worker, system:bootstrappers:gpu,", ""),
+			Entry("group without the bootstrappers prefix", "system:nodes", "invalid bootstrap-token-auth-extra-groups"),
+			Entry("one invalid group among valid ones", "system:bootstrappers:worker,system:bootstrappers:Invalid_Group", "invalid bootstrap-token-auth-extra-groups"),
+		)
+
+		DescribeTable("should validate the maximum number of concurrent replacements",
+			func(maxConcurrentReplacements int32, expectErr bool) {
+				s := options.NewMCServer()
+				s.SafetyOptions.MaxConcurrentReplacements = maxConcurrentReplacements
+
+				if expectErr {
+					Expect(s.Validate()).To(MatchError(ContainSubstring("machine-max-concurrent-replacements must not be negative")))
+				} else {
+					Expect(s.Validate()).To(Succeed())
+				}
+			},
+			Entry("unlimited by default", options.NewMCServer().SafetyOptions.MaxConcurrentReplacements, false),
+			Entry("positive limit", int32(3), false),
+			Entry("negative limit", int32(-1), true),
+		)
+
+		DescribeTable("should validate the replacement cooldown",
+			func(cooldown time.Duration, expectErr bool) {
+				s := options.NewMCServer()
+				s.SafetyOptions.ReplacementCooldown = metav1.Duration{Duration: cooldown}
+
+				if expectErr {
+					Expect(s.Validate()).To(MatchError(ContainSubstring("machine-replacement-cooldown must not be negative")))
+				} else {
+					Expect(s.Validate()).To(Succeed())
+				}
+			},
+			Entry("no cooldown by default", options.NewMCServer().SafetyOptions.ReplacementCooldown.Duration, false),
+			Entry("positive cooldown", 5*time.Minute, false),
+			Entry("negative cooldown", -time.Second, true),
+		)
+	})
+})
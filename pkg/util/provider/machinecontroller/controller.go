@@ -230,6 +230,7 @@ type controller struct {
 
 	recorder                record.EventRecorder
 	safetyOptions           options.SafetyOptions
+	apiServerSafetyState    SafetyState
 	internalExternalScheme  *runtime.Scheme
 	driver                  driver.Driver
 	volumeAttachmentHandler *drain.VolumeAttachmentHandler
@@ -304,6 +305,7 @@ func (c *controller) Run(workers int, stopCh <-chan struct{}) {
 	// will collect metrics to expose them via the metrics endpoint of the mcm
 	// every time when the endpoint is called.
 	prometheus.MustRegister(c)
+	prometheus.MustRegister(worker.ReconcileQueueDepth)
 
 	for i := 0; i < workers; i++ {
 		worker.Run(c.secretQueue, "ClusterSecret", worker.DefaultMaxRetries, true, c.reconcileClusterSecretKey, stopCh, &waitGroup)
@@ -315,6 +317,18 @@ func (c *controller) Run(workers int, stopCh <-chan struct{}) {
 		worker.Run(c.machineSafetyAPIServerQueue, "ClusterMachineAPIServer", worker.DefaultMaxRetries, true, c.reconcileClusterMachineSafetyAPIServer, stopCh, &waitGroup)
 	}
 
+	for controller, queue := range map[string]workqueue.Interface{
+		"ClusterSecret":                 c.secretQueue,
+		"ClusterMachineClass":           c.machineClassQueue,
+		"ClusterNode":                   c.nodeQueue,
+		"ClusterMachine":                c.machineQueue,
+		"ClusterMachineTermination":     c.machineTerminationQueue,
+		"ClusterMachineSafetyOrphanVMs": c.machineSafetyOrphanVMsQueue,
+		"ClusterMachineAPIServer":       c.machineSafetyAPIServerQueue,
+	} {
+		worker.NewQueueDepthReporter(controller, queue).Run(worker.DefaultQueueDepthReportPeriod, stopCh, &waitGroup)
+	}
+
 	<-stopCh
 	klog.V(1).Info("Shutting down Machine Controller Manager ")
 	handlers.UpdateHealth(false)
@@ -60,6 +60,8 @@ func (c *controller) updateMachine(oldObj, newObj interface{}) {
 		return
 	}
 
+	RecordPhaseTransition(c.recorder, newMachine, oldMachine.Status.CurrentStatus.Phase, newMachine.Status.CurrentStatus.Phase)
+
 	if oldMachine.Generation == newMachine.Generation {
 		klog.V(3).Infof("Skipping non-spec updates for machine %s", oldMachine.Name)
 		return
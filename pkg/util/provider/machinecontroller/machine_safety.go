@@ -53,7 +53,6 @@ func (c *controller) reconcileClusterMachineSafetyOrphanVMs(_ string) error {
 func (c *controller) reconcileClusterMachineSafetyAPIServer(_ string) error {
 	ctx := context.Background()
 	statusCheckTimeout := c.safetyOptions.MachineSafetyAPIServerStatusCheckTimeout.Duration
-	statusCheckPeriod := c.safetyOptions.MachineSafetyAPIServerStatusCheckPeriod.Duration
 
 	klog.V(4).Infof("reconcileClusterMachineSafetyAPIServer: Start")
 	defer klog.V(4).Infof("reconcileClusterMachineSafetyAPIServer: Stop")
@@ -102,7 +101,10 @@ func (c *controller) reconcileClusterMachineSafetyAPIServer(_ string) error {
 
 			c.safetyOptions.MachineControllerFrozen = false
 			c.safetyOptions.APIserverInactiveStartTime = time.Time{}
+			c.apiServerSafetyState.RecordSuccess()
 			klog.V(2).Infof("SafetyController: UnFreezing Machine Controller")
+		} else {
+			c.apiServerSafetyState.RecordFailure()
 		}
 	} else {
 		// MachineController is not frozen
@@ -118,13 +120,15 @@ func (c *controller) reconcileClusterMachineSafetyAPIServer(_ string) error {
 				klog.V(2).Infof("SafetyController: Freezing Machine Controller")
 			}
 
-			// Re-enqueue the safety check more often if APIServer is not active and is not frozen yet
-			defer c.machineSafetyAPIServerQueue.AddAfter("", statusCheckTimeout/5)
-			return nil
+			c.apiServerSafetyState.RecordFailure()
+		} else {
+			c.apiServerSafetyState.RecordSuccess()
 		}
 	}
 
-	defer c.machineSafetyAPIServerQueue.AddAfter("", statusCheckPeriod)
+	// Re-enqueue the safety check more often while APIServer is not active, backing off with each failed check
+	now := time.Now()
+	defer c.machineSafetyAPIServerQueue.AddAfter("", NextSafetyPoll(&c.apiServerSafetyState, c.safetyOptions, now).Sub(now))
 	return nil
 }
 
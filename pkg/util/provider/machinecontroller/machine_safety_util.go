@@ -6,7 +6,11 @@ package controller
 
 import (
 	"context"
+	"time"
 
+	"github.com/gardener/machine-controller-manager/pkg/apis/machine/v1alpha1"
+	"github.com/gardener/machine-controller-manager/pkg/util/provider/machineutils"
+	"github.com/gardener/machine-controller-manager/pkg/util/provider/options"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/klog/v2"
@@ -33,3 +37,173 @@ func (c *controller) updateNodeWithAnnotations(ctx context.Context, node *v1.Nod
 
 	return nil
 }
+
+// TimeInCurrentPhase returns the time elapsed at now since the machine entered its current phase, as recorded by
+// the last update of its current status. It returns zero if the timestamp is unset.
+func TimeInCurrentPhase(machine *v1alpha1.Machine, now time.Time) time.Duration {
+	lastUpdateTime := machine.Status.CurrentStatus.LastUpdateTime
+	if lastUpdateTime.IsZero() || now.Before(lastUpdateTime.Time) {
+		return 0
+	}
+	return now.Sub(lastUpdateTime.Time)
+}
+
+// IsMachineCreationTimedOut returns true if the machine is still being created (Pending or CrashLoopBackOff)
+// and has exceeded its creation timeout. The timeout set on the machine-object takes precedence over the global one.
+// Pending machines are timed from their last status update, CrashLoopBackOff machines from their creation.
+func IsMachineCreationTimedOut(machine *v1alpha1.Machine, o options.SafetyOptions, now time.Time) bool {
+	timeout := o.MachineCreationTimeout.Duration
+	if machine.Spec.MachineConfiguration != nil && machine.Spec.MachineConfiguration.MachineCreationTimeout != nil {
+		timeout = machine.Spec.MachineConfiguration.MachineCreationTimeout.Duration
+	}
+
+	switch machine.Status.CurrentStatus.Phase {
+	case v1alpha1.MachinePending:
+		return TimeInCurrentPhase(machine, now) > timeout
+	case v1alpha1.MachineCrashLoopBackOff:
+		return now.Sub(machine.CreationTimestamp.Time) > timeout
+	default:
+		return false
+	}
+}
+
+// Reasons returned by ShouldMarkFailed for machines which are to be marked as failed.
+const (
+	// ReasonCreationTimeout is returned for machines which did not join the cluster within the creation timeout.
+	ReasonCreationTimeout = "CreationTimeout"
+	// ReasonHealthTimeout is returned for machines whose node stayed unhealthy beyond the health timeout.
+	ReasonHealthTimeout = "HealthTimeout"
+	// ReasonInPlaceUpdateTimeout is returned for machines whose in-place update exceeded the in-place update timeout.
+	ReasonInPlaceUpdateTimeout = "InPlaceUpdateTimeout"
+)
+
+// ShouldMarkFailed returns true along with the reason if any of the creation, health or in-place update timeouts
+// has expired for the machine. The node backing the machine may be nil if it has not joined or went missing; a
+// machine whose node is healthy again with respect to the given nodeConditions is not considered timed out.
+func ShouldMarkFailed(machine *v1alpha1.Machine, node *v1.Node, o options.SafetyOptions, nodeConditions []string, now time.Time) (bool, string) {
+	switch machine.Status.CurrentStatus.Phase {
+	case v1alpha1.MachinePending, v1alpha1.MachineCrashLoopBackOff:
+		if IsMachineCreationTimedOut(machine, o, now) {
+			return true, ReasonCreationTimeout
+		}
+	case v1alpha1.MachineUnknown:
+		if (node == nil || !isNodeHealthy(node, nodeConditions)) && TimeInCurrentPhase(machine, now) > EffectiveHealthTimeout(machine, o) {
+			return true, ReasonHealthTimeout
+		}
+	case v1alpha1.MachineInPlaceUpdating:
+		if TimeInCurrentPhase(machine, now) > o.MachineInPlaceUpdateTimeout.Duration {
+			return true, ReasonInPlaceUpdateTimeout
+		}
+	}
+	return false, ""
+}
+
+// FindCreationTimedOutMachines returns the machines which are stuck in creation beyond their creation timeout.
+// The safety controller marks these machines as failed so that they are replaced.
+func FindCreationTimedOutMachines(machines []*v1alpha1.Machine, o options.SafetyOptions, now time.Time) []*v1alpha1.Machine {
+	var timedOut []*v1alpha1.Machine
+	for _, machine := range machines {
+		if IsMachineCreationTimedOut(machine, o, now) {
+			timedOut = append(timedOut, machine)
+		}
+	}
+	return timedOut
+}
+
+// CanStartReplacement returns true if another failed machine may be replaced while the given number of
+// replacements is in flight, bounded by MaxConcurrentReplacements. A zero MaxConcurrentReplacements means unlimited.
+func CanStartReplacement(inFlight int32, o options.SafetyOptions) bool {
+	if o.MaxConcurrentReplacements <= 0 {
+		return true
+	}
+	return inFlight < o.MaxConcurrentReplacements
+}
+
+// CanReplace returns true if a failed machine may be replaced at now, i.e. if the cooldown has passed since the
+// last replacement. Back-to-back replacements could otherwise mask a persistent problem. A zero lastReplacementTime
+// or a non-positive cooldown never blocks a replacement.
+func CanReplace(lastReplacementTime time.Time, now time.Time, cooldown time.Duration) bool {
+	if cooldown <= 0 || lastReplacementTime.IsZero() {
+		return true
+	}
+	return now.Sub(lastReplacementTime) >= cooldown
+}
+
+// SafetyState tracks the outcome of the APIServer status checks of the safety controller.
+type SafetyState struct {
+	// ConsecutiveFailures is the number of status checks which failed in a row.
+	ConsecutiveFailures int
+}
+
+// RecordSuccess records a successful status check, resetting the backoff.
+func (s *SafetyState) RecordSuccess() {
+	s.ConsecutiveFailures = 0
+}
+
+// RecordFailure records a failed status check, increasing the backoff.
+func (s *SafetyState) RecordFailure() {
+	s.ConsecutiveFailures++
+}
+
+// NextSafetyPoll returns when the next APIServer status check should occur. While the APIServer is reachable, it
+// is polled every MachineSafetyAPIServerStatusCheckPeriod. While it is unreachable, it is polled after a fifth of
+// MachineSafetyAPIServerStatusCheckTimeout, doubling with each consecutive failure up to the steady-state period.
+// Until the machine controller is frozen, the interval is capped so that the status is checked again once the
+// APIServer has been unreachable for MachineSafetyAPIServerStatusCheckTimeout, to freeze the controller in time.
+func NextSafetyPoll(state *SafetyState, o options.SafetyOptions, now time.Time) time.Time {
+	period := o.MachineSafetyAPIServerStatusCheckPeriod.Duration
+	if state == nil || state.ConsecutiveFailures == 0 {
+		return now.Add(period)
+	}
+
+	interval := o.MachineSafetyAPIServerStatusCheckTimeout.Duration / 5
+	if interval <= 0 {
+		return now.Add(period)
+	}
+	for i := 1; i < state.ConsecutiveFailures && interval < period; i++ {
+		interval *= 2
+	}
+	if period > 0 && interval > period {
+		interval = period
+	}
+	if !o.MachineControllerFrozen && !o.APIserverInactiveStartTime.IsZero() {
+		untilFreeze := o.APIserverInactiveStartTime.Add(o.MachineSafetyAPIServerStatusCheckTimeout.Duration).Sub(now)
+		if untilFreeze >= 0 && untilFreeze < interval {
+			interval = untilFreeze
+		}
+	}
+	return now.Add(interval)
+}
+
+// EffectiveCreationTimeout returns the machine creation timeout for the machines of the given machineSet. The
+// timeout set via the MachineSetCreationTimeout annotation takes precedence over the global MachineCreationTimeout.
+// Invalid or non-positive values are logged and ignored.
+func EffectiveCreationTimeout(ms *v1alpha1.MachineSet, defaults options.SafetyOptions) time.Duration {
+	if ms == nil {
+		return defaults.MachineCreationTimeout.Duration
+	}
+	value, ok := ms.Annotations[machineutils.MachineSetCreationTimeout]
+	if !ok {
+		return defaults.MachineCreationTimeout.Duration
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout <= 0 {
+		klog.Warningf("Invalid value %q for annotation %q on machineSet %q, using the default creation timeout %v", value, machineutils.MachineSetCreationTimeout, ms.Name, defaults.MachineCreationTimeout.Duration)
+		return defaults.MachineCreationTimeout.Duration
+	}
+	return timeout
+}
+
+// EffectiveHealthTimeout returns the machine health timeout for the given machine. The timeout set via the
+// MachineHealthTimeout annotation takes precedence over the one of the machine configuration, which in turn
+// takes precedence over the global MachineHealthTimeout. Invalid or non-positive values are logged and ignored.
+func EffectiveHealthTimeout(machine *v1alpha1.Machine, defaults options.SafetyOptions) time.Duration {
+	timeout := defaults.MachineHealthTimeout.Duration
+	if machine == nil {
+		return timeout
+	}
+	if machine.Spec.MachineConfiguration != nil && machine.Spec.MachineConfiguration.MachineHealthTimeout != nil {
+		timeout = machine.Spec.MachineConfiguration.MachineHealthTimeout.Duration
+	}
+	return machineutils.EffectiveHealthTimeout(machine, timeout)
+}
@@ -6,7 +6,10 @@ package controller
 
 import (
 	"context"
+	"time"
 
+	machinev1 "github.com/gardener/machine-controller-manager/pkg/apis/machine/v1alpha1"
+	"github.com/gardener/machine-controller-manager/pkg/util/provider/options"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
@@ -132,4 +135,353 @@ var _ = Describe("machine_safety_util", func() {
 
 	})
 
+	Describe("#FindCreationTimedOutMachines", func() {
+		var (
+			now           time.Time
+			safetyOptions options.SafetyOptions
+		)
+
+		newTestMachine := func(name string, phase machinev1.MachinePhase, created, lastUpdated time.Time) *machinev1.Machine {
+			return &machinev1.Machine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              name,
+					Namespace:         testNamespace,
+					CreationTimestamp: metav1.NewTime(created),
+				},
+				Status: machinev1.MachineStatus{
+					CurrentStatus: machinev1.CurrentStatus{
+						Phase:          phase,
+						LastUpdateTime: metav1.NewTime(lastUpdated),
+					},
+				},
+			}
+		}
+
+		BeforeEach(func() {
+			now = time.Now()
+			safetyOptions = options.SafetyOptions{
+				MachineCreationTimeout: metav1.Duration{Duration: 20 * time.Minute},
+			}
+		})
+
+		It("should return Pending machines whose last update is older than the creation timeout", func() {
+			stuck := newTestMachine("stuck", machinev1.MachinePending, now.Add(-time.Hour), now.Add(-21*time.Minute))
+			fresh := newTestMachine("fresh", machinev1.MachinePending, now.Add(-time.Hour), now.Add(-19*time.Minute))
+
+			Expect(FindCreationTimedOutMachines([]*machinev1.Machine{stuck, fresh}, safetyOptions, now)).To(ConsistOf(stuck))
+		})
+
+		It("should time CrashLoopBackOff machines from their creation", func() {
+			stuck := newTestMachine("stuck", machinev1.MachineCrashLoopBackOff, now.Add(-21*time.Minute), now)
+			fresh := newTestMachine("fresh", machinev1.MachineCrashLoopBackOff, now.Add(-19*time.Minute), now.Add(-time.Hour))
+
+			Expect(FindCreationTimedOutMachines([]*machinev1.Machine{stuck, fresh}, safetyOptions, now)).To(ConsistOf(stuck))
+		})
+
+		It("should ignore machines in other phases", func() {
+			running := newTestMachine("running", machinev1.MachineRunning, now.Add(-time.Hour), now.Add(-time.Hour))
+			unknown := newTestMachine("unknown", machinev1.MachineUnknown, now.Add(-time.Hour), now.Add(-time.Hour))
+
+			Expect(FindCreationTimedOutMachines([]*machinev1.Machine{running, unknown}, safetyOptions, now)).To(BeEmpty())
+		})
+
+		It("should honour the creation timeout set on the machine", func() {
+			machine := newTestMachine("machine", machinev1.MachinePending, now.Add(-time.Hour), now.Add(-6*time.Minute))
+			machine.Spec.MachineConfiguration = &machinev1.MachineConfiguration{
+				MachineCreationTimeout: &metav1.Duration{Duration: 5 * time.Minute},
+			}
+
+			Expect(FindCreationTimedOutMachines([]*machinev1.Machine{machine}, safetyOptions, now)).To(ConsistOf(machine))
+		})
+	})
+
+	Describe("#TimeInCurrentPhase", func() {
+		now := time.Now()
+		newPhaseMachine := func(lastUpdateTime time.Time) *machinev1.Machine {
+			return &machinev1.Machine{
+				ObjectMeta: metav1.ObjectMeta{Name: "machine-0", Namespace: testNamespace},
+				Status: machinev1.MachineStatus{
+					CurrentStatus: machinev1.CurrentStatus{
+						Phase:          machinev1.MachinePending,
+						LastUpdateTime: metav1.NewTime(lastUpdateTime),
+					},
+				},
+			}
+		}
+
+		DescribeTable("##table",
+			func(machine *machinev1.Machine, expected time.Duration) {
+				Expect(TimeInCurrentPhase(machine, now)).To(Equal(expected))
+			},
+			Entry("should return the time since the last status update", newPhaseMachine(now.Add(-5*time.Minute)), 5*time.Minute),
+			Entry("should return zero for a status updated just now", newPhaseMachine(now), time.Duration(0)),
+			Entry("should return zero for an unset timestamp", newPhaseMachine(time.Time{}), time.Duration(0)),
+			Entry("should return zero for a timestamp in the future", newPhaseMachine(now.Add(time.Minute)), time.Duration(0)),
+		)
+	})
+
+	Describe("#NextSafetyPoll", func() {
+		now := time.Now()
+		safetyOptions := options.SafetyOptions{
+			MachineSafetyAPIServerStatusCheckPeriod:  metav1.Duration{Duration: time.Minute},
+			MachineSafetyAPIServerStatusCheckTimeout: metav1.Duration{Duration: 30 * time.Second},
+		}
+
+		DescribeTable("##table",
+			func(consecutiveFailures int, expected time.Duration) {
+				state := &SafetyState{ConsecutiveFailures: consecutiveFailures}
+				Expect(NextSafetyPoll(state, safetyOptions, now)).To(Equal(now.Add(expected)))
+			},
+			Entry("should poll after the status check period while healthy", 0, time.Minute),
+			Entry("should poll after a fifth of the status check timeout after the first failure", 1, 6*time.Second),
+			Entry("should double the interval with each consecutive failure", 3, 24*time.Second),
+			Entry("should not back off beyond the status check period", 10, time.Minute),
+		)
+
+		It("should reset the backoff on recovery", func() {
+			state := &SafetyState{}
+			state.RecordFailure()
+			state.RecordFailure()
+			Expect(NextSafetyPoll(state, safetyOptions, now)).To(Equal(now.Add(12 * time.Second)))
+
+			state.RecordSuccess()
+			Expect(NextSafetyPoll(state, safetyOptions, now)).To(Equal(now.Add(time.Minute)))
+			state.RecordFailure()
+			Expect(NextSafetyPoll(state, safetyOptions, now)).To(Equal(now.Add(6 * time.Second)))
+		})
+
+		It("should check the status once the status check timeout is over to freeze in time", func() {
+			safetyOptions := options.SafetyOptions{
+				MachineSafetyAPIServerStatusCheckPeriod:  metav1.Duration{Duration: time.Minute},
+				MachineSafetyAPIServerStatusCheckTimeout: metav1.Duration{Duration: time.Minute},
+			}
+			state := &SafetyState{}
+			start := now
+
+			// Simulate the checks of an unreachable APIServer as done by the safety controller, each taking a millisecond.
+			var frozenAt time.Time
+			for check := start; frozenAt.IsZero() && check.Before(start.Add(5*time.Minute)); {
+				if safetyOptions.APIserverInactiveStartTime.IsZero() {
+					safetyOptions.APIserverInactiveStartTime = check
+				}
+				if check.Sub(safetyOptions.APIserverInactiveStartTime) > safetyOptions.MachineSafetyAPIServerStatusCheckTimeout.Duration {
+					safetyOptions.MachineControllerFrozen = true
+					frozenAt = check
+				}
+				state.RecordFailure()
+				check = NextSafetyPoll(state, safetyOptions, check).Add(time.Millisecond)
+			}
+
+			Expect(frozenAt).To(BeTemporally("~", start.Add(time.Minute), time.Second))
+		})
+
+		It("should back off beyond the status check timeout once frozen", func() {
+			safetyOptions := options.SafetyOptions{
+				MachineSafetyAPIServerStatusCheckPeriod:  metav1.Duration{Duration: time.Minute},
+				MachineSafetyAPIServerStatusCheckTimeout: metav1.Duration{Duration: 30 * time.Second},
+				APIserverInactiveStartTime:               now.Add(-40 * time.Second),
+				MachineControllerFrozen:                  true,
+			}
+
+			Expect(NextSafetyPoll(&SafetyState{ConsecutiveFailures: 3}, safetyOptions, now)).To(Equal(now.Add(24 * time.Second)))
+		})
+	})
+
+	Describe("#CanStartReplacement", func() {
+		It("should block at the configured limit and re-open as replacements complete", func() {
+			safetyOptions := options.SafetyOptions{MaxConcurrentReplacements: 2}
+			inFlight := int32(0)
+
+			Expect(CanStartReplacement(inFlight, safetyOptions)).To(BeTrue())
+			inFlight++
+			Expect(CanStartReplacement(inFlight, safetyOptions)).To(BeTrue())
+			inFlight++
+			Expect(CanStartReplacement(inFlight, safetyOptions)).To(BeFalse())
+
+			inFlight--
+			Expect(CanStartReplacement(inFlight, safetyOptions)).To(BeTrue())
+		})
+
+		It("should not limit replacements by default", func() {
+			Expect(CanStartReplacement(1000, options.SafetyOptions{})).To(BeTrue())
+		})
+	})
+
+	Describe("#CanReplace", func() {
+		now := time.Now()
+
+		DescribeTable("##table",
+			func(lastReplacementTime time.Time, cooldown time.Duration, expected bool) {
+				Expect(CanReplace(lastReplacementTime, now, cooldown)).To(Equal(expected))
+			},
+			Entry("should refuse within the cooldown", now.Add(-4*time.Minute), 5*time.Minute, false),
+			Entry("should refuse just before the cooldown edge", now.Add(-5*time.Minute+time.Nanosecond), 5*time.Minute, false),
+			Entry("should allow exactly at the cooldown edge", now.Add(-5*time.Minute), 5*time.Minute, true),
+			Entry("should allow after the cooldown", now.Add(-6*time.Minute), 5*time.Minute, true),
+			Entry("should allow without a cooldown", now, time.Duration(0), true),
+			Entry("should allow without a previous replacement", time.Time{}, 5*time.Minute, true),
+		)
+	})
+
+	Describe("#EffectiveCreationTimeout", func() {
+		safetyOptions := options.SafetyOptions{
+			MachineCreationTimeout: metav1.Duration{Duration: 20 * time.Minute},
+		}
+		newAnnotatedMachineSet := func(annotations map[string]string) *machinev1.MachineSet {
+			return &machinev1.MachineSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "machineset-0", Namespace: testNamespace, Annotations: annotations},
+			}
+		}
+
+		DescribeTable("##table",
+			func(ms *machinev1.MachineSet, expected time.Duration) {
+				Expect(EffectiveCreationTimeout(ms, safetyOptions)).To(Equal(expected))
+			},
+			Entry("should use the timeout from the annotation", newAnnotatedMachineSet(map[string]string{machineutils.MachineSetCreationTimeout: "30m"}), 30*time.Minute),
+			Entry("should fall back to the default without the annotation", newAnnotatedMachineSet(nil), 20*time.Minute),
+			Entry("should fall back to the default for an invalid value", newAnnotatedMachineSet(map[string]string{machineutils.MachineSetCreationTimeout: "soon"}), 20*time.Minute),
+			Entry("should fall back to the default for a negative value", newAnnotatedMachineSet(map[string]string{machineutils.MachineSetCreationTimeout: "-5m"}), 20*time.Minute),
+			Entry("should fall back to the default for a nil machineSet", nil, 20*time.Minute),
+		)
+	})
+
+	Describe("#EffectiveHealthTimeout", func() {
+		safetyOptions := options.SafetyOptions{
+			MachineHealthTimeout: metav1.Duration{Duration: 10 * time.Minute},
+		}
+		newAnnotatedMachine := func(annotations map[string]string) *machinev1.Machine {
+			return &machinev1.Machine{
+				ObjectMeta: metav1.ObjectMeta{Name: "machine-0", Namespace: testNamespace, Annotations: annotations},
+			}
+		}
+		configuredMachine := newAnnotatedMachine(map[string]string{machineutils.MachineHealthTimeout: "invalid"})
+		configuredMachine.Spec.MachineConfiguration = &machinev1.MachineConfiguration{
+			MachineHealthTimeout: &metav1.Duration{Duration: 15 * time.Minute},
+		}
+
+		DescribeTable("##table",
+			func(machine *machinev1.Machine, expected time.Duration) {
+				Expect(EffectiveHealthTimeout(machine, safetyOptions)).To(Equal(expected))
+			},
+			Entry("should use the timeout from the annotation", newAnnotatedMachine(map[string]string{machineutils.MachineHealthTimeout: "2m"}), 2*time.Minute),
+			Entry("should fall back to the default without the annotation", newAnnotatedMachine(nil), 10*time.Minute),
+			Entry("should fall back to the default for an invalid value", newAnnotatedMachine(map[string]string{machineutils.MachineHealthTimeout: "soon"}), 10*time.Minute),
+			Entry("should fall back to the default for a negative value", newAnnotatedMachine(map[string]string{machineutils.MachineHealthTimeout: "-5m"}), 10*time.Minute),
+			Entry("should fall back to the machine configuration for an invalid value", configuredMachine, 15*time.Minute),
+			Entry("should fall back to the default for a nil machine", nil, 10*time.Minute),
+		)
+	})
+
+	Describe("#ShouldMarkFailed", func() {
+		var (
+			now           time.Time
+			safetyOptions options.SafetyOptions
+		)
+
+		newPhaseMachine := func(phase machinev1.MachinePhase, created, lastUpdated time.Time) *machinev1.Machine {
+			return &machinev1.Machine{
+				ObjectMeta: metav1.ObjectMeta{Name: "machine-0", Namespace: testNamespace, CreationTimestamp: metav1.NewTime(created)},
+				Status: machinev1.MachineStatus{
+					CurrentStatus: machinev1.CurrentStatus{Phase: phase, LastUpdateTime: metav1.NewTime(lastUpdated)},
+				},
+			}
+		}
+		newConditionNode := func(ready corev1.ConditionStatus, conditions ...corev1.NodeCondition) *corev1.Node {
+			return &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: "node-0"},
+				Status: corev1.NodeStatus{
+					Conditions: append([]corev1.NodeCondition{{Type: corev1.NodeReady, Status: ready}}, conditions...),
+				},
+			}
+		}
+		nodeConditions := []string{"KernelDeadlock"}
+
+		BeforeEach(func() {
+			now = time.Now()
+			safetyOptions = options.SafetyOptions{
+				MachineCreationTimeout:      metav1.Duration{Duration: 20 * time.Minute},
+				MachineHealthTimeout:        metav1.Duration{Duration: 10 * time.Minute},
+				MachineInPlaceUpdateTimeout: metav1.Duration{Duration: 30 * time.Minute},
+			}
+		})
+
+		It("should fail Pending machines beyond the creation timeout", func() {
+			machine := newPhaseMachine(machinev1.MachinePending, now.Add(-time.Hour), now.Add(-21*time.Minute))
+
+			failed, reason := ShouldMarkFailed(machine, nil, safetyOptions, nodeConditions, now)
+			Expect(failed).To(BeTrue())
+			Expect(reason).To(Equal(ReasonCreationTimeout))
+		})
+
+		It("should fail CrashLoopBackOff machines created before the creation timeout", func() {
+			machine := newPhaseMachine(machinev1.MachineCrashLoopBackOff, now.Add(-21*time.Minute), now)
+
+			failed, reason := ShouldMarkFailed(machine, nil, safetyOptions, nodeConditions, now)
+			Expect(failed).To(BeTrue())
+			Expect(reason).To(Equal(ReasonCreationTimeout))
+		})
+
+		It("should fail Unknown machines with an unready node beyond the health timeout", func() {
+			machine := newPhaseMachine(machinev1.MachineUnknown, now.Add(-time.Hour), now.Add(-11*time.Minute))
+
+			failed, reason := ShouldMarkFailed(machine, newConditionNode(corev1.ConditionFalse), safetyOptions, nodeConditions, now)
+			Expect(failed).To(BeTrue())
+			Expect(reason).To(Equal(ReasonHealthTimeout))
+		})
+
+		It("should fail Unknown machines with a missing node beyond the health timeout", func() {
+			machine := newPhaseMachine(machinev1.MachineUnknown, now.Add(-time.Hour), now.Add(-11*time.Minute))
+
+			failed, reason := ShouldMarkFailed(machine, nil, safetyOptions, nodeConditions, now)
+			Expect(failed).To(BeTrue())
+			Expect(reason).To(Equal(ReasonHealthTimeout))
+		})
+
+		It("should fail Unknown machines with a bad node condition beyond the health timeout", func() {
+			machine := newPhaseMachine(machinev1.MachineUnknown, now.Add(-time.Hour), now.Add(-11*time.Minute))
+			node := newConditionNode(corev1.ConditionTrue, corev1.NodeCondition{Type: "KernelDeadlock", Status: corev1.ConditionTrue})
+
+			failed, reason := ShouldMarkFailed(machine, node, safetyOptions, nodeConditions, now)
+			Expect(failed).To(BeTrue())
+			Expect(reason).To(Equal(ReasonHealthTimeout))
+		})
+
+		It("should honour the health timeout set on the machine", func() {
+			machine := newPhaseMachine(machinev1.MachineUnknown, now.Add(-time.Hour), now.Add(-11*time.Minute))
+			machine.Spec.MachineConfiguration = &machinev1.MachineConfiguration{
+				MachineHealthTimeout: &metav1.Duration{Duration: 15 * time.Minute},
+			}
+
+			failed, _ := ShouldMarkFailed(machine, nil, safetyOptions, nodeConditions, now)
+			Expect(failed).To(BeFalse())
+		})
+
+		It("should not fail Unknown machines whose node recovered", func() {
+			machine := newPhaseMachine(machinev1.MachineUnknown, now.Add(-time.Hour), now.Add(-11*time.Minute))
+
+			failed, reason := ShouldMarkFailed(machine, newConditionNode(corev1.ConditionTrue), safetyOptions, nodeConditions, now)
+			Expect(failed).To(BeFalse())
+			Expect(reason).To(BeEmpty())
+		})
+
+		It("should fail machines beyond the in-place update timeout", func() {
+			machine := newPhaseMachine(machinev1.MachineInPlaceUpdating, now.Add(-time.Hour), now.Add(-31*time.Minute))
+
+			failed, reason := ShouldMarkFailed(machine, newConditionNode(corev1.ConditionTrue), safetyOptions, nodeConditions, now)
+			Expect(failed).To(BeTrue())
+			Expect(reason).To(Equal(ReasonInPlaceUpdateTimeout))
+		})
+
+		It("should not fail machines within their timeouts or in other phases", func() {
+			for _, machine := range []*machinev1.Machine{
+				newPhaseMachine(machinev1.MachinePending, now.Add(-time.Hour), now.Add(-19*time.Minute)),
+				newPhaseMachine(machinev1.MachineUnknown, now.Add(-time.Hour), now.Add(-9*time.Minute)),
+				newPhaseMachine(machinev1.MachineInPlaceUpdating, now.Add(-time.Hour), now.Add(-29*time.Minute)),
+				newPhaseMachine(machinev1.MachineRunning, now.Add(-time.Hour), now.Add(-time.Hour)),
+			} {
+				failed, _ := ShouldMarkFailed(machine, nil, safetyOptions, nodeConditions, now)
+				Expect(failed).To(BeFalse(), "machine in phase %s", machine.Status.CurrentStatus.Phase)
+			}
+		})
+	})
 })
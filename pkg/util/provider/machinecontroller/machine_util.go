@@ -41,8 +41,8 @@ import (
 	"github.com/gardener/machine-controller-manager/pkg/util/provider/machinecodes/codes"
 	"github.com/gardener/machine-controller-manager/pkg/util/provider/machinecodes/status"
 	"github.com/gardener/machine-controller-manager/pkg/util/provider/machineutils"
+	"github.com/gardener/machine-controller-manager/pkg/util/provider/options"
 	utilstrings "github.com/gardener/machine-controller-manager/pkg/util/strings"
-	utiltime "github.com/gardener/machine-controller-manager/pkg/util/time"
 
 	v1 "k8s.io/api/core/v1"
 	storagev1 "k8s.io/api/storage/v1"
@@ -55,6 +55,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/wait"
 	storageclient "k8s.io/client-go/kubernetes/typed/storage/v1"
 	storagelisters "k8s.io/client-go/listers/storage/v1"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 )
 
@@ -893,6 +894,32 @@ func (c *controller) isHealthy(machine *v1alpha1.Machine) bool {
 	return true
 }
 
+// IsNodeHealthyAndSchedulable returns true if the node is Ready, not cordoned and none of the given
+// nodeConditions is in a bad state, i.e. the node can be returned to service after a transient problem.
+func IsNodeHealthyAndSchedulable(node *v1.Node, nodeConditions []string) bool {
+	if node == nil || node.Spec.Unschedulable {
+		return false
+	}
+	return isNodeHealthy(node, nodeConditions)
+}
+
+// isNodeHealthy returns true if the node is Ready and none of the given nodeConditions is in a bad state.
+func isNodeHealthy(node *v1.Node, nodeConditions []string) bool {
+	ready := false
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == v1.NodeReady {
+			ready = condition.Status == v1.ConditionTrue
+			continue
+		}
+		for _, c := range nodeConditions {
+			if string(condition.Type) == strings.TrimSpace(c) && condition.Status != v1.ConditionFalse {
+				return false
+			}
+		}
+	}
+	return ready
+}
+
 func criticalComponentsNotReadyTaintPresent(node *v1.Node) bool {
 	for _, taint := range node.Spec.Taints {
 		if taint.Key == machineutils.TaintNodeCriticalComponentsNotReady && taint.Effect == v1.TaintEffectNoSchedule {
@@ -1047,6 +1074,36 @@ func printLogInitError(s string, err *error, description *string, machine *v1alp
 	*description = fmt.Sprintf(s+" %s", machineutils.InitiateVMDeletion)
 }
 
+// RequiresDrain returns false if the machine never became ready, so that no workload could have been scheduled
+// on its node and draining it would only wait for the drain timeout. These are machines without a node, machines
+// still being created (Pending or CrashLoopBackOff) and machines which failed during their creation. All other
+// machines, including Terminating ones whose earlier phase is unknown, require a drain.
+func RequiresDrain(machine *v1alpha1.Machine) bool {
+	if getNodeName(machine) == "" {
+		return false
+	}
+	switch machine.Status.CurrentStatus.Phase {
+	case v1alpha1.MachinePending, v1alpha1.MachineCrashLoopBackOff:
+		return false
+	case v1alpha1.MachineFailed:
+		return machine.Status.LastOperation.Type != v1alpha1.MachineOperationCreate
+	default:
+		return true
+	}
+}
+
+// ShouldForceDelete returns whether the machine should be deleted immediately without draining its node,
+// along with the reason. There is no point in draining a machine whose node is already gone.
+func ShouldForceDelete(machine *v1alpha1.Machine, node *v1.Node) (bool, string) {
+	if node != nil {
+		return false, ""
+	}
+	if machine.Status.CurrentStatus.Phase == v1alpha1.MachineFailed {
+		return true, fmt.Sprintf("Skipping drain as machine is Failed and its node %q doesn't exist.", getNodeName(machine))
+	}
+	return true, fmt.Sprintf("Skipping drain as node %q for machine doesn't exist.", getNodeName(machine))
+}
+
 // drainNode attempts to drain the node backed by the machine object
 func (c *controller) drainNode(ctx context.Context, deleteMachineRequest *driver.DeleteMachineRequest) (machineutils.RetryPeriod, error) {
 	var (
@@ -1108,7 +1165,7 @@ func (c *controller) drainNode(ctx context.Context, deleteMachineRequest *driver
 	if skipDrain {
 		state = v1alpha1.MachineStateProcessing
 	} else {
-		timeOutOccurred = utiltime.HasTimeOutOccurred(*machine.DeletionTimestamp, timeOutDuration)
+		timeOutOccurred = DrainStrategyFor(machine, c.safetyOptions, time.Now()) == DrainStrategyForceDelete
 
 		if forceDeleteLabelPresent || timeOutOccurred {
 			// To perform forceful machine drain/delete either one of the below conditions must be satified
@@ -1428,15 +1485,43 @@ func (c *controller) deleteNodeObject(ctx context.Context, machine *v1alpha1.Mac
 	return machineutils.ShortRetry, err
 }
 
-// getEffectiveDrainTimeout returns the drainTimeout set on the machine-object, otherwise returns the timeout set using the global-flag.
+// getEffectiveDrainTimeout returns the drainTimeout set via annotation or on the machine-object, otherwise returns the timeout set using the global-flag.
 func (c *controller) getEffectiveDrainTimeout(machine *v1alpha1.Machine) *metav1.Duration {
-	var effectiveDrainTimeout *metav1.Duration
+	return &metav1.Duration{Duration: effectiveDrainTimeout(machine, c.safetyOptions)}
+}
+
+// effectiveDrainTimeout returns the drain timeout of the machine. The timeout set via the MachineDrainTimeout
+// annotation takes precedence over the one of the machine configuration, which in turn takes precedence over the
+// global MachineDrainTimeout.
+func effectiveDrainTimeout(machine *v1alpha1.Machine, o options.SafetyOptions) time.Duration {
+	timeout := o.MachineDrainTimeout.Duration
 	if machine.Spec.MachineConfiguration != nil && machine.Spec.MachineConfiguration.MachineDrainTimeout != nil {
-		effectiveDrainTimeout = machine.Spec.MachineConfiguration.MachineDrainTimeout
-	} else {
-		effectiveDrainTimeout = &c.safetyOptions.MachineDrainTimeout
+		timeout = machine.Spec.MachineConfiguration.MachineDrainTimeout.Duration
+	}
+	return machineutils.EffectiveDrainTimeout(machine, timeout)
+}
+
+// DrainStrategy is the strategy used to drain the node backed by a machine.
+type DrainStrategy string
+
+const (
+	// DrainStrategyEviction drains the node by evicting its pods, respecting PodDisruptionBudgets.
+	DrainStrategyEviction DrainStrategy = "Eviction"
+	// DrainStrategyForceDelete drains the node by force deleting its pods.
+	DrainStrategyForceDelete DrainStrategy = "ForceDelete"
+)
+
+// DrainStrategyFor returns the strategy to drain the node of the machine at the given time. Draining escalates
+// from eviction to force deletion once the drain timeout has elapsed since the deletion of the machine.
+// The drain timeout set via annotation or on the machine-object takes precedence over the global one.
+func DrainStrategyFor(machine *v1alpha1.Machine, o options.SafetyOptions, now time.Time) DrainStrategy {
+	if machine.DeletionTimestamp == nil {
+		return DrainStrategyEviction
+	}
+	if now.Sub(machine.DeletionTimestamp.Time) > effectiveDrainTimeout(machine, o) {
+		return DrainStrategyForceDelete
 	}
-	return effectiveDrainTimeout
+	return DrainStrategyEviction
 }
 
 // getEffectiveMaxEvictRetries returns the maxEvictRetries set on the machine-object, otherwise returns the evict retries set using the global-flag.
@@ -1450,15 +1535,9 @@ func (c *controller) getEffectiveMaxEvictRetries(machine *v1alpha1.Machine) *int
 	return maxEvictRetries
 }
 
-// getEffectiveHealthTimeout returns the healthTimeout set on the machine-object, otherwise returns the timeout set using the global-flag.
+// getEffectiveHealthTimeout returns the healthTimeout set via annotation or on the machine-object, otherwise returns the timeout set using the global-flag.
 func (c *controller) getEffectiveHealthTimeout(machine *v1alpha1.Machine) *metav1.Duration {
-	var effectiveHealthTimeout *metav1.Duration
-	if machine.Spec.MachineConfiguration != nil && machine.Spec.MachineConfiguration.MachineHealthTimeout != nil {
-		effectiveHealthTimeout = machine.Spec.MachineConfiguration.MachineHealthTimeout
-	} else {
-		effectiveHealthTimeout = &c.safetyOptions.MachineHealthTimeout
-	}
-	return effectiveHealthTimeout
+	return &metav1.Duration{Duration: EffectiveHealthTimeout(machine, c.safetyOptions)}
 }
 
 // getEffectiveHealthTimeout returns the creationTimeout set on the machine-object, otherwise returns the timeout set using the global-flag.
@@ -1627,6 +1706,62 @@ func (c *controller) waitForFailedMachineCacheUpdate(machine *v1alpha1.Machine,
 	return true
 }
 
+// ComputeMachineConditions derives the conditions a machine should carry from the state of its node and its phase.
+// The machine mirrors the conditions of its node. Additionally, a terminating machine carries the termination
+// condition and a machine being updated in-place carries the in-place update condition, even if these have not
+// been set on the node yet. For a machine whose node is not yet provisioned, only the phase derived conditions
+// are returned. Machine conditions are node conditions, as the machine status does not define its own type.
+func ComputeMachineConditions(machine *v1alpha1.Machine, node *v1.Node, now time.Time) []v1.NodeCondition {
+	var conditions []v1.NodeCondition
+	if node != nil {
+		conditions = make([]v1.NodeCondition, 0, len(node.Status.Conditions)+1)
+		for _, condition := range node.Status.Conditions {
+			conditions = append(conditions, *condition.DeepCopy())
+		}
+	}
+
+	hasCondition := func(conditionType v1.NodeConditionType) bool {
+		for _, condition := range conditions {
+			if condition.Type == conditionType {
+				return true
+			}
+		}
+		return false
+	}
+	newCondition := func(conditionType v1.NodeConditionType, reason string) v1.NodeCondition {
+		return v1.NodeCondition{
+			Type:               conditionType,
+			Status:             v1.ConditionTrue,
+			Reason:             reason,
+			LastHeartbeatTime:  metav1.NewTime(now),
+			LastTransitionTime: metav1.NewTime(now),
+		}
+	}
+
+	switch machine.Status.CurrentStatus.Phase {
+	case v1alpha1.MachineTerminating:
+		if !hasCondition(machineutils.NodeTerminationCondition) {
+			terminationCondition := newCondition(machineutils.NodeTerminationCondition, "")
+			setTerminationReasonByPhase(machine.Status.CurrentStatus.Phase, &terminationCondition)
+			conditions = append(conditions, terminationCondition)
+		}
+	case v1alpha1.MachineInPlaceUpdating:
+		if !hasCondition(v1alpha1.NodeInPlaceUpdate) {
+			conditions = append(conditions, newCondition(v1alpha1.NodeInPlaceUpdate, v1alpha1.CandidateForUpdate))
+		}
+	case v1alpha1.MachineInPlaceUpdateSuccessful:
+		if !hasCondition(v1alpha1.NodeInPlaceUpdate) {
+			conditions = append(conditions, newCondition(v1alpha1.NodeInPlaceUpdate, v1alpha1.UpdateSuccessful))
+		}
+	case v1alpha1.MachineInPlaceUpdateFailed:
+		if !hasCondition(v1alpha1.NodeInPlaceUpdate) {
+			conditions = append(conditions, newCondition(v1alpha1.NodeInPlaceUpdate, v1alpha1.UpdateFailed))
+		}
+	}
+
+	return conditions
+}
+
 func setTerminationReasonByPhase(phase v1alpha1.MachinePhase, terminationCondition *v1.NodeCondition) {
 	if phase == v1alpha1.MachineFailed { // if failed, terminated due to health
 		terminationCondition.Reason = machineutils.NodeUnhealthy
@@ -1750,3 +1885,58 @@ func (c *controller) fetchMatchingNodeName(machineName string) (string, error) {
 	}
 	return "", fmt.Errorf("machine %q not found in node lister for machine %q", machineName, machineName)
 }
+
+// MaxConcurrentDrains returns the number of machines that may be drained simultaneously,
+// computed as min(hardCap, ceil(totalMachines*fraction)). A non-positive hardCap disables the cap.
+// The result is never lower than 1 so that draining always makes progress.
+func MaxConcurrentDrains(totalMachines int32, fraction float64, hardCap int32) int32 {
+	maxDrains := int32(1)
+	if totalMachines > 0 && fraction > 0 {
+		maxDrains = int32(math.Ceil(float64(totalMachines) * fraction))
+	}
+	if hardCap > 0 && maxDrains > hardCap {
+		maxDrains = hardCap
+	}
+	if maxDrains < 1 {
+		maxDrains = 1
+	}
+	return maxDrains
+}
+
+// CrashLoopBackoffDuration returns the delay after which a machine in CrashLoopBackOff is reconciled again,
+// computed as base*2^restartCount and capped at maxBackoff, to avoid tight retry loops against a
+// persistently failing machine. A non-positive restartCount returns base.
+func CrashLoopBackoffDuration(restartCount int, base, maxBackoff time.Duration) time.Duration {
+	if base <= 0 || base >= maxBackoff {
+		return maxBackoff
+	}
+	backoff := base
+	for i := 0; i < restartCount; i++ {
+		// compare before doubling so that the duration never overflows
+		if backoff > maxBackoff/2 {
+			return maxBackoff
+		}
+		backoff *= 2
+	}
+	return backoff
+}
+
+// RecordPhaseTransition records an event on the machine for its transition from one phase to another, with the
+// target phase as reason, e.g. "MachineRunning". Transitions into a failure phase are recorded as warnings.
+// No-op transitions are not recorded.
+func RecordPhaseTransition(recorder record.EventRecorder, machine *v1alpha1.Machine, from, to v1alpha1.MachinePhase) {
+	if from == to || to == "" {
+		return
+	}
+	eventType := v1.EventTypeNormal
+	switch to {
+	case v1alpha1.MachineFailed, v1alpha1.MachineCrashLoopBackOff, v1alpha1.MachineUnknown, v1alpha1.MachineInPlaceUpdateFailed:
+		eventType = v1.EventTypeWarning
+	}
+	reason := "Machine" + string(to)
+	if from == "" {
+		recorder.Eventf(machine, eventType, reason, "Machine transitioned to %s", to)
+		return
+	}
+	recorder.Eventf(machine, eventType, reason, "Machine transitioned from %s to %s", from, to)
+}
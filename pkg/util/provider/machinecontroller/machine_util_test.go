@@ -15,12 +15,14 @@ import (
 	"github.com/gardener/machine-controller-manager/pkg/fakeclient"
 	"github.com/gardener/machine-controller-manager/pkg/util/permits"
 	"github.com/gardener/machine-controller-manager/pkg/util/provider/machineutils"
+	"github.com/gardener/machine-controller-manager/pkg/util/provider/options"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 )
 
@@ -2711,4 +2713,258 @@ var _ = Describe("machine_util", func() {
 			}),
 		)
 	})
+
+	Describe("#MaxConcurrentDrains", func() {
+		DescribeTable("##table",
+			func(totalMachines int32, fraction float64, hardCap int32, expected int32) {
+				Expect(MaxConcurrentDrains(totalMachines, fraction, hardCap)).To(Equal(expected))
+			},
+			Entry("should round up fractional results", int32(10), 0.25, int32(100), int32(3)),
+			Entry("should return the exact value when there is no remainder", int32(10), 0.5, int32(100), int32(5)),
+			Entry("should cap the result at hardCap", int32(100), 0.5, int32(10), int32(10)),
+			Entry("should ignore a non-positive hardCap", int32(100), 0.5, int32(0), int32(50)),
+			Entry("should return at least 1 for a tiny fraction", int32(10), 0.01, int32(100), int32(1)),
+			Entry("should return at least 1 for zero machines", int32(0), 0.5, int32(100), int32(1)),
+			Entry("should return at least 1 for a zero fraction", int32(10), 0.0, int32(100), int32(1)),
+		)
+	})
+
+	Describe("#ComputeMachineConditions", func() {
+		now := time.Now()
+		readyCondition := corev1.NodeCondition{Type: corev1.NodeReady, Status: corev1.ConditionTrue}
+
+		newMachineInPhase := func(phase machinev1.MachinePhase) *machinev1.Machine {
+			return &machinev1.Machine{
+				ObjectMeta: metav1.ObjectMeta{Name: "machine-0", Namespace: testNamespace},
+				Status: machinev1.MachineStatus{
+					CurrentStatus: machinev1.CurrentStatus{Phase: phase},
+				},
+			}
+		}
+		newNodeWithConditions := func(conditions ...corev1.NodeCondition) *corev1.Node {
+			return &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: "node-0"},
+				Status:     corev1.NodeStatus{Conditions: conditions},
+			}
+		}
+
+		It("should return no conditions for a machine without node", func() {
+			Expect(ComputeMachineConditions(newMachineInPhase(machinev1.MachinePending), nil, now)).To(BeEmpty())
+		})
+
+		It("should mirror the node conditions for a running machine", func() {
+			conditions := ComputeMachineConditions(newMachineInPhase(machinev1.MachineRunning), newNodeWithConditions(readyCondition), now)
+			Expect(conditions).To(Equal([]corev1.NodeCondition{readyCondition}))
+		})
+
+		It("should add the termination condition for a terminating machine", func() {
+			conditions := ComputeMachineConditions(newMachineInPhase(machinev1.MachineTerminating), newNodeWithConditions(readyCondition), now)
+			Expect(conditions).To(HaveLen(2))
+			Expect(conditions[1].Type).To(Equal(machineutils.NodeTerminationCondition))
+			Expect(conditions[1].Status).To(Equal(corev1.ConditionTrue))
+			Expect(conditions[1].Reason).To(Equal(machineutils.NodeScaledDown))
+			Expect(conditions[1].LastTransitionTime.Time).To(Equal(metav1.NewTime(now).Time))
+		})
+
+		It("should add the termination condition for a terminating machine without node", func() {
+			conditions := ComputeMachineConditions(newMachineInPhase(machinev1.MachineTerminating), nil, now)
+			Expect(conditions).To(HaveLen(1))
+			Expect(conditions[0].Type).To(Equal(machineutils.NodeTerminationCondition))
+		})
+
+		It("should keep the termination condition already set on the node", func() {
+			terminationCondition := corev1.NodeCondition{Type: machineutils.NodeTerminationCondition, Status: corev1.ConditionTrue, Reason: machineutils.NodeUnhealthy}
+			conditions := ComputeMachineConditions(newMachineInPhase(machinev1.MachineTerminating), newNodeWithConditions(readyCondition, terminationCondition), now)
+			Expect(conditions).To(Equal([]corev1.NodeCondition{readyCondition, terminationCondition}))
+		})
+
+		DescribeTable("should add the in-place update condition for machines being updated in-place",
+			func(phase machinev1.MachinePhase, reason string) {
+				conditions := ComputeMachineConditions(newMachineInPhase(phase), newNodeWithConditions(readyCondition), now)
+				Expect(conditions).To(HaveLen(2))
+				Expect(conditions[1].Type).To(Equal(machinev1.NodeInPlaceUpdate))
+				Expect(conditions[1].Reason).To(Equal(reason))
+			},
+			Entry("in-place updating", machinev1.MachineInPlaceUpdating, machinev1.CandidateForUpdate),
+			Entry("in-place update successful", machinev1.MachineInPlaceUpdateSuccessful, machinev1.UpdateSuccessful),
+			Entry("in-place update failed", machinev1.MachineInPlaceUpdateFailed, machinev1.UpdateFailed),
+		)
+
+		It("should not modify the node conditions", func() {
+			node := newNodeWithConditions(readyCondition)
+			conditions := ComputeMachineConditions(newMachineInPhase(machinev1.MachineRunning), node, now)
+			conditions[0].Status = corev1.ConditionFalse
+			Expect(node.Status.Conditions[0].Status).To(Equal(corev1.ConditionTrue))
+		})
+	})
+
+	Describe("#RecordPhaseTransition", func() {
+		machine := &machinev1.Machine{ObjectMeta: metav1.ObjectMeta{Name: "machine-0", Namespace: testNamespace}}
+
+		DescribeTable("##table",
+			func(from, to machinev1.MachinePhase, expected []string) {
+				recorder := record.NewFakeRecorder(10)
+				RecordPhaseTransition(recorder, machine, from, to)
+				close(recorder.Events)
+
+				var events []string
+				for event := range recorder.Events {
+					events = append(events, event)
+				}
+				Expect(events).To(Equal(expected))
+			},
+			Entry("should record a transition", machinev1.MachinePending, machinev1.MachineRunning,
+				[]string{"Normal MachineRunning Machine transitioned from Pending to Running"}),
+			Entry("should record a transition into a failure phase as warning", machinev1.MachineRunning, machinev1.MachineUnknown,
+				[]string{"Warning MachineUnknown Machine transitioned from Running to Unknown"}),
+			Entry("should record the initial phase", machinev1.MachinePhase(""), machinev1.MachinePending,
+				[]string{"Normal MachinePending Machine transitioned to Pending"}),
+			Entry("should suppress no-op transitions", machinev1.MachineRunning, machinev1.MachineRunning, nil),
+		)
+	})
+
+	Describe("#CrashLoopBackoffDuration", func() {
+		DescribeTable("##table",
+			func(restartCount int, base, maxBackoff, expected time.Duration) {
+				Expect(CrashLoopBackoffDuration(restartCount, base, maxBackoff)).To(Equal(expected))
+			},
+			Entry("should return base for the first restart", 0, 10*time.Second, 5*time.Minute, 10*time.Second),
+			Entry("should return base for a negative restart count", -1, 10*time.Second, 5*time.Minute, 10*time.Second),
+			Entry("should double with every restart", 1, 10*time.Second, 5*time.Minute, 20*time.Second),
+			Entry("should grow exponentially", 4, 10*time.Second, 5*time.Minute, 160*time.Second),
+			Entry("should cap at max", 5, 10*time.Second, 5*time.Minute, 5*time.Minute),
+			Entry("should cap at max without overflowing for huge restart counts", 1000, 10*time.Second, 5*time.Minute, 5*time.Minute),
+			Entry("should return max if base exceeds it", 0, 10*time.Minute, 5*time.Minute, 5*time.Minute),
+			Entry("should return max for a non-positive base", 3, time.Duration(0), 5*time.Minute, 5*time.Minute),
+		)
+	})
+
+	Describe("#ShouldForceDelete", func() {
+		newDeletingMachine := func(phase machinev1.MachinePhase) *machinev1.Machine {
+			return &machinev1.Machine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "machine-0",
+					Namespace: testNamespace,
+					Labels:    map[string]string{machinev1.NodeLabelKey: "node-0"},
+				},
+				Status: machinev1.MachineStatus{
+					CurrentStatus: machinev1.CurrentStatus{Phase: phase},
+				},
+			}
+		}
+		node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-0"}}
+
+		DescribeTable("##table",
+			func(machine *machinev1.Machine, node *corev1.Node, expected bool) {
+				forceDelete, reason := ShouldForceDelete(machine, node)
+				Expect(forceDelete).To(Equal(expected))
+				if expected {
+					Expect(reason).To(ContainSubstring("node-0"))
+				} else {
+					Expect(reason).To(BeEmpty())
+				}
+			},
+			Entry("should force delete a failed machine with a missing node", newDeletingMachine(machinev1.MachineFailed), nil, true),
+			Entry("should force delete a running machine with a missing node", newDeletingMachine(machinev1.MachineRunning), nil, true),
+			Entry("should not force delete a running machine with a present node", newDeletingMachine(machinev1.MachineRunning), node, false),
+			Entry("should not force delete a failed machine with a present node", newDeletingMachine(machinev1.MachineFailed), node, false),
+		)
+	})
+
+	Describe("#DrainStrategyFor", func() {
+		now := time.Now()
+		safetyOptions := options.SafetyOptions{MachineDrainTimeout: metav1.Duration{Duration: 10 * time.Minute}}
+		newDeletedMachine := func(deletedFor time.Duration, drainTimeout *metav1.Duration) *machinev1.Machine {
+			machine := &machinev1.Machine{ObjectMeta: metav1.ObjectMeta{Name: "machine-0", Namespace: testNamespace}}
+			if deletedFor >= 0 {
+				machine.DeletionTimestamp = &metav1.Time{Time: now.Add(-deletedFor)}
+			}
+			if drainTimeout != nil {
+				machine.Spec.MachineConfiguration = &machinev1.MachineConfiguration{MachineDrainTimeout: drainTimeout}
+			}
+			return machine
+		}
+
+		DescribeTable("##table",
+			func(machine *machinev1.Machine, expected DrainStrategy) {
+				Expect(DrainStrategyFor(machine, safetyOptions, now)).To(Equal(expected))
+			},
+			Entry("should evict for a machine which isn't deleted", newDeletedMachine(-1, nil), DrainStrategyEviction),
+			Entry("should evict before the drain timeout", newDeletedMachine(5*time.Minute, nil), DrainStrategyEviction),
+			Entry("should evict exactly at the drain timeout", newDeletedMachine(10*time.Minute, nil), DrainStrategyEviction),
+			Entry("should force delete after the drain timeout", newDeletedMachine(10*time.Minute+time.Second, nil), DrainStrategyForceDelete),
+			Entry("should prefer the drain timeout of the machine", newDeletedMachine(5*time.Minute, &metav1.Duration{Duration: 2 * time.Minute}), DrainStrategyForceDelete),
+		)
+
+		It("should prefer the drain timeout set via annotation", func() {
+			machine := newDeletedMachine(15*time.Minute, nil)
+			machine.Annotations = map[string]string{machineutils.MachineDrainTimeout: "20m"}
+			Expect(DrainStrategyFor(machine, safetyOptions, now)).To(Equal(DrainStrategyEviction))
+
+			machine = newDeletedMachine(25*time.Minute, nil)
+			machine.Annotations = map[string]string{machineutils.MachineDrainTimeout: "1h"}
+			Expect(DrainStrategyFor(machine, safetyOptions, now)).To(Equal(DrainStrategyForceDelete))
+		})
+	})
+
+	Describe("#IsNodeHealthyAndSchedulable", func() {
+		nodeConditions := []string{"KernelDeadlock", "ReadonlyFilesystem"}
+		newConditionNode := func(unschedulable bool, conditions ...corev1.NodeCondition) *corev1.Node {
+			return &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: "node-0"},
+				Spec:       corev1.NodeSpec{Unschedulable: unschedulable},
+				Status:     corev1.NodeStatus{Conditions: conditions},
+			}
+		}
+		ready := corev1.NodeCondition{Type: corev1.NodeReady, Status: corev1.ConditionTrue}
+		notReady := corev1.NodeCondition{Type: corev1.NodeReady, Status: corev1.ConditionFalse}
+		kernelDeadlock := corev1.NodeCondition{Type: "KernelDeadlock", Status: corev1.ConditionTrue}
+		noKernelDeadlock := corev1.NodeCondition{Type: "KernelDeadlock", Status: corev1.ConditionFalse}
+		unknownFilesystem := corev1.NodeCondition{Type: "ReadonlyFilesystem", Status: corev1.ConditionUnknown}
+		diskPressure := corev1.NodeCondition{Type: corev1.NodeDiskPressure, Status: corev1.ConditionTrue}
+
+		DescribeTable("##table",
+			func(node *corev1.Node, expected bool) {
+				Expect(IsNodeHealthyAndSchedulable(node, nodeConditions)).To(Equal(expected))
+			},
+			Entry("should return true for a ready, schedulable node", newConditionNode(false, ready, noKernelDeadlock), true),
+			Entry("should ignore conditions which are not configured", newConditionNode(false, ready, diskPressure), true),
+			Entry("should return false for a cordoned node", newConditionNode(true, ready), false),
+			Entry("should return false for a node which is not ready", newConditionNode(false, notReady), false),
+			Entry("should return false for a node without a ready condition", newConditionNode(false, noKernelDeadlock), false),
+			Entry("should return false for a configured condition which is true", newConditionNode(false, ready, kernelDeadlock), false),
+			Entry("should return false for a configured condition which is unknown", newConditionNode(false, ready, unknownFilesystem), false),
+			Entry("should return false for a missing node", nil, false),
+		)
+	})
+
+	Describe("#RequiresDrain", func() {
+		newPhaseMachine := func(phase machinev1.MachinePhase, lastOperationType machinev1.MachineOperationType, nodeName string) *machinev1.Machine {
+			return &machinev1.Machine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "machine-0",
+					Namespace: testNamespace,
+					Labels:    map[string]string{machinev1.NodeLabelKey: nodeName},
+				},
+				Status: machinev1.MachineStatus{
+					CurrentStatus: machinev1.CurrentStatus{Phase: phase},
+					LastOperation: machinev1.LastOperation{Type: lastOperationType},
+				},
+			}
+		}
+
+		DescribeTable("##table",
+			func(machine *machinev1.Machine, expected bool) {
+				Expect(RequiresDrain(machine)).To(Equal(expected))
+			},
+			Entry("should not drain a machine stuck in Pending", newPhaseMachine(machinev1.MachinePending, machinev1.MachineOperationCreate, "node-0"), false),
+			Entry("should not drain a machine in CrashLoopBackOff", newPhaseMachine(machinev1.MachineCrashLoopBackOff, machinev1.MachineOperationCreate, "node-0"), false),
+			Entry("should not drain a machine which failed during creation", newPhaseMachine(machinev1.MachineFailed, machinev1.MachineOperationCreate, "node-0"), false),
+			Entry("should not drain a machine without a node", newPhaseMachine(machinev1.MachineRunning, machinev1.MachineOperationCreate, ""), false),
+			Entry("should drain a running machine", newPhaseMachine(machinev1.MachineRunning, machinev1.MachineOperationCreate, "node-0"), true),
+			Entry("should drain an available machine", newPhaseMachine(machinev1.MachineAvailable, machinev1.MachineOperationCreate, "node-0"), true),
+			Entry("should drain a machine which failed its health check after running", newPhaseMachine(machinev1.MachineFailed, machinev1.MachineOperationHealthCheck, "node-0"), true),
+			Entry("should drain a terminating machine", newPhaseMachine(machinev1.MachineTerminating, machinev1.MachineOperationDelete, "node-0"), true),
+		)
+	})
 })
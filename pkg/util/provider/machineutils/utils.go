@@ -9,6 +9,7 @@ import (
 	"time"
 
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
 
 	"github.com/gardener/machine-controller-manager/pkg/apis/machine/v1alpha1"
 )
@@ -47,6 +48,40 @@ const (
 	// Default priority for a machine is set to 3
 	MachinePriority = "machinepriority.machine.sapcloud.io"
 
+	// MachineDeletionCost is the annotation used to specify the cost of deleting a machine on scale down,
+	// analogous to the pod deletion cost. Machines with a lower cost are deleted first. The value is an
+	// integer, may be negative and takes precedence over the MachinePriority annotation. Default cost is 0
+	MachineDeletionCost = "machine.sapcloud.io/deletion-cost"
+
+	// MachineSetMachinePriority is the annotation on a machineSet specifying the MachinePriority
+	// stamped on the machines it creates
+	MachineSetMachinePriority = "machineset.sapcloud.io/machine-priority"
+
+	// MachineSetPriority is the annotation on a machineSet specifying its priority while scaling down multiple
+	// machineSets, e.g. surge machineSets of a rolling update. The lower the priority, the earlier it is scaled down.
+	// Default priority for a machineSet is 3
+	MachineSetPriority = "machineset.sapcloud.io/priority"
+
+	// MachineSetCreationTimeout is the annotation on a machineSet overriding the machine creation timeout
+	// for its machines. The value is parsed as a duration, e.g. "30m"
+	MachineSetCreationTimeout = "machine.sapcloud.io/creation-timeout"
+
+	// MachineHealthTimeout is the annotation on a machine overriding the machine health timeout for it.
+	// The value is parsed as a duration, e.g. "5m"
+	MachineHealthTimeout = "machine.sapcloud.io/health-timeout"
+
+	// MachineDrainTimeout is the annotation on a machine overriding the machine drain timeout for it.
+	// The value is parsed as a duration, e.g. "30m", and bounded by MaxDrainTimeoutOverrideFactor
+	MachineDrainTimeout = "machine.sapcloud.io/drain-timeout"
+
+	// MaxDrainTimeoutOverrideFactor bounds the drain timeout set via the MachineDrainTimeout annotation
+	// to this multiple of the default drain timeout, to prevent runaway drains
+	MaxDrainTimeoutOverrideFactor = 2
+
+	// MachineNoDelete is the annotation which, set to "true", protects a machine against deletion on scale down,
+	// e.g. to hold it for a manual investigation
+	MachineNoDelete = "machine.sapcloud.io/no-delete"
+
 	// MachineClassKind is used to identify the machineClassKind for generic machineClasses
 	MachineClassKind = "MachineClass"
 
@@ -119,3 +154,42 @@ func IsMachineFailed(p *v1alpha1.Machine) bool {
 func IsMachineTriggeredForDeletion(m *v1alpha1.Machine) bool {
 	return m.Annotations[MachinePriority] == "1" || m.Annotations[TriggerDeletionByMCM] == "true"
 }
+
+// EffectiveHealthTimeout returns the health timeout of the machine via the MachineHealthTimeout annotation,
+// or defaultTimeout if the annotation is absent. Values which are not a positive duration are logged and ignored.
+func EffectiveHealthTimeout(machine *v1alpha1.Machine, defaultTimeout time.Duration) time.Duration {
+	if timeout, ok := durationFromAnnotation(machine, MachineHealthTimeout, defaultTimeout); ok {
+		return timeout
+	}
+	return defaultTimeout
+}
+
+// EffectiveDrainTimeout returns the drain timeout of the machine via the MachineDrainTimeout annotation,
+// or defaultTimeout if the annotation is absent. Values which are not a positive duration are logged and ignored,
+// and values beyond MaxDrainTimeoutOverrideFactor times the defaultTimeout are clamped.
+func EffectiveDrainTimeout(machine *v1alpha1.Machine, defaultTimeout time.Duration) time.Duration {
+	timeout, ok := durationFromAnnotation(machine, MachineDrainTimeout, defaultTimeout)
+	if !ok {
+		return defaultTimeout
+	}
+	if maxTimeout := MaxDrainTimeoutOverrideFactor * defaultTimeout; timeout > maxTimeout {
+		klog.Warningf("Drain timeout %v set via annotation %q on machine %q exceeds the maximum, using %v", timeout, MachineDrainTimeout, machine.Name, maxTimeout)
+		return maxTimeout
+	}
+	return timeout
+}
+
+// durationFromAnnotation returns the positive duration set via the given annotation on the machine, if any.
+// Invalid values are logged along with the defaultTimeout which is used instead.
+func durationFromAnnotation(machine *v1alpha1.Machine, annotation string, defaultTimeout time.Duration) (time.Duration, bool) {
+	value, ok := machine.Annotations[annotation]
+	if !ok {
+		return 0, false
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout <= 0 {
+		klog.Warningf("Invalid value %q for annotation %q on machine %q, using the timeout %v", value, annotation, machine.Name, defaultTimeout)
+		return 0, false
+	}
+	return timeout, true
+}
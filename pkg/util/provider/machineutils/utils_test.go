@@ -0,0 +1,50 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package machineutils_test
+
+import (
+	"time"
+
+	"github.com/gardener/machine-controller-manager/pkg/apis/machine/v1alpha1"
+	. "github.com/gardener/machine-controller-manager/pkg/util/provider/machineutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newAnnotatedMachine(annotations map[string]string) *v1alpha1.Machine {
+	return &v1alpha1.Machine{
+		ObjectMeta: metav1.ObjectMeta{Name: "machine-0", Namespace: "test", Annotations: annotations},
+	}
+}
+
+var _ = Describe("machineutils", func() {
+	Describe("#EffectiveHealthTimeout", func() {
+		DescribeTable("##table",
+			func(machine *v1alpha1.Machine, expected time.Duration) {
+				Expect(EffectiveHealthTimeout(machine, 10*time.Minute)).To(Equal(expected))
+			},
+			Entry("should use the timeout from the annotation", newAnnotatedMachine(map[string]string{MachineHealthTimeout: "30m"}), 30*time.Minute),
+			Entry("should fall back to the default without the annotation", newAnnotatedMachine(nil), 10*time.Minute),
+			Entry("should fall back to the default for an invalid value", newAnnotatedMachine(map[string]string{MachineHealthTimeout: "later"}), 10*time.Minute),
+			Entry("should fall back to the default for a zero value", newAnnotatedMachine(map[string]string{MachineHealthTimeout: "0s"}), 10*time.Minute),
+		)
+	})
+
+	Describe("#EffectiveDrainTimeout", func() {
+		DescribeTable("##table",
+			func(machine *v1alpha1.Machine, expected time.Duration) {
+				Expect(EffectiveDrainTimeout(machine, 2*time.Hour)).To(Equal(expected))
+			},
+			Entry("should use a shorter timeout from the annotation", newAnnotatedMachine(map[string]string{MachineDrainTimeout: "30m"}), 30*time.Minute),
+			Entry("should use a longer timeout from the annotation", newAnnotatedMachine(map[string]string{MachineDrainTimeout: "3h"}), 3*time.Hour),
+			Entry("should use a timeout at the maximum", newAnnotatedMachine(map[string]string{MachineDrainTimeout: "4h"}), 4*time.Hour),
+			Entry("should clamp a timeout beyond the maximum", newAnnotatedMachine(map[string]string{MachineDrainTimeout: "48h"}), 4*time.Hour),
+			Entry("should fall back to the default without the annotation", newAnnotatedMachine(nil), 2*time.Hour),
+			Entry("should fall back to the default for an invalid value", newAnnotatedMachine(map[string]string{MachineDrainTimeout: "forever"}), 2*time.Hour),
+			Entry("should fall back to the default for a negative value", newAnnotatedMachine(map[string]string{MachineDrainTimeout: "-1h"}), 2*time.Hour),
+		)
+	})
+})
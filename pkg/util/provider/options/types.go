@@ -107,6 +107,12 @@ type SafetyOptions struct {
 	// Period (in duration) used to poll for APIServer's health
 	// by safety controller
 	MachineSafetyAPIServerStatusCheckPeriod metav1.Duration
+	// Maximum number of failed machines which are replaced concurrently,
+	// to avoid worsening an outage affecting many machines at once. Zero means unlimited
+	MaxConcurrentReplacements int32
+	// Cooldown (in duration) after replacing a failed machine of a machine-set,
+	// within which no further machine of it is replaced. Zero means no cooldown
+	ReplacementCooldown metav1.Duration
 
 	// APIserverInactiveStartTime to keep track of the
 	// start time of when the APIServers were not reachable
@@ -0,0 +1,58 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package worker
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// DefaultQueueDepthReportPeriod is the default period at which a QueueDepthReporter updates the queue depth gauge.
+const DefaultQueueDepthReportPeriod = 15 * time.Second
+
+// ReconcileQueueDepth is the number of keys waiting to be reconciled per controller, to alert on a growing backlog.
+// It is not registered by this package, the binaries running the reporters register it with their metrics registry.
+var ReconcileQueueDepth = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "mcm",
+	Name:      "reconcile_queue_depth",
+	Help:      "Number of keys waiting in the reconcile queue of a controller.",
+}, []string{"controller"})
+
+// QueueDepthReporter reports the depth of the reconcile queue of a controller via the ReconcileQueueDepth gauge.
+// It is safe to use concurrently with workers adding and processing keys of the queue.
+type QueueDepthReporter struct {
+	controller string
+	queue      workqueue.Interface
+}
+
+// NewQueueDepthReporter returns a QueueDepthReporter for the queue of the given controller.
+func NewQueueDepthReporter(controller string, queue workqueue.Interface) *QueueDepthReporter {
+	return &QueueDepthReporter{controller: controller, queue: queue}
+}
+
+// Depth returns the number of keys waiting in the queue, excluding the ones being processed.
+func (r *QueueDepthReporter) Depth() int {
+	return r.queue.Len()
+}
+
+// Report sets the ReconcileQueueDepth gauge of the controller to the current depth of the queue.
+func (r *QueueDepthReporter) Report() {
+	ReconcileQueueDepth.WithLabelValues(r.controller).Set(float64(r.Depth()))
+}
+
+// Run reports the depth of the queue every period until stopCh is closed, after which the gauge of the
+// controller is dropped. The reporter will be added to the wait group when started and marked done when finished.
+func (r *QueueDepthReporter) Run(period time.Duration, stopCh <-chan struct{}, waitGroup *sync.WaitGroup) {
+	waitGroup.Add(1)
+	go func() {
+		wait.Until(r.Report, period, stopCh)
+		ReconcileQueueDepth.DeleteLabelValues(r.controller)
+		waitGroup.Done()
+	}()
+}
@@ -0,0 +1,83 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package worker
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/client-go/util/workqueue"
+)
+
+var _ = Describe("queue_depth", func() {
+	var queue workqueue.RateLimitingInterface
+
+	BeforeEach(func() {
+		queue = workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	})
+
+	AfterEach(func() {
+		queue.ShutDown()
+		ReconcileQueueDepth.Reset()
+	})
+
+	Describe("#Depth", func() {
+		It("should count the keys waiting in the queue", func() {
+			reporter := NewQueueDepthReporter("ClusterMachineSet", queue)
+			queue.Add("test/machineset-0")
+			queue.Add("test/machineset-1")
+			Expect(reporter.Depth()).To(Equal(2))
+
+			key, _ := queue.Get()
+			Expect(reporter.Depth()).To(Equal(1))
+			queue.Done(key)
+		})
+
+		It("should be safe to query while keys are added and processed", func() {
+			reporter := NewQueueDepthReporter("ClusterMachineSet", queue)
+			var wg sync.WaitGroup
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 100; i++ {
+					queue.Add(fmt.Sprintf("test/machineset-%d", i))
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 50; i++ {
+					key, _ := queue.Get()
+					queue.Done(key)
+				}
+			}()
+			for i := 0; i < 100; i++ {
+				Expect(reporter.Depth()).To(BeNumerically(">=", 0))
+			}
+			wg.Wait()
+			Expect(reporter.Depth()).To(Equal(50))
+		})
+	})
+
+	Describe("#Run", func() {
+		It("should report the depth periodically and drop the gauge when stopped", func() {
+			stopCh := make(chan struct{})
+			var wg sync.WaitGroup
+			NewQueueDepthReporter("ClusterMachineSet", queue).Run(10*time.Millisecond, stopCh, &wg)
+
+			queue.Add("test/machineset-0")
+			Eventually(func() float64 {
+				return testutil.ToFloat64(ReconcileQueueDepth.WithLabelValues("ClusterMachineSet"))
+			}).Should(Equal(1.0))
+
+			close(stopCh)
+			wg.Wait()
+			Expect(testutil.CollectAndCount(ReconcileQueueDepth)).To(BeZero())
+		})
+	})
+})